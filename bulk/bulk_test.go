@@ -0,0 +1,199 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductVariant{}, &models.StockMovement{}, &models.OutboxEvent{}))
+	return db
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestCSVReader(t *testing.T) {
+	_, err := NewCSVReader(strings.NewReader("name,colour\n"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+	_, err = NewCSVReader(strings.NewReader("name,Name\n"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	input := "\ufeffSKU,name,price,currency,stock,is_available,reserved\n" +
+		"A-1,\"Desk, oak\",199.50,,5,false,3\n" +
+		"A-2,Chair,12.345,,,,\n" +
+		"A-3,Lamp,,,x,,\n"
+	r, err := NewCSVReader(strings.NewReader(input))
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, 2, row.Line)
+	assert.Equal(t, "A-1", *row.SKU)
+	assert.Equal(t, "Desk, oak", *row.Name)
+	assert.Equal(t, models.NewMoney(19950, "USD"), *row.Price)
+	assert.Equal(t, 5, *row.Stock)
+	assert.False(t, *row.IsAvailable)
+	assert.Nil(t, row.Description)
+
+	// 价格超出货币精度、库存不是整数
+	_, err = r.Read()
+	var rowErr *RowError
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, RowError{Line: 3, SKU: "A-2", Field: "price", Message: rowErr.Message}, *rowErr)
+	_, err = r.Read()
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, "stock", rowErr.Field)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestJSONLReader(t *testing.T) {
+	input := `{"sku": "B-1", "name": "Mug", "price": {"amount": "8.00", "currency": "USD"}, "reserved": 2}
+
+{"sku": "B-2", "colour": "red"}
+{"sku": "B-3", "stock": "many"}
+{"id": 7, "price": 9.5, "description": null}
+`
+	r := NewJSONLReader(strings.NewReader(input))
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, 1, row.Line)
+	assert.Equal(t, models.NewMoney(800, "USD"), *row.Price)
+
+	var rowErr *RowError
+	_, err = r.Read()
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, 3, rowErr.Line)
+	assert.Equal(t, "colour", rowErr.Field)
+	_, err = r.Read()
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, "stock", rowErr.Field)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), *row.ID)
+	assert.Equal(t, models.NewMoney(950, "USD"), *row.Price)
+	assert.Nil(t, row.Description)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestImport_UpsertAndDryRun(t *testing.T) {
+	db := setupTestDB(t)
+	products := repository.NewProductRepository(db)
+	existing := &models.Product{SKU: strPtr("LAMP-1"), Name: "Lamp", Price: models.NewMoney(2000, "USD"), Stock: 4, IsAvailable: true}
+	require.NoError(t, products.Create(existing))
+
+	input := "id,sku,name,price,stock,category\n" +
+		",LAMP-1,Desk lamp,25.00,10,\n" + // 按 SKU 更新，库存调整记入流水
+		",CHAIR-1,Chair,45.00,3,Furniture\n" + // 创建，按名称创建分类
+		",,,10.00,,\n" + // 缺少名称
+		"999,,Ghost,1.00,,\n" + // id 不存在
+		",CHAIR-2,Free chair,0,,\n" + // 价格必须为正数
+		",CHAIR-1,Chair v2,,,\n" // 同一文件中再次更新刚创建的产品
+
+	// 试运行：返回相同的结果但不写入
+	r, err := NewCSVReader(strings.NewReader(input))
+	require.NoError(t, err)
+	result, err := Import(context.Background(), db, r, Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, Result{DryRun: true, Total: 6, Created: 1, Updated: 2, Failed: 3, Errors: result.Errors}, *result)
+	var count int64
+	db.Model(&models.Product{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	reloaded, _ := products.FindByID(existing.ID)
+	assert.Equal(t, "Lamp", reloaded.Name)
+	assert.Equal(t, 4, reloaded.Stock)
+
+	r, _ = NewCSVReader(strings.NewReader(input))
+	result, err = Import(context.Background(), db, r, Options{Change: models.StockChange{Reason: "bulk import"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 2, result.Updated)
+	require.Len(t, result.Errors, 3)
+	assert.Equal(t, 4, result.Errors[0].Line)
+	assert.Equal(t, "id", result.Errors[1].Field)
+	assert.Equal(t, "price", result.Errors[2].Field)
+	assert.Equal(t, "CHAIR-2", result.Errors[2].SKU)
+
+	reloaded, _ = products.FindByID(existing.ID)
+	assert.Equal(t, "Desk lamp", reloaded.Name)
+	assert.Equal(t, models.NewMoney(2500, "USD"), reloaded.Price)
+	assert.Equal(t, 10, reloaded.Stock)
+	var movement models.StockMovement
+	require.NoError(t, db.Where("product_id = ?", existing.ID).Order("id DESC").First(&movement).Error)
+	assert.Equal(t, 6, movement.Quantity)
+	assert.Equal(t, "bulk import", movement.Reason)
+
+	chair, err := products.FindBySKU("CHAIR-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Chair v2", chair.Name)
+	assert.Equal(t, models.NewMoney(4500, "USD"), chair.Price)
+	assert.Equal(t, "Furniture", chair.Category)
+	assert.NotNil(t, chair.CategoryID)
+	assert.True(t, chair.IsAvailable)
+
+	// SKU 不能与其他产品重复
+	r, _ = NewCSVReader(strings.NewReader("id,sku\n" + strconv.FormatUint(uint64(existing.ID), 10) + ",CHAIR-1\n"))
+	result, err = Import(context.Background(), db, r, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "sku", result.Errors[0].Field)
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	products := repository.NewProductRepository(db)
+	for _, p := range []*models.Product{
+		{SKU: strPtr("A"), Name: "Alpha", Description: "line one\nline two", Price: models.NewMoney(1050, "USD"), Stock: 2, IsAvailable: true},
+		{Name: "Beta", Price: models.NewMoney(99, "USD"), IsAvailable: false},
+	} {
+		require.NoError(t, products.Create(p))
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf)
+		require.NoError(t, err)
+		q, _ := models.ParseListQuery(map[string][]string{"sort": {"-name"}}, models.ProductQuerySchema)
+		require.NoError(t, products.Each(context.Background(), q, w.Write))
+		require.NoError(t, w.Flush())
+
+		// 导出的文件可以直接导入，所有行都匹配到已有产品
+		r, err := NewReader(format, &buf)
+		require.NoError(t, err, format)
+		result, err := Import(context.Background(), db, r, Options{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Updated, "%s: %v", format, result.Errors)
+		assert.Empty(t, result.Errors)
+	}
+
+	var buf bytes.Buffer
+	w, _ := NewCSVWriter(&buf)
+	q, _ := models.ParseListQuery(map[string][]string{"is_available": {"true"}}, models.ProductQuerySchema)
+	require.NoError(t, products.Each(context.Background(), q, w.Write))
+	w.Flush()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, strings.Join(Columns, ","), lines[0])
+	assert.Contains(t, lines[1], `1,A,Alpha,"line one`)
+	assert.Contains(t, buf.String(), "10.50,USD,2,0")
+	assert.NotContains(t, buf.String(), "Beta")
+}
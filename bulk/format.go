@@ -0,0 +1,417 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+)
+
+// 支持的文件格式
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// ErrUnsupportedFormat 不支持的文件格式
+var ErrUnsupportedFormat = errors.New("unsupported format, expected csv or jsonl")
+
+// ErrInvalidHeader CSV 表头缺失、重复或包含未知列
+var ErrInvalidHeader = errors.New("invalid csv header")
+
+// maxLineSize JSONL 单行的最大长度
+const maxLineSize = 1 << 20
+
+// Columns 导出文件的列，导入时 reserved、created_at、updated_at 为只读列，会被忽略，
+// 因此导出的文件可以修改后直接导入。JSONL 中价格为金额对象，没有单独的 currency 列
+var Columns = []string{"id", "sku", "name", "description", "price", "currency", "stock", "reserved",
+	"category_id", "category", "is_available", "created_at", "updated_at"}
+
+var readOnlyColumns = map[string]bool{"reserved": true, "created_at": true, "updated_at": true}
+
+var knownColumns = func() map[string]bool {
+	known := make(map[string]bool, len(Columns))
+	for _, column := range Columns {
+		known[column] = true
+	}
+	return known
+}()
+
+// Row 导入文件中的一行。字段为 nil 表示该行没有提供，更新已有产品时保持原值
+type Row struct {
+	// Line 行在文件中的行号，用于错误报告
+	Line        int           `json:"-"`
+	ID          *uint         `json:"id"`
+	SKU         *string       `json:"sku"`
+	Name        *string       `json:"name"`
+	Description *string       `json:"description"`
+	Price       *models.Money `json:"price"`
+	Stock       *int          `json:"stock"`
+	CategoryID  *uint         `json:"category_id"`
+	Category    *string       `json:"category"`
+	IsAvailable *bool         `json:"is_available"`
+}
+
+// RowError 单行的错误，不影响其他行的导入
+type RowError struct {
+	Line    int    `json:"line"`
+	ID      *uint  `json:"id,omitempty"`
+	SKU     string `json:"sku,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *RowError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Reader 逐行读取导入文件。文件结束时返回 io.EOF；单行格式错误时返回 *RowError，
+// 可以继续读取下一行；其他错误表示文件无法继续读取
+type Reader interface {
+	Read() (*Row, error)
+}
+
+// NewReader 按格式创建读取器
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r)
+	case FormatJSONL:
+		return NewJSONLReader(r), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// CSVReader 读取带表头的 CSV，列的顺序任意，空单元格表示未提供该字段
+type CSVReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+// NewCSVReader 读取并校验表头，未知列和重复列返回 ErrInvalidHeader
+func NewCSVReader(r io.Reader) (*CSVReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidHeader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !knownColumns[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidHeader, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidHeader, name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	return &CSVReader{r: cr, columns: columns}, nil
+}
+
+// Read 实现 Reader
+func (r *CSVReader) Read() (*Row, error) {
+	record, err := r.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &RowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	line, _ := r.r.FieldPos(0)
+	row := &Row{Line: line}
+	if len(record) > len(r.columns) {
+		return nil, &RowError{Line: line, Message: fmt.Sprintf("expected at most %d fields, got %d", len(r.columns), len(record))}
+	}
+
+	values := make(map[string]string, len(record))
+	for i, value := range record {
+		if value = strings.TrimSpace(value); value != "" {
+			values[r.columns[i]] = value
+		}
+	}
+	for _, column := range r.columns {
+		value, ok := values[column]
+		if !ok || readOnlyColumns[column] || column == "currency" {
+			continue
+		}
+		if err := row.set(column, value, values["currency"]); err != nil {
+			return nil, row.errorf(column, "%v", err)
+		}
+	}
+	return row, nil
+}
+
+// set 按列名解析单元格
+func (row *Row) set(column, value, currency string) error {
+	switch column {
+	case "id", "category_id":
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || n == 0 {
+			return fmt.Errorf("invalid id %q", value)
+		}
+		id := uint(n)
+		if column == "id" {
+			row.ID = &id
+		} else {
+			row.CategoryID = &id
+		}
+	case "sku":
+		row.SKU = &value
+	case "name":
+		row.Name = &value
+	case "description":
+		row.Description = &value
+	case "category":
+		row.Category = &value
+	case "price":
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		price, err := models.ParseMoney(value, currency)
+		if err != nil {
+			return err
+		}
+		row.Price = &price
+	case "stock":
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		row.Stock = &stock
+	case "is_available":
+		available, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		row.IsAvailable = &available
+	}
+	return nil
+}
+
+// errorf 创建带有行号和标识的行错误
+func (row *Row) errorf(field, format string, args ...interface{}) *RowError {
+	e := &RowError{Line: row.Line, ID: row.ID, Field: field, Message: fmt.Sprintf(format, args...)}
+	if row.SKU != nil {
+		e.SKU = *row.SKU
+	}
+	return e
+}
+
+// JSONLReader 读取每行一个 JSON 对象的文件，空行被忽略，缺少的键或 null 表示未提供该字段
+type JSONLReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewJSONLReader 创建 JSONL 读取器
+func NewJSONLReader(r io.Reader) *JSONLReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &JSONLReader{scanner: scanner}
+}
+
+// Read 实现 Reader
+func (r *JSONLReader) Read() (*Row, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		row := &Row{Line: r.line}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, row.errorf("", "invalid json: %v", err)
+		}
+		for key := range fields {
+			if key == "currency" || !knownColumns[key] {
+				return nil, row.errorf(key, "unknown field")
+			}
+		}
+		if err := json.Unmarshal(data, row); err != nil {
+			field := ""
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				field = typeErr.Field
+			}
+			return nil, row.errorf(field, "%v", err)
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Writer 逐个写出导出的产品
+type Writer interface {
+	Write(product *models.Product) error
+	// Flush 将缓冲的内容写入底层 io.Writer
+	Flush() error
+}
+
+// NewWriter 按格式创建写入器
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w)
+	case FormatJSONL:
+		return NewJSONLWriter(w), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ContentType 格式对应的 Content-Type
+func ContentType(format string) string {
+	if format == FormatJSONL {
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// CSVWriter 按 Columns 的顺序写出 CSV，价格为基础货币的十进制金额
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter 创建 CSV 写入器并写出表头
+func NewCSVWriter(w io.Writer) (*CSVWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &CSVWriter{w: cw}, nil
+}
+
+// Write 实现 Writer
+func (w *CSVWriter) Write(p *models.Product) error {
+	sku, categoryID := "", ""
+	if p.SKU != nil {
+		sku = *p.SKU
+	}
+	if p.CategoryID != nil {
+		categoryID = strconv.FormatUint(uint64(*p.CategoryID), 10)
+	}
+	return w.w.Write([]string{
+		strconv.FormatUint(uint64(p.ID), 10),
+		sku,
+		p.Name,
+		p.Description,
+		p.Price.String(),
+		p.Price.Currency,
+		strconv.Itoa(p.Stock),
+		strconv.Itoa(p.Reserved),
+		categoryID,
+		p.Category,
+		strconv.FormatBool(p.IsAvailable),
+		p.CreatedAt.UTC().Format(time.RFC3339),
+		p.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// Flush 实现 Writer
+func (w *CSVWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// JSONLWriter 每个产品写出一行 JSON，字段与 CSV 的列相同
+type JSONLWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter 创建 JSONL 写入器
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &JSONLWriter{w: bw, enc: enc}
+}
+
+// exportRecord 导出的 JSON 对象
+type exportRecord struct {
+	ID          uint         `json:"id"`
+	SKU         *string      `json:"sku"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       models.Money `json:"price"`
+	Stock       int          `json:"stock"`
+	Reserved    int          `json:"reserved"`
+	CategoryID  *uint        `json:"category_id"`
+	Category    string       `json:"category"`
+	IsAvailable bool         `json:"is_available"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// Write 实现 Writer
+func (w *JSONLWriter) Write(p *models.Product) error {
+	return w.enc.Encode(exportRecord{
+		ID:          p.ID,
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Stock:       p.Stock,
+		Reserved:    p.Reserved,
+		CategoryID:  p.CategoryID,
+		Category:    p.Category,
+		IsAvailable: p.IsAvailable,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	})
+}
+
+// Flush 实现 Writer
+func (w *JSONLWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// WriteErrorReport 将行错误写出为 CSV 报告，按行号排序
+func WriteErrorReport(w io.Writer, errs []RowError) error {
+	sorted := append([]RowError(nil), errs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Line < sorted[j].Line })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "id", "sku", "field", "message"}); err != nil {
+		return err
+	}
+	for _, e := range sorted {
+		id := ""
+		if e.ID != nil {
+			id = strconv.FormatUint(uint64(*e.ID), 10)
+		}
+		if err := cw.Write([]string{strconv.Itoa(e.Line), id, e.SKU, e.Field, e.Message}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
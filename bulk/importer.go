@@ -0,0 +1,207 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxErrors 结果中最多保留的行错误数量，超出的错误只计数
+const DefaultMaxErrors = 1000
+
+// errDryRun 用于回滚试运行的事务
+var errDryRun = errors.New("dry run")
+
+// Options 导入选项
+type Options struct {
+	// DryRun 为 true 时完整执行校验和写入，最后回滚，不修改数据
+	DryRun bool
+	// Change 库存变化记入流水时使用的操作人和关联单据
+	Change models.StockChange
+	// MaxErrors 结果中最多保留的行错误数量，为 0 时使用 DefaultMaxErrors
+	MaxErrors int
+}
+
+// Result 导入结果
+type Result struct {
+	DryRun          bool       `json:"dry_run"`
+	Total           int        `json:"total"`
+	Created         int        `json:"created"`
+	Updated         int        `json:"updated"`
+	Failed          int        `json:"failed"`
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errors_truncated"`
+}
+
+// Import 逐行导入产品：有 id 时更新该产品，否则按 sku 匹配已有产品，都没有匹配时创建。
+// 每行在独立的事务中执行，失败的行记入结果，不影响其他行；试运行时所有行在一个事务中执行并最终回滚。
+// 读取文件失败或 ctx 取消时返回错误，此时已导入的行不会回滚
+func Import(ctx context.Context, db *gorm.DB, r Reader, opts Options) (*Result, error) {
+	if opts.MaxErrors <= 0 {
+		opts.MaxErrors = DefaultMaxErrors
+	}
+	result := &Result{DryRun: opts.DryRun, Errors: []RowError{}}
+	if !opts.DryRun {
+		return result, importRows(ctx, db, r, opts, result)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := importRows(ctx, tx, r, opts, result); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return result, err
+}
+
+func importRows(ctx context.Context, db *gorm.DB, r Reader, opts Options, result *Result) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			result.Total++
+			result.fail(rowErr, opts.MaxErrors)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		result.Total++
+		created, rowErr := importRow(db, row, opts.Change)
+		switch {
+		case rowErr != nil:
+			result.fail(rowErr, opts.MaxErrors)
+		case created:
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+}
+
+func (result *Result) fail(err *RowError, maxErrors int) {
+	result.Failed++
+	if len(result.Errors) < maxErrors {
+		result.Errors = append(result.Errors, *err)
+	} else {
+		result.ErrorsTruncated = true
+	}
+}
+
+// importRow 在事务中创建或更新一行对应的产品
+func importRow(db *gorm.DB, row *Row, change models.StockChange) (bool, *RowError) {
+	created := false
+	var field string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		repo := repository.NewProductRepository(tx)
+
+		var product *models.Product
+		var err error
+		switch {
+		case row.ID != nil:
+			if product, err = repo.FindByID(*row.ID); err == gorm.ErrRecordNotFound {
+				field = "id"
+				return errors.New("product not found")
+			}
+		case row.SKU != nil && strings.TrimSpace(*row.SKU) != "":
+			product, err = repo.FindBySKU(strings.TrimSpace(*row.SKU))
+			if err == gorm.ErrRecordNotFound {
+				product, err = nil, nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if product == nil {
+			created = true
+			product = &models.Product{IsAvailable: true}
+			row.apply(product)
+			if row.Stock != nil {
+				product.Stock = *row.Stock
+			}
+			if err := binding.Validator.ValidateStruct(product); err != nil {
+				return err
+			}
+			return repo.Create(product)
+		}
+
+		row.apply(product)
+		if err := binding.Validator.ValidateStruct(product); err != nil {
+			return err
+		}
+		if err := repo.Update(product); err != nil {
+			return err
+		}
+		if row.Stock != nil && *row.Stock != product.Stock {
+			field = "stock"
+			_, err := repo.AdjustStock(product.ID, *row.Stock-product.Stock, change)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if field == "" {
+			field = errorField(err)
+		}
+		return created, row.errorf(field, "%v", err)
+	}
+	return created, nil
+}
+
+// apply 将行中提供的字段写入产品，库存由调用方单独处理
+func (row *Row) apply(product *models.Product) {
+	if row.SKU != nil {
+		sku := *row.SKU
+		product.SKU = &sku
+	}
+	if row.Name != nil {
+		product.Name = *row.Name
+	}
+	if row.Description != nil {
+		product.Description = *row.Description
+	}
+	if row.Price != nil {
+		product.Price = *row.Price
+	}
+	if row.IsAvailable != nil {
+		product.IsAvailable = *row.IsAvailable
+	}
+	// 只提供分类名称时按名称匹配或创建分类
+	if row.CategoryID != nil {
+		product.CategoryID = row.CategoryID
+	} else if row.Category != nil {
+		product.CategoryID = nil
+		product.Category = *row.Category
+	}
+}
+
+// errorField 根据仓库返回的错误推断出错的列
+func errorField(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrInvalidPrice):
+		return "price"
+	case errors.Is(err, repository.ErrSKUTaken):
+		return "sku"
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		return "category_id"
+	default:
+		return ""
+	}
+}
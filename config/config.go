@@ -1,40 +1,80 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
+	Server     ServerConfig
+	Log        LogConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	CORS       CORSConfig
+	RBAC       RBACConfig
+	RateLimit  RateLimitConfig
+	Pagination PaginationConfig
+	Currency   CurrencyConfig
+	Storage    StorageConfig
+	Upload     UploadConfig
+	Jobs       JobsConfig
+	Outbox     OutboxConfig
+	Webhooks   WebhooksConfig
+	Metrics    MetricsConfig
+	Tracing    TracingConfig
+	Health     HealthConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Mode string
+	Port            string
+	Mode            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+type LogConfig struct {
+	// Level 日志级别：debug、info、warn、error
+	Level string
+	// Format 输出格式：json 或 text
+	Format string
 }
 
 type DatabaseConfig struct {
-	Driver     string
-	Host       string
-	Port       string
-	User       string
-	Password   string
-	Name       string
-	Charset    string
-	SQLitePath string
+	Driver      string
+	Host        string
+	Port        string
+	User        string
+	Password    string
+	Name        string
+	Charset     string
+	SQLitePath  string
+	AutoMigrate bool
+	// LogLevel GORM 日志级别：silent、error、warn、info
+	LogLevel string
+	// SlowThreshold 超过该耗时的 SQL 记录为慢查询，为 0 时不记录
+	SlowThreshold time.Duration
 }
 
 type JWTConfig struct {
-	Secret     string
-	Expiration int
+	Secret            string
+	Expiration        int
+	RefreshExpiration int
+	Algorithm         string
+	Issuer            string
+	Audience          string
+	PrivateKeyPath    string
+	PublicKeyPath     string
 }
 
 type CORSConfig struct {
@@ -43,8 +83,141 @@ type CORSConfig struct {
 	AllowHeaders string
 }
 
+type RBACConfig struct {
+	BootstrapAdmin string
+}
+
+type PaginationConfig struct {
+	// CursorSecret 游标签名密钥，未配置时由 JWT 密钥派生，不直接复用签发令牌的密钥
+	CursorSecret string
+}
+
+type CurrencyConfig struct {
+	// Default 基础货币，产品价格、价格过滤和购物车均使用该币种
+	Default string
+	// ExchangeRatesFile 汇率文件路径，留空时只能使用基础货币和价目表中的币种
+	ExchangeRatesFile string
+}
+
+type StorageConfig struct {
+	// Driver 文件存储驱动：local 或 s3
+	Driver string
+	// LocalDir 本地存储根目录
+	LocalDir string
+	// PublicURL 文件公开访问地址前缀，本地存储为 "/" 开头的路径时由本服务提供静态文件
+	PublicURL   string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle 使用 endpoint/bucket/key 形式的地址，MinIO 等兼容服务通常需要开启
+	S3PathStyle bool
+}
+
+type UploadConfig struct {
+	// MaxSize 单个上传文件的最大字节数
+	MaxSize int64
+	// MaxPixels 图片的最大像素数
+	MaxPixels int
+	// ThumbnailSizes 缩略图规格，格式 "small:150,medium:400,large:800"
+	ThumbnailSizes string
+}
+
+type JobsConfig struct {
+	// Enabled 是否在本进程中运行 worker，关闭时任务只入队不执行
+	Enabled bool
+	// Queues 要处理的队列及并发数，格式 "default:5,imports:1"
+	Queues       map[string]int
+	PollInterval time.Duration
+	// LockTimeout 单个任务的最长执行时间，超时的任务重新排队
+	LockTimeout time.Duration
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention 已完成任务的保留时间，为 0 时不清理
+	Retention time.Duration
+}
+
+type OutboxConfig struct {
+	// Enabled 是否在本进程中投递领域事件，关闭时事件只写入 outbox 表
+	Enabled      bool
+	PollInterval time.Duration
+	// MaxAttempts 投递失败的最大次数，超过后事件进入 failed 状态
+	MaxAttempts int
+	// Retention 已投递事件的保留时间，为 0 时不清理
+	Retention time.Duration
+}
+
+type WebhooksConfig struct {
+	// Queue 投递任务所在的队列，需要在 JOBS_QUEUES 中配置
+	Queue       string
+	MaxAttempts int
+	Timeout     time.Duration
+	// DisableAfter 连续失败多少次后自动停用 webhook
+	DisableAfter int
+}
+
+type MetricsConfig struct {
+	// Enabled 是否采集指标并在 Path 上以 Prometheus 文本格式导出
+	Enabled bool
+	Path    string
+}
+
+type TracingConfig struct {
+	// Enabled 是否创建链路并通过 OTLP/HTTP 导出到 Endpoint
+	Enabled     bool
+	ServiceName string
+	Endpoint    string
+	// Headers 导出时附加的请求头，格式为 key1=value1,key2=value2
+	Headers string
+	// SampleRatio 新链路的采样比例，0 到 1
+	SampleRatio float64
+}
+
+type HealthConfig struct {
+	// CheckTimeout 单项依赖检查的超时时间
+	CheckTimeout time.Duration
+	// CacheTTL 检查结果的缓存时间，避免频繁的探针请求压垮依赖
+	CacheTTL time.Duration
+	// DiskMinFreeMB SQLite 数据库所在磁盘的最小可用空间
+	DiskMinFreeMB int
+	// ShutdownDelay 收到退出信号后先让就绪探针失败，等待该时间后再停止接收请求，
+	// 让负载均衡有时间摘除实例
+	ShutdownDelay time.Duration
+}
+
+type RateLimitConfig struct {
+	Enabled       bool
+	Store         string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	// Rules 按路由组配置的限流规则，键为 default、auth、users、products、admin
+	Rules map[string]RateLimitRule
+}
+
+// RateLimitRule 限流规则，环境变量格式为 "算法,次数,窗口,维度[,突发]"，
+// 例如 "token_bucket,100,1m,ip" 或 "sliding_window,10,1m,user"
+type RateLimitRule struct {
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	Burst     int
+	KeyBy     string
+}
+
+// RateLimitGroups 支持单独配置限流的路由组
+var RateLimitGroups = []string{"default", "auth", "users", "products", "admin"}
+
 var AppConfig *Config
 
+// insecureJWTSecrets 内置默认值和 .env.example 中的占位值，任何人都能用它们伪造令牌
+var insecureJWTSecrets = map[string]bool{
+	"":                     true,
+	"your-secret-key":      true,
+	"your-secret-key-here": true,
+}
+
 // LoadConfig 加载配置
 func LoadConfig() (*Config, error) {
 	// 加载 .env 文件
@@ -53,37 +226,251 @@ func LoadConfig() (*Config, error) {
 	}
 
 	expiration, _ := strconv.Atoi(getEnv("JWT_EXPIRATION", "24"))
+	refreshExpiration, _ := strconv.Atoi(getEnv("JWT_REFRESH_EXPIRATION", "720"))
 
 	config := &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Mode: getEnv("SERVER_MODE", "debug"),
+			Port:            getEnv("SERVER_PORT", "8080"),
+			Mode:            getEnv("SERVER_MODE", "debug"),
+			ReadTimeout:     getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:    getDurationEnv("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:     getDurationEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout: getDurationEnv("SERVER_SHUTDOWN_TIMEOUT", 20*time.Second),
 		},
 		Database: DatabaseConfig{
-			Driver:     getEnv("DB_DRIVER", "sqlite"),
-			Host:       getEnv("DB_HOST", "localhost"),
-			Port:       getEnv("DB_PORT", "3306"),
-			User:       getEnv("DB_USER", "root"),
-			Password:   getEnv("DB_PASSWORD", ""),
-			Name:       getEnv("DB_NAME", "gin_gorm_app"),
-			Charset:    getEnv("DB_CHARSET", "utf8mb4"),
-			SQLitePath: getEnv("DB_SQLITE_PATH", "./database.db"),
+			Driver:        getEnv("DB_DRIVER", "sqlite"),
+			Host:          getEnv("DB_HOST", "localhost"),
+			Port:          getEnv("DB_PORT", "3306"),
+			User:          getEnv("DB_USER", "root"),
+			Password:      getEnv("DB_PASSWORD", ""),
+			Name:          getEnv("DB_NAME", "gin_gorm_app"),
+			Charset:       getEnv("DB_CHARSET", "utf8mb4"),
+			SQLitePath:    getEnv("DB_SQLITE_PATH", "./database.db"),
+			AutoMigrate:   getEnv("DB_AUTO_MIGRATE", "false") == "true",
+			LogLevel:      getEnv("DB_LOG_LEVEL", "warn"),
+			SlowThreshold: getDurationEnv("DB_SLOW_THRESHOLD", 200*time.Millisecond),
+		},
+		Log: LogConfig{
+			Level:  getEnv("LOG_LEVEL", "info"),
+			Format: getEnv("LOG_FORMAT", "json"),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key"),
-			Expiration: expiration,
+			Secret:            getEnv("JWT_SECRET", "your-secret-key"),
+			Expiration:        expiration,
+			RefreshExpiration: refreshExpiration,
+			Algorithm:         getEnv("JWT_ALGORITHM", "HS256"),
+			Issuer:            getEnv("JWT_ISSUER", "gin-gorm-app"),
+			Audience:          getEnv("JWT_AUDIENCE", "gin-gorm-app"),
+			PrivateKeyPath:    getEnv("JWT_PRIVATE_KEY_PATH", ""),
+			PublicKeyPath:     getEnv("JWT_PUBLIC_KEY_PATH", ""),
 		},
 		CORS: CORSConfig{
 			AllowOrigins: getEnv("CORS_ALLOW_ORIGINS", "*"),
 			AllowMethods: getEnv("CORS_ALLOW_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
 			AllowHeaders: getEnv("CORS_ALLOW_HEADERS", "Origin,Content-Type,Authorization"),
 		},
+		RBAC: RBACConfig{
+			BootstrapAdmin: getEnv("RBAC_BOOTSTRAP_ADMIN", ""),
+		},
+	}
+	config.Pagination = PaginationConfig{
+		CursorSecret: getEnv("PAGINATION_CURSOR_SECRET", deriveSecret(config.JWT.Secret, "pagination-cursor")),
+	}
+
+	config.Currency = CurrencyConfig{
+		Default:           strings.ToUpper(getEnv("CURRENCY_DEFAULT", "USD")),
+		ExchangeRatesFile: getEnv("EXCHANGE_RATES_FILE", ""),
+	}
+
+	config.Storage = StorageConfig{
+		Driver:      getEnv("STORAGE_DRIVER", "local"),
+		LocalDir:    getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+		PublicURL:   getEnv("STORAGE_PUBLIC_URL", ""),
+		S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:    getEnv("S3_BUCKET", ""),
+		S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle: getEnv("S3_PATH_STYLE", "false") == "true",
+	}
+
+	maxSizeMB, _ := strconv.Atoi(getEnv("UPLOAD_MAX_SIZE_MB", "10"))
+	if maxSizeMB <= 0 {
+		maxSizeMB = 10
+	}
+	maxPixels, _ := strconv.Atoi(getEnv("UPLOAD_MAX_PIXELS", "40000000"))
+	config.Upload = UploadConfig{
+		MaxSize:        int64(maxSizeMB) << 20,
+		MaxPixels:      maxPixels,
+		ThumbnailSizes: getEnv("IMAGE_THUMBNAIL_SIZES", "small:150,medium:400,large:800"),
+	}
+
+	queues, err := ParseJobQueues(getEnv("JOBS_QUEUES", "default:5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOBS_QUEUES: %w", err)
+	}
+	config.Jobs = JobsConfig{
+		Enabled:      getEnv("JOBS_ENABLED", "true") == "true",
+		Queues:       queues,
+		PollInterval: getDurationEnv("JOBS_POLL_INTERVAL", time.Second),
+		LockTimeout:  getDurationEnv("JOBS_LOCK_TIMEOUT", 5*time.Minute),
+		BackoffBase:  getDurationEnv("JOBS_BACKOFF_BASE", 10*time.Second),
+		BackoffMax:   getDurationEnv("JOBS_BACKOFF_MAX", time.Hour),
+		Retention:    getDurationEnv("JOBS_RETENTION", 7*24*time.Hour),
+	}
+
+	outboxMaxAttempts, _ := strconv.Atoi(getEnv("OUTBOX_MAX_ATTEMPTS", "10"))
+	config.Outbox = OutboxConfig{
+		Enabled:      getEnv("OUTBOX_ENABLED", "true") == "true",
+		PollInterval: getDurationEnv("OUTBOX_POLL_INTERVAL", 500*time.Millisecond),
+		MaxAttempts:  outboxMaxAttempts,
+		Retention:    getDurationEnv("OUTBOX_RETENTION", 7*24*time.Hour),
+	}
+
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	webhookDisableAfter, _ := strconv.Atoi(getEnv("WEBHOOK_DISABLE_AFTER", "20"))
+	config.Webhooks = WebhooksConfig{
+		Queue:        getEnv("WEBHOOK_QUEUE", "default"),
+		MaxAttempts:  webhookMaxAttempts,
+		Timeout:      getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		DisableAfter: webhookDisableAfter,
+	}
+
+	config.Metrics = MetricsConfig{
+		Enabled: getEnv("METRICS_ENABLED", "true") == "true",
+		Path:    getEnv("METRICS_PATH", "/metrics"),
+	}
+
+	diskMinFree, _ := strconv.Atoi(getEnv("HEALTH_DISK_MIN_FREE_MB", "100"))
+	config.Health = HealthConfig{
+		CheckTimeout:  getDurationEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		CacheTTL:      getDurationEnv("HEALTH_CACHE_TTL", 2*time.Second),
+		DiskMinFreeMB: diskMinFree,
+		ShutdownDelay: getDurationEnv("HEALTH_SHUTDOWN_DELAY", 0),
+	}
+
+	sampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil || sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO %q: must be between 0 and 1", os.Getenv("TRACING_SAMPLE_RATIO"))
+	}
+	config.Tracing = TracingConfig{
+		Enabled:     getEnv("TRACING_ENABLED", "false") == "true",
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "gin-gorm-app"),
+		Endpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		Headers:     getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""),
+		SampleRatio: sampleRatio,
+	}
+
+	rateLimit, err := loadRateLimitConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.RateLimit = rateLimit
+
+	if err := validateSecrets(config); err != nil {
+		return nil, err
 	}
 
 	AppConfig = config
 	return config, nil
 }
 
+// loadRateLimitConfig 加载限流配置
+func loadRateLimitConfig() (RateLimitConfig, error) {
+	redisDB, _ := strconv.Atoi(getEnv("RATE_LIMIT_REDIS_DB", "0"))
+	cfg := RateLimitConfig{
+		Enabled:       getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+		Store:         getEnv("RATE_LIMIT_STORE", "memory"),
+		RedisAddr:     getEnv("RATE_LIMIT_REDIS_ADDR", "localhost:6379"),
+		RedisPassword: getEnv("RATE_LIMIT_REDIS_PASSWORD", ""),
+		RedisDB:       redisDB,
+		Rules:         make(map[string]RateLimitRule),
+	}
+
+	defaults := map[string]string{
+		"default": "token_bucket,100,1m,ip",
+		"auth":    "sliding_window,10,1m,ip",
+	}
+	for _, group := range RateLimitGroups {
+		spec := getEnv("RATE_LIMIT_"+strings.ToUpper(group), defaults[group])
+		if spec == "" || spec == "off" {
+			continue
+		}
+		rule, err := ParseRateLimitRule(spec)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_%s: %w", strings.ToUpper(group), err)
+		}
+		cfg.Rules[group] = rule
+	}
+	return cfg, nil
+}
+
+// ParseRateLimitRule 解析限流规则
+func ParseRateLimitRule(spec string) (RateLimitRule, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 && len(parts) != 5 {
+		return RateLimitRule{}, fmt.Errorf("expected \"algorithm,limit,window,key[,burst]\", got %q", spec)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	rule := RateLimitRule{Algorithm: parts[0], KeyBy: parts[3]}
+	switch rule.Algorithm {
+	case "token_bucket", "sliding_window":
+	default:
+		return rule, fmt.Errorf("unknown algorithm %q", rule.Algorithm)
+	}
+	switch rule.KeyBy {
+	case "ip", "user", "api_key":
+	default:
+		return rule, fmt.Errorf("unknown key %q", rule.KeyBy)
+	}
+
+	var err error
+	if rule.Limit, err = strconv.Atoi(parts[1]); err != nil || rule.Limit <= 0 {
+		return rule, fmt.Errorf("invalid limit %q", parts[1])
+	}
+	if rule.Window, err = time.ParseDuration(parts[2]); err != nil || rule.Window <= 0 {
+		return rule, fmt.Errorf("invalid window %q", parts[2])
+	}
+	if len(parts) == 5 {
+		if rule.Burst, err = strconv.Atoi(parts[4]); err != nil || rule.Burst < 0 {
+			return rule, fmt.Errorf("invalid burst %q", parts[4])
+		}
+	}
+	return rule, nil
+}
+
+// ParseJobQueues 解析队列并发配置，格式 "队列:并发数,..."，省略并发数时为 1
+func ParseJobQueues(spec string) (map[string]int, error) {
+	queues := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, found := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		concurrency := 1
+		if found {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid concurrency %q for queue %q", value, name)
+			}
+			concurrency = n
+		}
+		if name == "" {
+			return nil, fmt.Errorf("missing queue name in %q", part)
+		}
+		queues[name] = concurrency
+	}
+	if len(queues) == 0 {
+		return nil, fmt.Errorf("no queues configured")
+	}
+	return queues, nil
+}
+
 // GetDSN 获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
 	switch c.Driver {
@@ -100,6 +487,33 @@ func (c *DatabaseConfig) GetDSN() string {
 	}
 }
 
+// deriveSecret 用 HMAC-SHA256 从主密钥派生指定用途的子密钥，
+// 子密钥泄露或被用于签名客户端可见的数据时不会暴露主密钥
+func deriveSecret(secret, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateSecrets release 模式下拒绝使用默认的 JWT 密钥启动：HS* 算法用它签发令牌，
+// 未单独配置 PAGINATION_CURSOR_SECRET 时游标签名密钥也由它派生
+func validateSecrets(cfg *Config) error {
+	if !insecureJWTSecrets[cfg.JWT.Secret] {
+		return nil
+	}
+	if cfg.Server.Mode != "release" {
+		log.Println("JWT_SECRET is not set, using an insecure default key; do not use this outside development")
+		return nil
+	}
+	if strings.HasPrefix(cfg.JWT.Algorithm, "HS") {
+		return fmt.Errorf("JWT_SECRET must be set to a random value in release mode")
+	}
+	if os.Getenv("PAGINATION_CURSOR_SECRET") == "" {
+		return fmt.Errorf("JWT_SECRET or PAGINATION_CURSOR_SECRET must be set to a random value in release mode")
+	}
+	return nil
+}
+
 // getEnv 获取环境变量，如果不存在则返回默认值
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -107,3 +521,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationEnv 获取时长类型的环境变量（如 "15s"、"1m"），格式错误时返回默认值
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
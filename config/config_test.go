@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_CursorSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "jwt-signing-key")
+
+	// 未配置时由 JWT 密钥派生，不直接复用
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.NotEqual(t, cfg.JWT.Secret, cfg.Pagination.CursorSecret)
+	assert.Len(t, cfg.Pagination.CursorSecret, 64)
+	again, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Pagination.CursorSecret, again.Pagination.CursorSecret)
+
+	t.Setenv("PAGINATION_CURSOR_SECRET", "cursor-key")
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "cursor-key", cfg.Pagination.CursorSecret)
+}
+
+func TestLoadConfig_ReleaseRequiresJWTSecret(t *testing.T) {
+	t.Setenv("SERVER_MODE", "release")
+
+	// release 模式下未设置或仍为默认值的 JWT 密钥拒绝启动
+	for _, secret := range []string{"", "your-secret-key", "your-secret-key-here"} {
+		t.Setenv("JWT_SECRET", secret)
+		_, err := LoadConfig()
+		assert.ErrorContains(t, err, "JWT_SECRET", secret)
+	}
+
+	// RS256 不用 JWT_SECRET 签发令牌，但游标密钥需要单独配置
+	t.Setenv("JWT_ALGORITHM", "RS256")
+	_, err := LoadConfig()
+	assert.Error(t, err)
+	t.Setenv("PAGINATION_CURSOR_SECRET", "cursor-key")
+	_, err = LoadConfig()
+	assert.NoError(t, err)
+
+	t.Setenv("JWT_ALGORITHM", "HS256")
+	t.Setenv("JWT_SECRET", "a-long-random-secret")
+	_, err = LoadConfig()
+	assert.NoError(t, err)
+
+	// 开发模式下允许默认值
+	t.Setenv("SERVER_MODE", "debug")
+	t.Setenv("JWT_SECRET", "")
+	_, err = LoadConfig()
+	assert.NoError(t, err)
+}
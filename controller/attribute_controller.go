@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AttributeController struct {
+	repo       *repository.AttributeRepository
+	categories *repository.CategoryRepository
+}
+
+func NewAttributeController(db *gorm.DB) *AttributeController {
+	return &AttributeController{
+		repo:       repository.NewAttributeRepository(db),
+		categories: repository.NewCategoryRepository(db),
+	}
+}
+
+// AttributeRequest 创建或更新属性定义请求，type 为 string、number、boolean 或 enum，
+// enum 类型必须提供 options
+type AttributeRequest struct {
+	Code      string   `json:"code" binding:"required,max=50"`
+	Name      string   `json:"name" binding:"required,max=100"`
+	Type      string   `json:"type" binding:"required"`
+	Options   []string `json:"options"`
+	Required  bool     `json:"required"`
+	SortOrder int      `json:"sort_order"`
+}
+
+// GetAttributes 获取分类的属性定义，?inherited=true 时包括从祖先分类继承的定义
+// @Summary 获取分类属性定义
+// @Tags categories
+// @Produce json
+// @Param id path int true "分类ID"
+// @Param inherited query bool false "包括继承的属性"
+// @Success 200 {object} utils.Response
+// @Router /categories/{id}/attributes [get]
+func (ctrl *AttributeController) GetAttributes(c *gin.Context) {
+	categoryID, ok := ctrl.findCategory(c)
+	if !ok {
+		return
+	}
+
+	var definitions []models.AttributeDefinition
+	var err error
+	if inherited, _ := strconv.ParseBool(c.Query("inherited")); inherited {
+		definitions, err = ctrl.repo.WithContext(c.Request.Context()).FindEffective(categoryID)
+	} else {
+		definitions, err = ctrl.repo.WithContext(c.Request.Context()).FindByCategory(categoryID)
+	}
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	if definitions == nil {
+		definitions = []models.AttributeDefinition{}
+	}
+
+	utils.SuccessResponse(c, definitions)
+}
+
+// CreateAttribute 为分类创建属性定义
+// @Summary 创建属性定义
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "分类ID"
+// @Param attribute body AttributeRequest true "属性定义"
+// @Success 201 {object} utils.Response
+// @Router /categories/{id}/attributes [post]
+func (ctrl *AttributeController) CreateAttribute(c *gin.Context) {
+	categoryID, ok := ctrl.findCategory(c)
+	if !ok {
+		return
+	}
+
+	var req AttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	definition := models.AttributeDefinition{CategoryID: categoryID}
+	req.apply(&definition)
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&definition); err != nil {
+		attributeErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, definition)
+}
+
+// UpdateAttribute 更新属性定义
+// @Summary 更新属性定义
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "分类ID"
+// @Param attribute_id path int true "属性定义ID"
+// @Param attribute body AttributeRequest true "属性定义"
+// @Success 200 {object} utils.Response
+// @Router /categories/{id}/attributes/{attribute_id} [put]
+func (ctrl *AttributeController) UpdateAttribute(c *gin.Context) {
+	categoryID, id, ok := ctrl.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	definition, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(categoryID, id)
+	if err != nil {
+		attributeErrorResponse(c, err)
+		return
+	}
+
+	var req AttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	req.apply(definition)
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(definition); err != nil {
+		attributeErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, definition)
+}
+
+// DeleteAttribute 删除属性定义，已有变体中的该属性值保留，下次修改变体时需移除
+// @Summary 删除属性定义
+// @Tags categories
+// @Param id path int true "分类ID"
+// @Param attribute_id path int true "属性定义ID"
+// @Success 200 {object} utils.Response
+// @Router /categories/{id}/attributes/{attribute_id} [delete]
+func (ctrl *AttributeController) DeleteAttribute(c *gin.Context) {
+	categoryID, id, ok := ctrl.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(categoryID, id); err != nil {
+		attributeErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Attribute deleted successfully"})
+}
+
+func (req *AttributeRequest) apply(definition *models.AttributeDefinition) {
+	definition.Code = req.Code
+	definition.Name = req.Name
+	definition.Type = req.Type
+	definition.Options = req.Options
+	definition.Required = req.Required
+	definition.SortOrder = req.SortOrder
+}
+
+// findCategory 解析路径中的分类ID并确认分类存在
+func (ctrl *AttributeController) findCategory(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid category ID")
+		return 0, false
+	}
+
+	if _, err := ctrl.categories.WithContext(c.Request.Context()).FindByID(uint(id)); err != nil {
+		categoryErrorResponse(c, err)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseIDs 解析路径中的分类ID和属性定义ID
+func (ctrl *AttributeController) parseIDs(c *gin.Context) (uint, uint, bool) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid category ID")
+		return 0, 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("attribute_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid attribute ID")
+		return 0, 0, false
+	}
+	return uint(categoryID), uint(id), true
+}
+
+// attributeErrorResponse 将属性定义操作错误转换为对应的 HTTP 响应
+func attributeErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Attribute not found")
+	case errors.Is(err, repository.ErrAttributeCodeTaken):
+		utils.ConflictResponse(c, err.Error())
+	case errors.Is(err, models.ErrInvalidAttribute),
+		errors.Is(err, repository.ErrCategoryNotFound):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		utils.InternalServerErrorResponse(c, err.Error())
+	}
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AuthController struct {
+	userRepo    *repository.UserRepository
+	refreshRepo *repository.RefreshTokenRepository
+	cartRepo    *repository.CartRepository
+	tokens      *utils.TokenService
+}
+
+func NewAuthController(db *gorm.DB, tokens *utils.TokenService) *AuthController {
+	return &AuthController{
+		userRepo:    repository.NewUserRepository(db),
+		refreshRepo: repository.NewRefreshTokenRepository(db),
+		cartRepo:    repository.NewCartRepository(db),
+		tokens:      tokens,
+	}
+}
+
+// LoginRequest 登录请求，用户名和邮箱二选一；携带匿名购物车令牌时合并到用户购物车
+type LoginRequest struct {
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Password  string `json:"password" binding:"required"`
+	CartToken string `json:"cart_token"`
+}
+
+// RefreshRequest 刷新/登出请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse 令牌响应
+type TokenResponse struct {
+	AccessToken      string               `json:"access_token"`
+	TokenType        string               `json:"token_type"`
+	ExpiresIn        int64                `json:"expires_in"`
+	RefreshToken     string               `json:"refresh_token"`
+	RefreshExpiresAt time.Time            `json:"refresh_expires_at"`
+	User             *models.UserResponse `json:"user,omitempty"`
+}
+
+// Login 用户登录
+// @Summary 用户登录
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body LoginRequest true "登录信息"
+// @Success 200 {object} utils.Response
+// @Router /auth/login [post]
+func (ctrl *AuthController) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	var user *models.User
+	var err error
+	switch {
+	case req.Username != "":
+		user, err = ctrl.userRepo.WithContext(c.Request.Context()).FindByUsername(req.Username)
+	case req.Email != "":
+		user, err = ctrl.userRepo.WithContext(c.Request.Context()).FindByEmail(req.Email)
+	default:
+		utils.BadRequestResponse(c, "Username or email is required")
+		return
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.UnauthorizedResponse(c, "Invalid credentials")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	if !utils.CheckPassword(user.Password, req.Password) {
+		utils.UnauthorizedResponse(c, "Invalid credentials")
+		return
+	}
+	if !user.IsActive {
+		utils.ForbiddenResponse(c, "User is disabled")
+		return
+	}
+
+	// 每次登录开启一个新的令牌 family
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
+	}
+	refreshToken, record, err := ctrl.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
+	}
+	if err := ctrl.refreshRepo.WithContext(c.Request.Context()).Create(record); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	// 合并匿名购物车，失败不影响登录
+	cartToken := req.CartToken
+	if cartToken == "" {
+		cartToken = c.GetHeader(CartTokenHeader)
+	}
+	if cartToken != "" {
+		if err := ctrl.cartRepo.WithContext(c.Request.Context()).MergeAnonymous(user.ID, utils.HashToken(cartToken)); err != nil {
+			log.Printf("Failed to merge cart for user %d: %v", user.ID, err)
+		}
+	}
+
+	resp, err := ctrl.issue(user, refreshToken, record)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
+	}
+	userResponse := user.ToResponse()
+	resp.User = &userResponse
+
+	utils.SuccessResponse(c, resp)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对（轮换）
+// @Summary 刷新令牌
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body RefreshRequest true "刷新令牌"
+// @Success 200 {object} utils.Response
+// @Router /auth/refresh [post]
+func (ctrl *AuthController) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	current, err := ctrl.refreshRepo.WithContext(c.Request.Context()).FindByHash(utils.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.UnauthorizedResponse(c, "Invalid refresh token")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	// 已使用或已吊销的令牌再次出现，说明令牌可能泄露，吊销整个 family
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		ctrl.revokeFamily(c, current.FamilyID)
+		return
+	}
+	if !current.IsActive(time.Now()) {
+		utils.UnauthorizedResponse(c, "Refresh token has expired")
+		return
+	}
+
+	user, err := ctrl.userRepo.WithContext(c.Request.Context()).FindByID(current.UserID)
+	if err != nil || !user.IsActive {
+		_ = ctrl.refreshRepo.WithContext(c.Request.Context()).RevokeFamily(current.FamilyID)
+		utils.UnauthorizedResponse(c, "Invalid refresh token")
+		return
+	}
+
+	refreshToken, record, err := ctrl.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
+	}
+	if err := ctrl.refreshRepo.WithContext(c.Request.Context()).Rotate(current, record); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			ctrl.revokeFamily(c, current.FamilyID)
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	resp, err := ctrl.issue(user, refreshToken, record)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// Logout 登出，吊销刷新令牌所在的整个 family
+// @Summary 用户登出
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body RefreshRequest true "刷新令牌"
+// @Success 200 {object} utils.Response
+// @Router /auth/logout [post]
+func (ctrl *AuthController) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	current, err := ctrl.refreshRepo.WithContext(c.Request.Context()).FindByHash(utils.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.UnauthorizedResponse(c, "Invalid refresh token")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	if err := ctrl.refreshRepo.WithContext(c.Request.Context()).RevokeFamily(current.FamilyID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Logged out successfully"})
+}
+
+// newRefreshToken 生成刷新令牌明文及其数据库记录
+func (ctrl *AuthController) newRefreshToken(userID uint, familyID string) (string, *models.RefreshToken, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	record := &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(ctrl.tokens.RefreshExpiration()),
+	}
+	return token, record, nil
+}
+
+// issue 签发访问令牌并组装响应
+func (ctrl *AuthController) issue(user *models.User, refreshToken string, record *models.RefreshToken) (*TokenResponse, error) {
+	accessToken, expiresAt, err := ctrl.tokens.GenerateToken(user.ID, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(expiresAt).Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// revokeFamily 检测到令牌重用时吊销整个 family 并返回 401
+func (ctrl *AuthController) revokeFamily(c *gin.Context, familyID string) {
+	if err := ctrl.refreshRepo.WithContext(c.Request.Context()).RevokeFamily(familyID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	utils.UnauthorizedResponse(c, "Refresh token reuse detected")
+}
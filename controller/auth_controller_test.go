@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/config"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAuthRouter 创建认证测试路由和一个测试用户
+func setupAuthRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB()
+	db.AutoMigrate(&models.RefreshToken{})
+
+	tokens, err := utils.NewTokenService(config.JWTConfig{Secret: "test-secret", Expiration: 1, RefreshExpiration: 24})
+	assert.NoError(t, err)
+
+	hashed, _ := utils.HashPassword("password123")
+	db.Create(&models.User{Username: "testuser", Email: "test@example.com", Password: hashed, IsActive: true})
+
+	ctrl := NewAuthController(db, tokens)
+	router := gin.New()
+	router.POST("/auth/login", ctrl.Login)
+	router.POST("/auth/refresh", ctrl.Refresh)
+	router.POST("/auth/logout", ctrl.Logout)
+	return router
+}
+
+func postJSON(router *gin.Engine, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	jsonData, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestLogin(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	w, response := postJSON(router, "/auth/login", gin.H{"email": "test@example.com", "password": "password123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := response["data"].(map[string]interface{})
+	assert.NotEmpty(t, data["access_token"])
+	assert.NotEmpty(t, data["refresh_token"])
+
+	w, _ = postJSON(router, "/auth/login", gin.H{"username": "testuser", "password": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	_, response := postJSON(router, "/auth/login", gin.H{"username": "testuser", "password": "password123"})
+	first := response["data"].(map[string]interface{})["refresh_token"]
+
+	// 第一次刷新成功并返回新的刷新令牌
+	w, response := postJSON(router, "/auth/refresh", gin.H{"refresh_token": first})
+	assert.Equal(t, http.StatusOK, w.Code)
+	second := response["data"].(map[string]interface{})["refresh_token"]
+	assert.NotEqual(t, first, second)
+
+	// 重用旧令牌会吊销整个 family，新令牌也随之失效
+	w, _ = postJSON(router, "/auth/refresh", gin.H{"refresh_token": first})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w, _ = postJSON(router, "/auth/refresh", gin.H{"refresh_token": second})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogout(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	_, response := postJSON(router, "/auth/login", gin.H{"username": "testuser", "password": "password123"})
+	token := response["data"].(map[string]interface{})["refresh_token"]
+
+	w, _ := postJSON(router, "/auth/logout", gin.H{"refresh_token": token})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = postJSON(router, "/auth/refresh", gin.H{"refresh_token": token})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
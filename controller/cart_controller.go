@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/middleware"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CartTokenHeader 匿名购物车令牌所在的请求/响应头
+const CartTokenHeader = "X-Cart-Token"
+
+type CartController struct {
+	repo *repository.CartRepository
+}
+
+func NewCartController(db *gorm.DB) *CartController {
+	return &CartController{
+		repo: repository.NewCartRepository(db),
+	}
+}
+
+// CartItemRequest 添加购物车商品请求，产品有变体时必须指定 variant_id
+type CartItemRequest struct {
+	ProductID uint  `json:"product_id" binding:"required"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity" binding:"required,gt=0"`
+}
+
+// UpdateCartItemRequest 修改购物车商品数量请求，数量为 0 时移除
+type UpdateCartItemRequest struct {
+	Quantity *int `json:"quantity" binding:"required,min=0"`
+}
+
+// CheckoutRequest 结算请求
+type CheckoutRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// GetCart 获取购物车，价格和总价按产品当前价格重新计算
+// @Summary 获取购物车
+// @Tags cart
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /cart [get]
+func (ctrl *CartController) GetCart(c *gin.Context) {
+	cart, token, err := ctrl.resolveCart(c, false)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	if cart == nil {
+		utils.SuccessResponse(c, models.CartSummary{Items: []models.CartLine{}})
+		return
+	}
+
+	ctrl.respond(c, cart, token)
+}
+
+// AddItem 添加商品到购物车，匿名用户首次添加时创建购物车并返回令牌
+// @Summary 添加购物车商品
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param item body CartItemRequest true "商品信息"
+// @Success 200 {object} utils.Response
+// @Router /cart/items [post]
+func (ctrl *CartController) AddItem(c *gin.Context) {
+	var req CartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	cart, token, err := ctrl.resolveCart(c, true)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).AddItem(cart.ID, req.ProductID, req.VariantID, req.Quantity); err != nil {
+		orderErrorResponse(c, err)
+		return
+	}
+
+	ctrl.reload(c, cart.ID, token)
+}
+
+// UpdateItem 修改购物车商品数量
+// @Summary 修改购物车商品数量
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param product_id path int true "产品ID"
+// @Param variant_id query int false "变体ID，产品有变体时必填"
+// @Param item body UpdateCartItemRequest true "数量"
+// @Success 200 {object} utils.Response
+// @Router /cart/items/{product_id} [put]
+func (ctrl *CartController) UpdateItem(c *gin.Context) {
+	productID, variantID, ok := cartItemParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	cart, token, ok := ctrl.existingCart(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).SetItemQuantity(cart.ID, productID, variantID, *req.Quantity); err != nil {
+		orderErrorResponse(c, err)
+		return
+	}
+
+	ctrl.reload(c, cart.ID, token)
+}
+
+// RemoveItem 从购物车移除商品
+// @Summary 移除购物车商品
+// @Tags cart
+// @Produce json
+// @Param product_id path int true "产品ID"
+// @Param variant_id query int false "变体ID，产品有变体时必填"
+// @Success 200 {object} utils.Response
+// @Router /cart/items/{product_id} [delete]
+func (ctrl *CartController) RemoveItem(c *gin.Context) {
+	productID, variantID, ok := cartItemParams(c)
+	if !ok {
+		return
+	}
+
+	cart, token, ok := ctrl.existingCart(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).RemoveItem(cart.ID, productID, variantID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	ctrl.reload(c, cart.ID, token)
+}
+
+// ClearCart 清空购物车
+// @Summary 清空购物车
+// @Tags cart
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /cart [delete]
+func (ctrl *CartController) ClearCart(c *gin.Context) {
+	cart, token, ok := ctrl.existingCart(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Clear(cart.ID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	ctrl.reload(c, cart.ID, token)
+}
+
+// Checkout 将当前用户的购物车转为订单
+// @Summary 购物车结算
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param request body CheckoutRequest false "结算信息"
+// @Success 201 {object} utils.Response
+// @Router /cart/checkout [post]
+func (ctrl *CartController) Checkout(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CheckoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+	}
+
+	cart, err := ctrl.repo.WithContext(c.Request.Context()).FindByUser(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.BadRequestResponse(c, repository.ErrEmptyOrder.Error())
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	order, err := ctrl.repo.WithContext(c.Request.Context()).Checkout(cart.ID, userID, req.Note)
+	if err != nil {
+		orderErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, order)
+}
+
+// resolveCart 登录用户使用自己的购物车，匿名用户通过 X-Cart-Token 识别。
+// create 为 true 时在购物车不存在时创建；返回的令牌仅对匿名购物车有值
+func (ctrl *CartController) resolveCart(c *gin.Context, create bool) (*models.Cart, string, error) {
+	if userID, ok := middleware.GetUserID(c); ok {
+		if create {
+			cart, err := ctrl.repo.WithContext(c.Request.Context()).FindOrCreateByUser(userID)
+			return cart, "", err
+		}
+		cart, err := ctrl.repo.WithContext(c.Request.Context()).FindByUser(userID)
+		if err == gorm.ErrRecordNotFound {
+			return nil, "", nil
+		}
+		return cart, "", err
+	}
+
+	if token := c.GetHeader(CartTokenHeader); token != "" {
+		cart, err := ctrl.repo.WithContext(c.Request.Context()).FindByTokenHash(utils.HashToken(token))
+		if err == nil {
+			return cart, token, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, "", err
+		}
+	}
+	if !create {
+		return nil, "", nil
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	cart, err := ctrl.repo.WithContext(c.Request.Context()).CreateAnonymous(utils.HashToken(token))
+	return cart, token, err
+}
+
+// cartItemParams 解析路径中的产品ID和查询参数中可选的变体ID，无效时返回 400
+func cartItemParams(c *gin.Context) (uint, *uint, bool) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return 0, nil, false
+	}
+
+	var variantID *uint
+	if raw := c.Query("variant_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid variant ID")
+			return 0, nil, false
+		}
+		value := uint(parsed)
+		variantID = &value
+	}
+	return uint(productID), variantID, true
+}
+
+// existingCart 查找当前请求的购物车，不存在时返回 404
+func (ctrl *CartController) existingCart(c *gin.Context) (*models.Cart, string, bool) {
+	cart, token, err := ctrl.resolveCart(c, false)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return nil, "", false
+	}
+	if cart == nil {
+		utils.NotFoundResponse(c, "Cart not found")
+		return nil, "", false
+	}
+	return cart, token, true
+}
+
+// reload 重新读取购物车并返回
+func (ctrl *CartController) reload(c *gin.Context, cartID uint, token string) {
+	cart, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(cartID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	ctrl.respond(c, cart, token)
+}
+
+// respond 返回重新计算后的购物车，匿名购物车同时在响应头中返回令牌
+func (ctrl *CartController) respond(c *gin.Context, cart *models.Cart, token string) {
+	summary := cart.Summary()
+	if token != "" {
+		summary.CartToken = token
+		c.Header(CartTokenHeader, token)
+	}
+	utils.SuccessResponse(c, summary)
+}
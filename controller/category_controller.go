@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CategoryController struct {
+	repo *repository.CategoryRepository
+}
+
+func NewCategoryController(db *gorm.DB) *CategoryController {
+	return &CategoryController{
+		repo: repository.NewCategoryRepository(db),
+	}
+}
+
+// CategoryRequest 创建或更新分类请求，slug 为空时由名称生成
+type CategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Slug        string `json:"slug" binding:"max=100"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+// GetCategories 获取分类树，?flat=true 时返回按排序值排列的平铺列表
+// @Summary 获取分类
+// @Tags categories
+// @Produce json
+// @Param flat query bool false "返回平铺列表"
+// @Success 200 {object} utils.Response
+// @Router /categories [get]
+func (ctrl *CategoryController) GetCategories(c *gin.Context) {
+	if flat, _ := strconv.ParseBool(c.Query("flat")); flat {
+		categories, err := ctrl.repo.WithContext(c.Request.Context()).FindAll()
+		if err != nil {
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+		utils.SuccessResponse(c, categories)
+		return
+	}
+
+	tree, err := ctrl.repo.WithContext(c.Request.Context()).FindTree(nil)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	if tree == nil {
+		tree = []*models.Category{}
+	}
+	utils.SuccessResponse(c, tree)
+}
+
+// GetCategory 获取分类及其子分类树
+// @Summary 获取分类详情
+// @Tags categories
+// @Produce json
+// @Param id path int true "分类ID"
+// @Success 200 {object} utils.Response
+// @Router /categories/{id} [get]
+func (ctrl *CategoryController) GetCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid category ID")
+		return
+	}
+
+	rootID := uint(id)
+	tree, err := ctrl.repo.WithContext(c.Request.Context()).FindTree(&rootID)
+	if err != nil {
+		categoryErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, tree[0])
+}
+
+// CreateCategory 创建分类
+// @Summary 创建分类
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body CategoryRequest true "分类信息"
+// @Success 201 {object} utils.Response
+// @Router /categories [post]
+func (ctrl *CategoryController) CreateCategory(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	category := models.Category{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		SortOrder:   req.SortOrder,
+	}
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&category); err != nil {
+		categoryErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, category)
+}
+
+// UpdateCategory 更新分类，修改 parent_id 即移动分类，重命名会同步到所属产品
+// @Summary 更新分类
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "分类ID"
+// @Param category body CategoryRequest true "分类信息"
+// @Success 200 {object} utils.Response
+// @Router /categories/{id} [put]
+func (ctrl *CategoryController) UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid category ID")
+		return
+	}
+
+	category, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		categoryErrorResponse(c, err)
+		return
+	}
+
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	category.Name = req.Name
+	category.Description = req.Description
+	category.ParentID = req.ParentID
+	category.SortOrder = req.SortOrder
+	if req.Slug != "" {
+		category.Slug = req.Slug
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(category); err != nil {
+		categoryErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, category)
+}
+
+// DeleteCategory 删除分类，分类下仍有子分类或产品时返回 409
+// @Summary 删除分类
+// @Tags categories
+// @Param id path int true "分类ID"
+// @Success 200 {object} utils.Response
+// @Router /categories/{id} [delete]
+func (ctrl *CategoryController) DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid category ID")
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(uint(id)); err != nil {
+		categoryErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Category deleted successfully"})
+}
+
+// categoryErrorResponse 将分类操作错误转换为对应的 HTTP 响应
+func categoryErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Category not found")
+	case errors.Is(err, repository.ErrCategorySlugTaken),
+		errors.Is(err, repository.ErrCategoryInUse):
+		utils.ConflictResponse(c, err.Error())
+	case errors.Is(err, repository.ErrCategoryNotFound),
+		errors.Is(err, repository.ErrInvalidCategorySlug),
+		errors.Is(err, repository.ErrCategoryCycle):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		utils.InternalServerErrorResponse(c, err.Error())
+	}
+}
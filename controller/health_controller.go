@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/fangyanlin/gin-gorm-app/health"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	checks *health.Registry
+}
+
+func NewHealthController(checks *health.Registry) *HealthController {
+	return &HealthController{checks: checks}
+}
+
+// Livez 存活探针，只检查进程自身，失败时应重启进程
+// @Summary 存活探针
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /livez [get]
+func (ctrl *HealthController) Livez(c *gin.Context) {
+	respondReport(c, ctrl.checks.Liveness(c.Request.Context()))
+}
+
+// Readyz 就绪探针，检查数据库等依赖，失败或服务正在停止时不应接收流量
+// @Summary 就绪探针
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (ctrl *HealthController) Readyz(c *gin.Context) {
+	respondReport(c, ctrl.checks.Readiness(c.Request.Context()))
+}
+
+// respondReport 所有检查通过时返回 200，否则返回 503；探针结果不能被缓存
+func respondReport(c *gin.Context, report health.Report) {
+	c.Header("Cache-Control", "no-store")
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
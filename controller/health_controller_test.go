@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/health"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checks := health.NewRegistry(health.Config{})
+	var dbErr error
+	checks.Register("database", func(ctx context.Context) error { return dbErr })
+
+	ctrl := NewHealthController(checks)
+	router := gin.New()
+	router.GET("/livez", ctrl.Livez)
+	router.GET("/readyz", ctrl.Readyz)
+
+	get := func(path string) (*httptest.ResponseRecorder, health.Report) {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w, report
+	}
+
+	w, report := get("/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, health.StatusOK, report.Status)
+
+	// 依赖失败时就绪探针返回 503，存活探针不受影响
+	dbErr = errors.New("database is down")
+	w, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "database is down", report.Checks[0].Error)
+	w, report = get("/livez")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, report.Checks)
+
+	dbErr = nil
+	checks.SetShuttingDown()
+	w, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "shutdown", report.Checks[len(report.Checks)-1].Name)
+}
@@ -0,0 +1,366 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/media"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/storage"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead 允许的 multipart 边界和表单字段开销
+const multipartOverhead = 1 << 20
+
+// ImageOptions 图片上传配置
+type ImageOptions struct {
+	// MaxSize 单个文件的最大字节数
+	MaxSize int64
+	// MaxPixels 图片的最大像素数
+	MaxPixels int
+	// Sizes 生成的缩略图规格
+	Sizes []media.Size
+}
+
+type ImageController struct {
+	repo     *repository.ImageRepository
+	products *repository.ProductRepository
+	store    storage.Storage
+	opts     ImageOptions
+}
+
+func NewImageController(db *gorm.DB, store storage.Storage, opts ImageOptions) *ImageController {
+	return &ImageController{
+		repo:     repository.NewImageRepository(db),
+		products: repository.NewProductRepository(db),
+		store:    store,
+		opts:     opts,
+	}
+}
+
+// UpdateImageRequest 更新图片请求，is_primary 只能设为 true，原主图自动取消
+type UpdateImageRequest struct {
+	AltText   *string `json:"alt_text" binding:"omitempty,max=255"`
+	IsPrimary *bool   `json:"is_primary"`
+}
+
+// ReorderImagesRequest 图片排序请求，image_ids 必须包含产品的所有图片
+type ReorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" binding:"required"`
+}
+
+// GetImages 获取产品图片列表
+// @Summary 获取产品图片
+// @Tags images
+// @Produce json
+// @Param id path int true "产品ID"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/images [get]
+func (ctrl *ImageController) GetImages(c *gin.Context) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	images, err := ctrl.repo.WithContext(c.Request.Context()).FindByProduct(productID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, images)
+}
+
+// UploadImage 上传产品图片（multipart/form-data，文件字段 file，可选 alt_text、is_primary）。
+// 文件类型按内容识别，只接受 JPEG、PNG、GIF，并按配置生成缩略图
+// @Summary 上传产品图片
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param file formData file true "图片文件"
+// @Success 201 {object} utils.Response
+// @Router /products/{id}/images [post]
+func (ctrl *ImageController) UploadImage(c *gin.Context) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ctrl.opts.MaxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctrl.tooLarge(c)
+		} else {
+			utils.BadRequestResponse(c, "file is required: "+err.Error())
+		}
+		return
+	}
+	if header.Size > ctrl.opts.MaxSize {
+		ctrl.tooLarge(c)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, ctrl.opts.MaxSize+1))
+	file.Close()
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if int64(len(data)) > ctrl.opts.MaxSize {
+		ctrl.tooLarge(c)
+		return
+	}
+
+	img, err := media.Inspect(data, header.Header.Get("Content-Type"), ctrl.opts.MaxPixels)
+	if err != nil {
+		imageErrorResponse(c, err)
+		return
+	}
+	thumbnails, err := img.Thumbnails(ctrl.opts.Sizes)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	isPrimary, _ := strconv.ParseBool(c.PostForm("is_primary"))
+	altText := c.PostForm("alt_text")
+	if len(altText) > 255 {
+		utils.BadRequestResponse(c, "alt_text must be at most 255 characters")
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	base := fmt.Sprintf("products/%d/%s", productID, token)
+	image := &models.ProductImage{
+		ProductID:   productID,
+		Key:         base + img.Ext,
+		ContentType: img.ContentType,
+		Size:        int64(len(img.Data)),
+		Width:       img.Width,
+		Height:      img.Height,
+		AltText:     altText,
+		IsPrimary:   isPrimary,
+		Thumbnails:  models.ImageThumbnails{},
+	}
+	image.URL = ctrl.store.URL(image.Key)
+
+	// 先写入文件再保存元数据，任一步失败时删除已写入的文件
+	ctx := c.Request.Context()
+	if err := ctrl.store.Put(ctx, image.Key, bytes.NewReader(img.Data), image.Size, img.ContentType); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	for _, thumb := range thumbnails {
+		key := base + "_" + thumb.Size.Name + thumb.Ext
+		if err := ctrl.store.Put(ctx, key, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+			ctrl.removeFiles(image)
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+		image.Thumbnails[thumb.Size.Name] = models.ImageThumbnail{
+			Key:    key,
+			URL:    ctrl.store.URL(key),
+			Width:  thumb.Width,
+			Height: thumb.Height,
+		}
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(image); err != nil {
+		ctrl.removeFiles(image)
+		imageErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, image)
+}
+
+// UpdateImage 更新图片的替代文本或设为主图
+// @Summary 更新产品图片
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param image_id path int true "图片ID"
+// @Param request body UpdateImageRequest true "图片信息"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/images/{image_id} [put]
+func (ctrl *ImageController) UpdateImage(c *gin.Context) {
+	image, ok := ctrl.findImage(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if req.IsPrimary != nil && !*req.IsPrimary && image.IsPrimary {
+		utils.BadRequestResponse(c, "set another image as primary instead")
+		return
+	}
+
+	if req.AltText != nil {
+		image.AltText = *req.AltText
+	}
+	if req.IsPrimary != nil && *req.IsPrimary {
+		image.IsPrimary = true
+	}
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(image); err != nil {
+		imageErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, image)
+}
+
+// ReorderImages 按给定顺序重新排列产品图片
+// @Summary 调整产品图片顺序
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param request body ReorderImagesRequest true "图片ID顺序"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/images/order [put]
+func (ctrl *ImageController) ReorderImages(c *gin.Context) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var req ReorderImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	images, err := ctrl.repo.WithContext(c.Request.Context()).Reorder(productID, req.ImageIDs)
+	if err != nil {
+		imageErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, images)
+}
+
+// DeleteImage 删除产品图片及其缩略图文件，删除主图时排在最前的图片成为主图
+// @Summary 删除产品图片
+// @Tags images
+// @Param id path int true "产品ID"
+// @Param image_id path int true "图片ID"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/images/{image_id} [delete]
+func (ctrl *ImageController) DeleteImage(c *gin.Context) {
+	image, ok := ctrl.findImage(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := ctrl.repo.WithContext(c.Request.Context()).Delete(image.ProductID, image.ID)
+	if err != nil {
+		imageErrorResponse(c, err)
+		return
+	}
+	ctrl.removeFiles(deleted)
+
+	utils.SuccessResponse(c, gin.H{"message": "Image deleted successfully"})
+}
+
+// removeFiles 删除图片在存储中的文件，失败只记录日志，不影响请求结果；
+// 不使用请求的 context，客户端断开时也要完成清理
+func (ctrl *ImageController) removeFiles(image *models.ProductImage) {
+	for _, key := range image.Keys() {
+		if err := ctrl.store.Delete(context.Background(), key); err != nil {
+			log.Printf("Warning: failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
+
+func (ctrl *ImageController) tooLarge(c *gin.Context) {
+	utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d byte limit", ctrl.opts.MaxSize))
+}
+
+// findProduct 解析路径中的产品ID并确认产品存在
+func (ctrl *ImageController) findProduct(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return 0, false
+	}
+
+	if _, err := ctrl.products.WithContext(c.Request.Context()).FindByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Product not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findImage 查找路径中产品下的图片
+func (ctrl *ImageController) findImage(c *gin.Context) (*models.ProductImage, bool) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("image_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid image ID")
+		return nil, false
+	}
+
+	image, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(productID, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Image not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return image, true
+}
+
+// imageErrorResponse 将图片操作错误转换为对应的 HTTP 响应
+func imageErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Image not found")
+	case errors.Is(err, media.ErrUnsupportedType),
+		errors.Is(err, media.ErrTypeMismatch):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, media.ErrInvalidImage),
+		errors.Is(err, repository.ErrInvalidImageOrder):
+		utils.BadRequestResponse(c, err.Error())
+	case errors.Is(err, repository.ErrTooManyImages):
+		utils.ConflictResponse(c, err.Error())
+	default:
+		utils.InternalServerErrorResponse(c, err.Error())
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/media"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupImageRouter(t *testing.T) (*gin.Engine, *storage.Local, *models.Product) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB()
+	db.AutoMigrate(&models.Product{}, &models.ProductImage{})
+	product := &models.Product{Name: "Lamp", Price: models.NewMoney(1000, "USD"), IsAvailable: true}
+	require.NoError(t, db.Create(product).Error)
+
+	store, err := storage.NewLocal(t.TempDir(), "/uploads")
+	require.NoError(t, err)
+	ctrl := NewImageController(db, store, ImageOptions{MaxSize: 64 << 10, Sizes: []media.Size{{Name: "small", Max: 8}}})
+
+	router := gin.New()
+	router.GET("/products/:id/images", ctrl.GetImages)
+	router.POST("/products/:id/images", ctrl.UploadImage)
+	router.PUT("/products/:id/images/order", ctrl.ReorderImages)
+	router.PUT("/products/:id/images/:image_id", ctrl.UpdateImage)
+	router.DELETE("/products/:id/images/:image_id", ctrl.DeleteImage)
+	return router, store, product
+}
+
+func uploadImage(router *gin.Engine, path, contentType string, data []byte) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="photo.png"`)
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	part.Write(data)
+	writer.WriteField("alt_text", "front")
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestImageUpload(t *testing.T) {
+	router, store, product := setupImageRouter(t)
+	path := "/products/" + strconv.FormatUint(uint64(product.ID), 10) + "/images"
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 32, 16)))
+
+	w, response := uploadImage(router, path, "image/png", buf.Bytes())
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, true, data["is_primary"])
+	assert.Equal(t, "front", data["alt_text"])
+	assert.Equal(t, float64(32), data["width"])
+	small := data["thumbnails"].(map[string]interface{})["small"].(map[string]interface{})
+	assert.Equal(t, float64(8), small["width"])
+	assert.Equal(t, float64(4), small["height"])
+
+	// 原图和缩略图都已写入存储
+	rc, err := store.Get(context.Background(), data["key"].(string))
+	require.NoError(t, err)
+	stored, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, buf.Bytes(), stored)
+
+	// 按内容识别类型：声明类型不一致、非图片内容、超出大小限制
+	w, _ = uploadImage(router, path, "image/jpeg", buf.Bytes())
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w, _ = uploadImage(router, path, "image/png", []byte("<html><script>alert(1)</script></html>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w, _ = uploadImage(router, path, "image/png", append(buf.Bytes(), make([]byte, 64<<10)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	w, _ = uploadImage(router, "/products/999/images", "image/png", buf.Bytes())
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 删除图片时同时删除存储中的文件
+	id := strconv.Itoa(int(data["id"].(float64)))
+	req, _ := http.NewRequest("DELETE", path+"/"+id, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = store.Get(context.Background(), small["key"].(string))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/middleware"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type InventoryController struct {
+	repo *repository.ProductRepository
+}
+
+func NewInventoryController(db *gorm.DB) *InventoryController {
+	return &InventoryController{
+		repo: repository.NewProductRepository(db),
+	}
+}
+
+// AdjustStockRequest 调整库存请求，quantity 为增减量；产品有变体时必须指定 variant_id
+type AdjustStockRequest struct {
+	VariantID *uint  `json:"variant_id"`
+	Quantity  int    `json:"quantity" binding:"required"`
+	Reason    string `json:"reason" binding:"required,max=255"`
+	Reference string `json:"reference" binding:"max=100"`
+}
+
+// ReserveStockRequest 预留库存请求；产品有变体时必须指定 variant_id
+type ReserveStockRequest struct {
+	VariantID *uint  `json:"variant_id"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	Reference string `json:"reference" binding:"required,max=100"`
+	Reason    string `json:"reason" binding:"max=255"`
+}
+
+// SettleReservationRequest 提交/释放预留请求
+type SettleReservationRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// AdjustStock 调整产品库存
+// @Summary 调整产品库存
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param request body AdjustStockRequest true "调整信息"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/stock/adjust [post]
+func (ctrl *InventoryController) AdjustStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return
+	}
+
+	var req AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	change := stockChange(c, req.Reason, req.Reference)
+	var movement *models.StockMovement
+	if req.VariantID != nil {
+		movement, err = ctrl.repo.WithContext(c.Request.Context()).AdjustVariantStock(uint(id), *req.VariantID, req.Quantity, change)
+	} else {
+		movement, err = ctrl.repo.WithContext(c.Request.Context()).AdjustStock(uint(id), req.Quantity, change)
+	}
+	if err != nil {
+		stockErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, movement)
+}
+
+// ReserveStock 预留产品库存
+// @Summary 预留产品库存
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param request body ReserveStockRequest true "预留信息"
+// @Success 201 {object} utils.Response
+// @Router /products/{id}/stock/reserve [post]
+func (ctrl *InventoryController) ReserveStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return
+	}
+
+	var req ReserveStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	change := stockChange(c, req.Reason, req.Reference)
+	var reservation *models.StockReservation
+	if req.VariantID != nil {
+		reservation, err = ctrl.repo.WithContext(c.Request.Context()).ReserveVariant(uint(id), *req.VariantID, req.Quantity, change)
+	} else {
+		reservation, err = ctrl.repo.WithContext(c.Request.Context()).Reserve(uint(id), req.Quantity, change)
+	}
+	if err != nil {
+		stockErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, reservation)
+}
+
+// GetStockMovements 获取产品库存流水
+// @Summary 获取产品库存流水
+// @Tags inventory
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param variant_id query int false "只查询该变体的流水"
+// @Success 200 {object} utils.PaginatedResponse
+// @Router /products/{id}/stock/movements [get]
+func (ctrl *InventoryController) GetStockMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return
+	}
+
+	if _, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Product not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	var variantID *uint
+	if raw := c.Query("variant_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid variant ID")
+			return
+		}
+		value := uint(parsed)
+		variantID = &value
+	}
+
+	var pagination models.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination.Page = 1
+		pagination.PageSize = 10
+	}
+
+	movements, err := ctrl.repo.WithContext(c.Request.Context()).FindStockMovements(uint(id), variantID, &pagination)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, movements, pagination.Page, pagination.PageSize, pagination.Total)
+}
+
+// GetReservations 获取关联单据下的预留记录
+// @Summary 获取预留记录
+// @Tags inventory
+// @Produce json
+// @Param reference path string true "关联单据"
+// @Success 200 {object} utils.Response
+// @Router /inventory/reservations/{reference} [get]
+func (ctrl *InventoryController) GetReservations(c *gin.Context) {
+	reservations, err := ctrl.repo.WithContext(c.Request.Context()).FindReservations(c.Param("reference"))
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	if len(reservations) == 0 {
+		utils.NotFoundResponse(c, "Reservation not found")
+		return
+	}
+
+	utils.SuccessResponse(c, reservations)
+}
+
+// CommitReservation 提交预留，实际扣减库存
+// @Summary 提交预留
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param reference path string true "关联单据"
+// @Success 200 {object} utils.Response
+// @Router /inventory/reservations/{reference}/commit [post]
+func (ctrl *InventoryController) CommitReservation(c *gin.Context) {
+	ctrl.settle(c, ctrl.repo.CommitReservation)
+}
+
+// ReleaseReservation 释放预留，归还可用库存
+// @Summary 释放预留
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param reference path string true "关联单据"
+// @Success 200 {object} utils.Response
+// @Router /inventory/reservations/{reference}/release [post]
+func (ctrl *InventoryController) ReleaseReservation(c *gin.Context) {
+	ctrl.settle(c, ctrl.repo.ReleaseReservation)
+}
+
+func (ctrl *InventoryController) settle(c *gin.Context, fn func(models.StockChange) ([]models.StockReservation, error)) {
+	var req SettleReservationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+	}
+
+	reservations, err := fn(stockChange(c, req.Reason, c.Param("reference")))
+	if err != nil {
+		stockErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, reservations)
+}
+
+// stockChange 根据请求构造库存变动信息，操作人取自当前登录用户
+func stockChange(c *gin.Context, reason, reference string) models.StockChange {
+	change := models.StockChange{Reason: reason, Reference: reference}
+	if userID, ok := middleware.GetUserID(c); ok {
+		change.ActorID = &userID
+	}
+	return change
+}
+
+// stockErrorResponse 将库存操作错误转换为对应的 HTTP 响应
+func stockErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Product not found")
+	case errors.Is(err, repository.ErrReservationNotFound):
+		utils.NotFoundResponse(c, "Reservation not found")
+	case errors.Is(err, repository.ErrInsufficientStock):
+		utils.ConflictResponse(c, err.Error())
+	case errors.Is(err, repository.ErrInvalidQuantity),
+		errors.Is(err, repository.ErrVariantRequired):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		utils.InternalServerErrorResponse(c, err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type JobController struct {
+	repo *repository.JobRepository
+}
+
+func NewJobController(db *gorm.DB) *JobController {
+	return &JobController{
+		repo: repository.NewJobRepository(db),
+	}
+}
+
+// GetJobs 获取后台任务列表，支持按 queue、type、status 过滤
+// @Summary 获取后台任务列表
+// @Tags admin
+// @Produce json
+// @Param queue query string false "队列"
+// @Param type query string false "任务类型"
+// @Param status query string false "状态：pending、running、succeeded、dead、cancelled"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} utils.PaginatedResponse
+// @Router /admin/jobs [get]
+func (ctrl *JobController) GetJobs(c *gin.Context) {
+	filter := repository.JobFilter{
+		Queue:  c.Query("queue"),
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+	}
+	if filter.Status != "" && !models.IsValidJobStatus(filter.Status) {
+		utils.BadRequestResponse(c, "Invalid job status")
+		return
+	}
+
+	var pagination models.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination.Page = 1
+		pagination.PageSize = 10
+	}
+
+	jobs, err := ctrl.repo.WithContext(c.Request.Context()).FindAll(filter, &pagination)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, jobs, pagination.Page, pagination.PageSize, pagination.Total)
+}
+
+// GetJobStats 按队列和状态统计任务数量
+// @Summary 后台任务统计
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /admin/jobs/stats [get]
+func (ctrl *JobController) GetJobStats(c *gin.Context) {
+	stats, err := ctrl.repo.WithContext(c.Request.Context()).Stats()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, stats)
+}
+
+// GetJob 获取单个任务，包括参数、尝试次数和最后一次错误
+// @Summary 获取后台任务
+// @Tags admin
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/jobs/{id} [get]
+func (ctrl *JobController) GetJob(c *gin.Context) {
+	id, ok := jobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(id)
+	if err != nil {
+		jobErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, job)
+}
+
+// RetryJob 立即重新执行死信或已取消的任务，尝试次数清零
+// @Summary 重试后台任务
+// @Tags admin
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/jobs/{id}/retry [post]
+func (ctrl *JobController) RetryJob(c *gin.Context) {
+	id, ok := jobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := ctrl.repo.WithContext(c.Request.Context()).Retry(id, time.Now())
+	if err != nil {
+		jobErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, job)
+}
+
+// CancelJob 取消尚未开始执行的任务，执行中的任务不能取消
+// @Summary 取消后台任务
+// @Tags admin
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/jobs/{id}/cancel [post]
+func (ctrl *JobController) CancelJob(c *gin.Context) {
+	id, ok := jobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := ctrl.repo.WithContext(c.Request.Context()).Cancel(id, time.Now())
+	if err != nil {
+		jobErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, job)
+}
+
+func jobID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid job ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// jobErrorResponse 将任务操作错误转换为对应的 HTTP 响应
+func jobErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Job not found")
+	case errors.Is(err, repository.ErrJobNotRetryable),
+		errors.Is(err, repository.ErrJobNotCancellable):
+		utils.ConflictResponse(c, err.Error())
+	default:
+		utils.InternalServerErrorResponse(c, err.Error())
+	}
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/exchange"
+	"github.com/fangyanlin/gin-gorm-app/middleware"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrderController struct {
+	repo *repository.OrderRepository
+}
+
+func NewOrderController(db *gorm.DB) *OrderController {
+	return &OrderController{
+		repo: repository.NewOrderRepository(db),
+	}
+}
+
+// OrderItemRequest 下单商品，产品有变体时必须指定 variant_id
+type OrderItemRequest struct {
+	ProductID uint  `json:"product_id" binding:"required"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity" binding:"required,gt=0"`
+}
+
+// CreateOrderRequest 创建订单请求，currency 为订单币种，为空时使用基础货币
+type CreateOrderRequest struct {
+	Items    []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	Note     string             `json:"note" binding:"max=500"`
+	Currency string             `json:"currency" binding:"omitempty,len=3"`
+}
+
+// UpdateOrderStatusRequest 更新订单状态请求
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// CreateOrder 为当前用户创建订单
+// @Summary 创建订单
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body CreateOrderRequest true "订单信息"
+// @Success 201 {object} utils.Response
+// @Router /orders [post]
+func (ctrl *OrderController) CreateOrder(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	order := models.Order{UserID: userID, Note: req.Note, Total: models.Money{Currency: req.Currency}}
+	for _, item := range req.Items {
+		order.Items = append(order.Items, models.OrderItem{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity})
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&order); err != nil {
+		orderErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, order)
+}
+
+// GetOrders 获取当前用户的订单列表
+// @Summary 获取订单列表
+// @Tags orders
+// @Produce json
+// @Param status query string false "订单状态"
+// @Success 200 {object} utils.PaginatedResponse
+// @Router /orders [get]
+func (ctrl *OrderController) GetOrders(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	ctrl.listOrders(c, userID)
+}
+
+// GetAllOrders 获取所有用户的订单列表
+// @Summary 获取所有订单
+// @Tags admin
+// @Produce json
+// @Param status query string false "订单状态"
+// @Success 200 {object} utils.PaginatedResponse
+// @Router /admin/orders [get]
+func (ctrl *OrderController) GetAllOrders(c *gin.Context) {
+	ctrl.listOrders(c, 0)
+}
+
+// GetOrder 获取当前用户的单个订单
+// @Summary 获取订单
+// @Tags orders
+// @Produce json
+// @Param id path int true "订单ID"
+// @Success 200 {object} utils.Response
+// @Router /orders/{id} [get]
+func (ctrl *OrderController) GetOrder(c *gin.Context) {
+	order, ok := ctrl.findOwnOrder(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, order)
+}
+
+// CancelOrder 取消当前用户的待支付订单
+// @Summary 取消订单
+// @Tags orders
+// @Produce json
+// @Param id path int true "订单ID"
+// @Success 200 {object} utils.Response
+// @Router /orders/{id}/cancel [post]
+func (ctrl *OrderController) CancelOrder(c *gin.Context) {
+	order, ok := ctrl.findOwnOrder(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	order, err := ctrl.repo.WithContext(c.Request.Context()).UpdateStatus(order.ID, models.OrderStatusCancelled, &userID)
+	if err != nil {
+		orderErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, order)
+}
+
+// DeleteOrder 删除当前用户已取消的订单
+// @Summary 删除订单
+// @Tags orders
+// @Produce json
+// @Param id path int true "订单ID"
+// @Success 200 {object} utils.Response
+// @Router /orders/{id} [delete]
+func (ctrl *OrderController) DeleteOrder(c *gin.Context) {
+	order, ok := ctrl.findOwnOrder(c)
+	if !ok {
+		return
+	}
+
+	if order.Status != models.OrderStatusCancelled {
+		utils.ConflictResponse(c, "Only cancelled orders can be deleted")
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(order.ID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Order deleted successfully"})
+}
+
+// UpdateOrderStatus 按状态机更新任意订单的状态
+// @Summary 更新订单状态
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "订单ID"
+// @Param request body UpdateOrderStatusRequest true "目标状态"
+// @Success 200 {object} utils.Response
+// @Router /admin/orders/{id}/status [put]
+func (ctrl *OrderController) UpdateOrderStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID")
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if !models.IsValidOrderStatus(req.Status) {
+		utils.BadRequestResponse(c, "Invalid order status")
+		return
+	}
+
+	var actorID *uint
+	if userID, ok := middleware.GetUserID(c); ok {
+		actorID = &userID
+	}
+
+	order, err := ctrl.repo.WithContext(c.Request.Context()).UpdateStatus(uint(id), req.Status, actorID)
+	if err != nil {
+		orderErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, order)
+}
+
+func (ctrl *OrderController) listOrders(c *gin.Context, userID uint) {
+	status := c.Query("status")
+	if status != "" && !models.IsValidOrderStatus(status) {
+		utils.BadRequestResponse(c, "Invalid order status")
+		return
+	}
+
+	var pagination models.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination.Page = 1
+		pagination.PageSize = 10
+	}
+
+	orders, err := ctrl.repo.WithContext(c.Request.Context()).FindAll(userID, status, &pagination)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, orders, pagination.Page, pagination.PageSize, pagination.Total)
+}
+
+// findOwnOrder 查找当前用户的订单，其他用户的订单按不存在处理
+func (ctrl *OrderController) findOwnOrder(c *gin.Context) (*models.Order, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid order ID")
+		return nil, false
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	order, err := ctrl.repo.WithContext(c.Request.Context()).FindByIDForUser(uint(id), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Order not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return order, true
+}
+
+// orderErrorResponse 将订单操作错误转换为对应的 HTTP 响应
+func orderErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, err.Error())
+	case errors.Is(err, repository.ErrInvalidOrderTransition),
+		errors.Is(err, repository.ErrInsufficientStock),
+		errors.Is(err, repository.ErrProductUnavailable):
+		utils.ConflictResponse(c, err.Error())
+	case errors.Is(err, repository.ErrEmptyOrder),
+		errors.Is(err, repository.ErrInvalidQuantity),
+		errors.Is(err, repository.ErrVariantRequired),
+		errors.Is(err, models.ErrInvalidCurrency),
+		errors.Is(err, models.ErrInvalidMoney),
+		errors.Is(err, exchange.ErrRateNotFound):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		stockErrorResponse(c, err)
+	}
+}
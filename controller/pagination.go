@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// cursorMode 请求携带 cursor 参数（第一页可以为空）或 pagination=cursor 时使用游标分页，
+// 否则保持原有的 page/page_size 分页
+func cursorMode(c *gin.Context) bool {
+	_, hasCursor := c.GetQuery("cursor")
+	return hasCursor || c.Query("pagination") == "cursor"
+}
+
+// bindCursorPage 解析游标分页参数并校验游标签名
+func bindCursorPage(c *gin.Context, cursors *utils.CursorCodec) (*models.CursorPage, error) {
+	var page models.CursorPage
+	if err := c.ShouldBindQuery(&page); err != nil {
+		return nil, err
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		var cursor models.Cursor
+		if err := cursors.Decode(raw, &cursor); err != nil {
+			return nil, err
+		}
+		page.Cursor = &cursor
+	}
+	return &page, nil
+}
+
+// cursorPaginatedResponse 编码下一页/上一页游标并返回游标分页响应
+func cursorPaginatedResponse(c *gin.Context, cursors *utils.CursorCodec, data interface{}, page *models.CursorPage) {
+	var next, prev string
+	var err error
+	if page.Next != nil {
+		if next, err = cursors.Encode(page.Next); err != nil {
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+	}
+	if page.Prev != nil {
+		if prev, err = cursors.Encode(page.Prev); err != nil {
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+	}
+	utils.CursorPaginatedSuccessResponse(c, data, page.GetLimit(), next, prev, page.Total)
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PriceController struct {
+	repo     *repository.PriceRepository
+	products *repository.ProductRepository
+}
+
+func NewPriceController(db *gorm.DB) *PriceController {
+	return &PriceController{
+		repo:     repository.NewPriceRepository(db),
+		products: repository.NewProductRepository(db),
+	}
+}
+
+// SetPriceRequest 设置价目表标价请求，amount 为十进制字符串或数字，币种取自路径
+type SetPriceRequest struct {
+	Amount string `json:"amount" binding:"required"`
+}
+
+// GetPrices 获取产品的价目表，基础货币的价格见产品本身
+// @Summary 获取产品价目表
+// @Tags prices
+// @Produce json
+// @Param id path int true "产品ID"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/prices [get]
+func (ctrl *PriceController) GetPrices(c *gin.Context) {
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	prices, err := ctrl.repo.WithContext(c.Request.Context()).FindByProduct(product.ID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"base_price": product.Price, "prices": prices})
+}
+
+// SetPrice 设置产品在某一币种下的标价，已存在时覆盖
+// @Summary 设置价目表标价
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param currency path string true "ISO 4217 货币代码"
+// @Param request body SetPriceRequest true "标价"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/prices/{currency} [put]
+func (ctrl *PriceController) SetPrice(c *gin.Context) {
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var req SetPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	price, err := models.ParseMoney(req.Amount, c.Param("currency"))
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	entry, err := ctrl.repo.WithContext(c.Request.Context()).Set(product.ID, price)
+	if err != nil {
+		productErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, entry)
+}
+
+// DeletePrice 删除产品在某一币种下的标价，之后该币种按汇率换算
+// @Summary 删除价目表标价
+// @Tags prices
+// @Param id path int true "产品ID"
+// @Param currency path string true "ISO 4217 货币代码"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/prices/{currency} [delete]
+func (ctrl *PriceController) DeletePrice(c *gin.Context) {
+	product, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(product.ID, c.Param("currency")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFoundResponse(c, "Price not found")
+		} else {
+			productErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Price deleted successfully"})
+}
+
+// findProduct 解析路径中的产品ID并查找产品
+func (ctrl *PriceController) findProduct(c *gin.Context) (*models.Product, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return nil, false
+	}
+
+	product, err := ctrl.products.WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Product not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return product, true
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/bulk"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxImportSize 导入文件的最大字节数
+const maxImportSize = 100 << 20
+
+// exportFlushEvery 导出时每写出多少个产品刷新一次响应
+const exportFlushEvery = 500
+
+type ProductBulkController struct {
+	db   *gorm.DB
+	repo *repository.ProductRepository
+}
+
+func NewProductBulkController(db *gorm.DB) *ProductBulkController {
+	return &ProductBulkController{
+		db:   db,
+		repo: repository.NewProductRepository(db),
+	}
+}
+
+// ImportProducts 批量导入产品。文件可以直接作为请求体，也可以通过 multipart/form-data 的 file 字段上传；
+// 格式由 ?format=csv|jsonl、Content-Type 或文件扩展名确定。每行按 id 或 sku 更新已有产品，否则创建新产品，
+// 失败的行不影响其他行。?dry_run=true 时只校验不写入；?report=csv 时以 CSV 文件返回行错误报告
+// @Summary 批量导入产品
+// @Tags products
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json,text/csv
+// @Success 200 {object} utils.Response
+// @Router /products/import [post]
+func (ctrl *ProductBulkController) ImportProducts(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	reportCSV := c.Query("report") == "csv"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	body, format, err := importSource(c)
+	if err != nil {
+		bulkErrorResponse(c, err)
+		return
+	}
+
+	reader, err := bulk.NewReader(format, body)
+	if err != nil {
+		bulkErrorResponse(c, err)
+		return
+	}
+
+	result, err := bulk.Import(c.Request.Context(), ctrl.db, reader, bulk.Options{
+		DryRun: dryRun,
+		Change: stockChange(c, "bulk import", ""),
+	})
+	if err != nil {
+		// 非试运行时出错前的行已经导入
+		if !dryRun && result.Created+result.Updated > 0 {
+			err = fmt.Errorf("%w (%d rows read before the error, %d created and %d updated)", err, result.Total, result.Created, result.Updated)
+		}
+		bulkErrorResponse(c, err)
+		return
+	}
+
+	if !reportCSV {
+		utils.SuccessResponse(c, result)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="import-errors.csv"`)
+	c.Header("X-Import-Total", strconv.Itoa(result.Total))
+	c.Header("X-Import-Created", strconv.Itoa(result.Created))
+	c.Header("X-Import-Updated", strconv.Itoa(result.Updated))
+	c.Header("X-Import-Failed", strconv.Itoa(result.Failed))
+	c.Header("Content-Type", bulk.ContentType(bulk.FormatCSV))
+	c.Status(http.StatusOK)
+	if err := bulk.WriteErrorReport(c.Writer, result.Errors); err != nil {
+		log.Printf("Failed to write import error report: %v", err)
+	}
+}
+
+// ExportProducts 以 CSV 或 JSONL 流式导出产品，支持与产品列表相同的过滤和排序参数，
+// 价格为基础货币。导出的文件修改后可以直接导入
+// @Summary 导出产品
+// @Tags products
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "csv 或 jsonl，默认 csv"
+// @Router /products/export [get]
+func (ctrl *ProductBulkController) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", bulk.FormatCSV)
+	q, err := models.ParseListQuery(c.Request.URL.Query(), models.ProductQuerySchema)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	writer, err := bulk.NewWriter(format, c.Writer)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Content-Type", bulk.ContentType(format))
+	c.Status(http.StatusOK)
+
+	// 响应头已经发出，出错时只能记录日志并中断输出
+	count := 0
+	err = ctrl.repo.WithContext(c.Request.Context()).Each(c.Request.Context(), q, func(product *models.Product) error {
+		if err := writer.Write(product); err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		log.Printf("Product export aborted after %d rows: %v", count, err)
+		c.Abort()
+	}
+}
+
+// importSource 返回导入文件的内容和格式，multipart 请求时流式读取 file 字段
+func importSource(c *gin.Context) (io.Reader, string, error) {
+	format := strings.ToLower(c.Query("format"))
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if format == "" {
+			format = formatFromMediaType(mediaType)
+		}
+		return c.Request.Body, format, nil
+	}
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, "", errors.New("file is required")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		if format == "" {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			format = formatFromMediaType(partType)
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(path.Ext(part.FileName())), ".")
+			if format == "ndjson" {
+				format = bulk.FormatJSONL
+			}
+		}
+		return part, format, nil
+	}
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return bulk.FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return bulk.FormatJSONL
+	default:
+		return ""
+	}
+}
+
+// bulkErrorResponse 将导入文件无法读取的错误转换为对应的 HTTP 响应，请求体过大时返回 413
+func bulkErrorResponse(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds the %d byte limit: %v", maxImportSize, err))
+		return
+	}
+	utils.BadRequestResponse(c, err.Error())
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBulkRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB()
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductVariant{}, &models.StockMovement{}))
+
+	ctrl := NewProductBulkController(db)
+	router := gin.New()
+	router.POST("/products/import", ctrl.ImportProducts)
+	router.GET("/products/export", ctrl.ExportProducts)
+	return router
+}
+
+func TestProductImportExport(t *testing.T) {
+	router := setupBulkRouter(t)
+	csvBody := "sku,name,price,stock\nM-1,Mouse,19.99,5\nK-1,Keyboard,abc,1\n"
+
+	// 试运行并下载错误报告
+	req, _ := http.NewRequest("POST", "/products/import?dry_run=true&report=csv", strings.NewReader(csvBody))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Import-Created"))
+	assert.Equal(t, "1", w.Header().Get("X-Import-Failed"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "import-errors.csv")
+	assert.True(t, strings.HasPrefix(w.Body.String(), "line,id,sku,field,message\n3,,K-1,price,"))
+
+	// multipart 上传 JSONL，格式由扩展名确定
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "products.jsonl")
+	part.Write([]byte(`{"sku": "M-1", "name": "Mouse", "price": "19.99", "stock": 5}` + "\n" +
+		`{"sku": "P-1", "name": "Pad", "price": "4.50", "is_available": false}` + "\n"))
+	writer.Close()
+	req, _ = http.NewRequest("POST", "/products/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Data struct {
+			Created int `json:"created"`
+			Failed  int `json:"failed"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 2, response.Data.Created)
+	assert.Equal(t, 0, response.Data.Failed)
+
+	// 未知列和格式
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/products/import?format=csv", strings.NewReader("sku,colour\n"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/products/import", strings.NewReader("x"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 导出使用与产品列表相同的过滤参数
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/products/export?format=jsonl&is_available=true", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"sku":"M-1"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/products/export?price[foo]=1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
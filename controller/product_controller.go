@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 
+	"github.com/fangyanlin/gin-gorm-app/exchange"
 	"github.com/fangyanlin/gin-gorm-app/models"
 	"github.com/fangyanlin/gin-gorm-app/repository"
 	"github.com/fangyanlin/gin-gorm-app/utils"
@@ -11,12 +13,18 @@ import (
 )
 
 type ProductController struct {
-	repo *repository.ProductRepository
+	repo       *repository.ProductRepository
+	categories *repository.CategoryRepository
+	prices     *repository.PriceRepository
+	cursors    *utils.CursorCodec
 }
 
-func NewProductController(db *gorm.DB) *ProductController {
+func NewProductController(db *gorm.DB, cursors *utils.CursorCodec) *ProductController {
 	return &ProductController{
-		repo: repository.NewProductRepository(db),
+		repo:       repository.NewProductRepository(db),
+		categories: repository.NewCategoryRepository(db),
+		prices:     repository.NewPriceRepository(db),
+		cursors:    cursors,
 	}
 }
 
@@ -29,15 +37,15 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.repo.Create(&product); err != nil {
-		utils.InternalServerErrorResponse(c, err.Error())
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&product); err != nil {
+		productErrorResponse(c, err)
 		return
 	}
 
 	utils.CreatedResponse(c, product)
 }
 
-// GetProduct 获取单个产品
+// GetProduct 获取单个产品及其变体和图片，?currency=EUR 时附带该币种下的价格 local_price
 func (ctrl *ProductController) GetProduct(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -45,7 +53,7 @@ func (ctrl *ProductController) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := ctrl.repo.FindByID(uint(id))
+	product, err := ctrl.repo.WithContext(c.Request.Context()).FindByIDWithDetails(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "Product not found")
@@ -55,23 +63,59 @@ func (ctrl *ProductController) GetProduct(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, product)
+	products := []models.Product{*product}
+	if !ctrl.localize(c, products) {
+		return
+	}
+
+	utils.SuccessResponse(c, products[0])
 }
 
-// GetProducts 获取产品列表
+// GetProducts 获取产品列表，支持 ?price[gte]=10&category[in]=a,b&sort=-price,name 形式的过滤和排序，
+// 价格条件使用基础货币；携带 cursor 参数时使用游标分页，?currency=EUR 时附带该币种下的价格
 func (ctrl *ProductController) GetProducts(c *gin.Context) {
-	var pagination models.Pagination
+	q, err := models.ParseListQuery(c.Request.URL.Query(), models.ProductQuerySchema)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	if cursorMode(c) {
+		page, err := bindCursorPage(c, ctrl.cursors)
+		if err != nil {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		products, err := ctrl.repo.WithContext(c.Request.Context()).FindAllCursor(q, page)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				utils.BadRequestResponse(c, err.Error())
+			} else {
+				utils.InternalServerErrorResponse(c, err.Error())
+			}
+			return
+		}
+		if !ctrl.localize(c, products) {
+			return
+		}
+		cursorPaginatedResponse(c, ctrl.cursors, products, page)
+		return
+	}
 
+	var pagination models.Pagination
 	if err := c.ShouldBindQuery(&pagination); err != nil {
 		pagination.Page = 1
 		pagination.PageSize = 10
 	}
 
-	products, err := ctrl.repo.FindAll(&pagination)
+	products, err := ctrl.repo.WithContext(c.Request.Context()).FindAll(q, &pagination)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
+	if !ctrl.localize(c, products) {
+		return
+	}
 
 	utils.PaginatedSuccessResponse(c, products, pagination.Page, pagination.PageSize, pagination.Total)
 }
@@ -84,7 +128,7 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := ctrl.repo.FindByID(uint(id))
+	product, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "Product not found")
@@ -100,15 +144,17 @@ func (ctrl *ProductController) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	product.SKU = updateData.SKU
 	product.Name = updateData.Name
 	product.Description = updateData.Description
 	product.Price = updateData.Price
-	product.Stock = updateData.Stock
+	product.CategoryID = updateData.CategoryID
 	product.Category = updateData.Category
 	product.IsAvailable = updateData.IsAvailable
 
-	if err := ctrl.repo.Update(product); err != nil {
-		utils.InternalServerErrorResponse(c, err.Error())
+	// 库存变化通过库存调整记入流水，与产品更新在同一事务中完成
+	if _, err := ctrl.repo.WithContext(c.Request.Context()).UpdateWithStock(product, updateData.Stock, stockChange(c, "product update", "")); err != nil {
+		productErrorResponse(c, err)
 		return
 	}
 
@@ -123,7 +169,7 @@ func (ctrl *ProductController) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.repo.Delete(uint(id)); err != nil {
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(uint(id)); err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
@@ -131,7 +177,7 @@ func (ctrl *ProductController) DeleteProduct(c *gin.Context) {
 	utils.SuccessResponse(c, gin.H{"message": "Product deleted successfully"})
 }
 
-// SearchProducts 搜索产品
+// SearchProducts 全文搜索产品，返回相关度和高亮片段，facets 参数指定需要的分面统计
 func (ctrl *ProductController) SearchProducts(c *gin.Context) {
 	keyword := c.Query("keyword")
 
@@ -141,18 +187,64 @@ func (ctrl *ProductController) SearchProducts(c *gin.Context) {
 		pagination.PageSize = 10
 	}
 
-	products, err := ctrl.repo.Search(keyword, &pagination)
+	q, err := models.ParseListQuery(c.Request.URL.Query(), models.ProductQuerySchema)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	facets, err := models.ParseFacetRequest(c.Request.URL.Query())
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	hits, facetCounts, err := ctrl.repo.WithContext(c.Request.Context()).Search(keyword, q, facets, &pagination)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
+	products := make([]models.Product, len(hits))
+	for i := range hits {
+		products[i] = hits[i].Product
+	}
+	if !ctrl.localize(c, products) {
+		return
+	}
+	for i := range hits {
+		hits[i].Product.LocalPrice = products[i].LocalPrice
+	}
 
-	utils.PaginatedSuccessResponse(c, products, pagination.Page, pagination.PageSize, pagination.Total)
+	if facetCounts != nil {
+		utils.FacetedPaginatedSuccessResponse(c, hits, facetCounts, pagination.Page, pagination.PageSize, pagination.Total)
+		return
+	}
+	utils.PaginatedSuccessResponse(c, hits, pagination.Page, pagination.PageSize, pagination.Total)
 }
 
-// GetProductsByCategory 根据分类获取产品
+// GetProductsByCategory 根据分类 slug（兼容分类名称）获取产品，
+// ?include_descendants=true 时包含所有子孙分类的产品
 func (ctrl *ProductController) GetProductsByCategory(c *gin.Context) {
-	category := c.Param("category")
+	category, err := ctrl.categories.WithContext(c.Request.Context()).FindBySlug(c.Param("category"))
+	if err == gorm.ErrRecordNotFound {
+		category, err = ctrl.categories.WithContext(c.Request.Context()).FindByName(c.Param("category"))
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Category not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	categoryIDs := []uint{category.ID}
+	if include, _ := strconv.ParseBool(c.Query("include_descendants")); include {
+		if categoryIDs, err = ctrl.categories.WithContext(c.Request.Context()).DescendantIDs(category.ID); err != nil {
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+	}
 
 	var pagination models.Pagination
 	if err := c.ShouldBindQuery(&pagination); err != nil {
@@ -160,11 +252,51 @@ func (ctrl *ProductController) GetProductsByCategory(c *gin.Context) {
 		pagination.PageSize = 10
 	}
 
-	products, err := ctrl.repo.FindByCategory(category, &pagination)
+	q, err := models.ParseListQuery(c.Request.URL.Query(), models.ProductQuerySchema)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	products, err := ctrl.repo.WithContext(c.Request.Context()).FindByCategory(categoryIDs, q, &pagination)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
+	if !ctrl.localize(c, products) {
+		return
+	}
 
 	utils.PaginatedSuccessResponse(c, products, pagination.Page, pagination.PageSize, pagination.Total)
 }
+
+// localize 请求带 currency 参数时为产品填充该币种下的价格，失败时已写入错误响应
+func (ctrl *ProductController) localize(c *gin.Context, products []models.Product) bool {
+	currency := c.Query("currency")
+	if currency == "" {
+		return true
+	}
+	if err := ctrl.prices.WithContext(c.Request.Context()).Localize(c.Request.Context(), products, currency); err != nil {
+		productErrorResponse(c, err)
+		return false
+	}
+	return true
+}
+
+// productErrorResponse 将产品写入、库存调整和价格换算错误转换为对应的 HTTP 响应
+func productErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Product not found")
+	case errors.Is(err, repository.ErrCategoryNotFound),
+		errors.Is(err, repository.ErrInvalidPrice),
+		errors.Is(err, models.ErrInvalidMoney),
+		errors.Is(err, models.ErrInvalidCurrency),
+		errors.Is(err, exchange.ErrRateNotFound):
+		utils.BadRequestResponse(c, err.Error())
+	case errors.Is(err, repository.ErrSKUTaken):
+		utils.ConflictResponse(c, err.Error())
+	default:
+		stockErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RoleController struct {
+	repo     *repository.RoleRepository
+	userRepo *repository.UserRepository
+}
+
+func NewRoleController(db *gorm.DB) *RoleController {
+	return &RoleController{
+		repo:     repository.NewRoleRepository(db),
+		userRepo: repository.NewUserRepository(db),
+	}
+}
+
+// RoleRequest 创建/更新角色请求
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// PermissionsRequest 设置角色权限请求
+type PermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// UserRolesRequest 设置用户角色请求
+type UserRolesRequest struct {
+	RoleIDs []uint `json:"role_ids" binding:"required"`
+}
+
+// CreateRole 创建角色
+// @Summary 创建角色
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param role body RoleRequest true "角色信息"
+// @Success 201 {object} utils.Response
+// @Router /admin/roles [post]
+func (ctrl *RoleController) CreateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	role := models.Role{Name: req.Name, Description: req.Description}
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&role); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	if err := ctrl.repo.WithContext(c.Request.Context()).SetPermissions(&role, req.Permissions); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.CreatedResponse(c, role)
+}
+
+// GetRoles 获取角色列表
+// @Summary 获取角色列表
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /admin/roles [get]
+func (ctrl *RoleController) GetRoles(c *gin.Context) {
+	roles, err := ctrl.repo.WithContext(c.Request.Context()).FindAll()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, roles)
+}
+
+// GetRole 获取单个角色
+// @Summary 获取角色详情
+// @Tags admin
+// @Produce json
+// @Param id path int true "角色ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/roles/{id} [get]
+func (ctrl *RoleController) GetRole(c *gin.Context) {
+	role, ok := ctrl.findRole(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, role)
+}
+
+// UpdateRole 更新角色
+// @Summary 更新角色
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param role body RoleRequest true "角色信息"
+// @Success 200 {object} utils.Response
+// @Router /admin/roles/{id} [put]
+func (ctrl *RoleController) UpdateRole(c *gin.Context) {
+	role, ok := ctrl.findRole(c)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	role.Name = req.Name
+	role.Description = req.Description
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(role); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+	if req.Permissions != nil {
+		if err := ctrl.repo.WithContext(c.Request.Context()).SetPermissions(role, req.Permissions); err != nil {
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, role)
+}
+
+// DeleteRole 删除角色
+// @Summary 删除角色
+// @Tags admin
+// @Produce json
+// @Param id path int true "角色ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/roles/{id} [delete]
+func (ctrl *RoleController) DeleteRole(c *gin.Context) {
+	role, ok := ctrl.findRole(c)
+	if !ok {
+		return
+	}
+	if role.Name == models.RoleAdmin {
+		utils.BadRequestResponse(c, "The admin role cannot be deleted")
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(role.ID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Role deleted successfully"})
+}
+
+// SetRolePermissions 设置角色权限
+// @Summary 设置角色权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param permissions body PermissionsRequest true "权限列表"
+// @Success 200 {object} utils.Response
+// @Router /admin/roles/{id}/permissions [put]
+func (ctrl *RoleController) SetRolePermissions(c *gin.Context) {
+	role, ok := ctrl.findRole(c)
+	if !ok {
+		return
+	}
+
+	var req PermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).SetPermissions(role, req.Permissions); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, role)
+}
+
+// GetPermissions 获取权限列表
+// @Summary 获取权限列表
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /admin/permissions [get]
+func (ctrl *RoleController) GetPermissions(c *gin.Context) {
+	permissions, err := ctrl.repo.WithContext(c.Request.Context()).FindAllPermissions()
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, permissions)
+}
+
+// GetUserRoles 获取用户角色
+// @Summary 获取用户角色
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/users/{id}/roles [get]
+func (ctrl *RoleController) GetUserRoles(c *gin.Context) {
+	user, ok := ctrl.findUser(c)
+	if !ok {
+		return
+	}
+
+	roles, err := ctrl.repo.WithContext(c.Request.Context()).FindUserRoles(user.ID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, roles)
+}
+
+// SetUserRoles 设置用户角色
+// @Summary 设置用户角色
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param roles body UserRolesRequest true "角色ID列表"
+// @Success 200 {object} utils.Response
+// @Router /admin/users/{id}/roles [put]
+func (ctrl *RoleController) SetUserRoles(c *gin.Context) {
+	user, ok := ctrl.findUser(c)
+	if !ok {
+		return
+	}
+
+	var req UserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).SetUserRoles(user, req.RoleIDs); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.BadRequestResponse(c, "Unknown role ID")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, user.ToResponse())
+}
+
+// findRole 根据路径参数查找角色，失败时写入错误响应
+func (ctrl *RoleController) findRole(c *gin.Context) (*models.Role, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid role ID")
+		return nil, false
+	}
+
+	role, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Role not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return role, true
+}
+
+// findUser 根据路径参数查找用户，失败时写入错误响应
+func (ctrl *RoleController) findUser(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid user ID")
+		return nil, false
+	}
+
+	user, err := ctrl.userRepo.WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "User not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return user, true
+}
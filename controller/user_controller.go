@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/fangyanlin/gin-gorm-app/models"
@@ -11,12 +12,14 @@ import (
 )
 
 type UserController struct {
-	repo *repository.UserRepository
+	repo    *repository.UserRepository
+	cursors *utils.CursorCodec
 }
 
-func NewUserController(db *gorm.DB) *UserController {
+func NewUserController(db *gorm.DB, cursors *utils.CursorCodec) *UserController {
 	return &UserController{
-		repo: repository.NewUserRepository(db),
+		repo:    repository.NewUserRepository(db),
+		cursors: cursors,
 	}
 }
 
@@ -45,7 +48,7 @@ func (ctrl *UserController) CreateUser(c *gin.Context) {
 	user.Password = hashedPassword
 
 	// 创建用户
-	if err := ctrl.repo.Create(&user); err != nil {
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&user); err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
@@ -69,7 +72,7 @@ func (ctrl *UserController) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.repo.FindByID(uint(id))
+	user, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "User not found")
@@ -88,17 +91,49 @@ func (ctrl *UserController) GetUser(c *gin.Context) {
 // @Produce json
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
+// @Param sort query string false "排序字段，逗号分隔，- 前缀表示降序" example(-created_at,username)
+// @Param cursor query string false "游标，携带时使用游标分页（第一页传空值）"
+// @Param limit query int false "游标分页每页数量" default(10)
+// @Param with_total query bool false "游标分页时是否返回总数" default(false)
 // @Success 200 {object} utils.PaginatedResponse
 // @Router /users [get]
 func (ctrl *UserController) GetUsers(c *gin.Context) {
-	var pagination models.Pagination
+	q, err := models.ParseListQuery(c.Request.URL.Query(), models.UserQuerySchema)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
 
+	if cursorMode(c) {
+		page, err := bindCursorPage(c, ctrl.cursors)
+		if err != nil {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		users, err := ctrl.repo.WithContext(c.Request.Context()).FindAllCursor(q, page)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				utils.BadRequestResponse(c, err.Error())
+			} else {
+				utils.InternalServerErrorResponse(c, err.Error())
+			}
+			return
+		}
+		userResponses := make([]models.UserResponse, 0, len(users))
+		for _, user := range users {
+			userResponses = append(userResponses, user.ToResponse())
+		}
+		cursorPaginatedResponse(c, ctrl.cursors, userResponses, page)
+		return
+	}
+
+	var pagination models.Pagination
 	if err := c.ShouldBindQuery(&pagination); err != nil {
 		pagination.Page = 1
 		pagination.PageSize = 10
 	}
 
-	users, err := ctrl.repo.FindAll(&pagination)
+	users, err := ctrl.repo.WithContext(c.Request.Context()).FindAll(q, &pagination)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
@@ -130,7 +165,7 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 	}
 
 	// 检查用户是否存在
-	user, err := ctrl.repo.FindByID(uint(id))
+	user, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "User not found")
@@ -164,7 +199,7 @@ func (ctrl *UserController) UpdateUser(c *gin.Context) {
 		user.Password = hashedPassword
 	}
 
-	if err := ctrl.repo.Update(user); err != nil {
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(user); err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
@@ -186,7 +221,7 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.repo.Delete(uint(id)); err != nil {
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(uint(id)); err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
 	}
@@ -212,7 +247,13 @@ func (ctrl *UserController) SearchUsers(c *gin.Context) {
 		pagination.PageSize = 10
 	}
 
-	users, err := ctrl.repo.Search(keyword, &pagination)
+	q, err := models.ParseListQuery(c.Request.URL.Query(), models.UserQuerySchema)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	users, err := ctrl.repo.WithContext(c.Request.Context()).Search(keyword, q, &pagination)
 	if err != nil {
 		utils.InternalServerErrorResponse(c, err.Error())
 		return
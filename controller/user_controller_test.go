@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -23,7 +24,7 @@ func setupTestDB() *gorm.DB {
 	}
 	
 	// 自动迁移
-	db.AutoMigrate(&models.User{})
+	db.AutoMigrate(&models.User{}, &models.OutboxEvent{})
 	
 	return db
 }
@@ -32,7 +33,7 @@ func TestCreateUser(t *testing.T) {
 	// 设置测试环境
 	gin.SetMode(gin.TestMode)
 	db := setupTestDB()
-	ctrl := NewUserController(db)
+	ctrl := NewUserController(db, utils.NewCursorCodec("test-secret"))
 	
 	// 创建测试路由
 	router := gin.New()
@@ -69,7 +70,7 @@ func TestCreateUser(t *testing.T) {
 func TestGetUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := setupTestDB()
-	ctrl := NewUserController(db)
+	ctrl := NewUserController(db, utils.NewCursorCodec("test-secret"))
 	
 	// 创建测试用户
 	user := models.User{
@@ -104,7 +105,7 @@ func TestGetUser(t *testing.T) {
 func TestGetUsers(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := setupTestDB()
-	ctrl := NewUserController(db)
+	ctrl := NewUserController(db, utils.NewCursorCodec("test-secret"))
 	
 	// 创建测试用户
 	users := []models.User{
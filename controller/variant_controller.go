@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type VariantController struct {
+	repo     *repository.VariantRepository
+	products *repository.ProductRepository
+}
+
+func NewVariantController(db *gorm.DB) *VariantController {
+	return &VariantController{
+		repo:     repository.NewVariantRepository(db),
+		products: repository.NewProductRepository(db),
+	}
+}
+
+// VariantRequest 创建或更新变体请求，price 为空时使用产品价格，否则须为基础货币；
+// stock 只在创建时作为初始库存，之后通过库存接口调整
+type VariantRequest struct {
+	SKU         string            `json:"sku" binding:"required,max=64"`
+	Barcode     string            `json:"barcode" binding:"max=64"`
+	Price       *models.Money     `json:"price"`
+	Stock       int               `json:"stock" binding:"gte=0"`
+	Attributes  models.Attributes `json:"attributes"`
+	IsAvailable *bool             `json:"is_available"`
+	SortOrder   int               `json:"sort_order"`
+}
+
+// GetVariants 获取产品的变体列表
+// @Summary 获取产品变体
+// @Tags variants
+// @Produce json
+// @Param id path int true "产品ID"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/variants [get]
+func (ctrl *VariantController) GetVariants(c *gin.Context) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	variants, err := ctrl.repo.WithContext(c.Request.Context()).FindByProduct(productID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, variants)
+}
+
+// GetVariant 获取单个变体
+// @Summary 获取变体详情
+// @Tags variants
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param variant_id path int true "变体ID"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/variants/{variant_id} [get]
+func (ctrl *VariantController) GetVariant(c *gin.Context) {
+	variant, ok := ctrl.findVariant(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, variant)
+}
+
+// CreateVariant 为产品创建变体，属性按产品分类（含祖先分类）的属性定义校验
+// @Summary 创建变体
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param variant body VariantRequest true "变体信息"
+// @Success 201 {object} utils.Response
+// @Router /products/{id}/variants [post]
+func (ctrl *VariantController) CreateVariant(c *gin.Context) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var req VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	variant := models.ProductVariant{
+		ProductID:   productID,
+		SKU:         req.SKU,
+		Barcode:     req.Barcode,
+		Price:       req.Price,
+		Stock:       req.Stock,
+		Attributes:  req.Attributes,
+		IsAvailable: req.IsAvailable == nil || *req.IsAvailable,
+		SortOrder:   req.SortOrder,
+	}
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(&variant); err != nil {
+		variantErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, variant)
+}
+
+// UpdateVariant 更新变体，请求中的 stock 会被忽略
+// @Summary 更新变体
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "产品ID"
+// @Param variant_id path int true "变体ID"
+// @Param variant body VariantRequest true "变体信息"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/variants/{variant_id} [put]
+func (ctrl *VariantController) UpdateVariant(c *gin.Context) {
+	variant, ok := ctrl.findVariant(c)
+	if !ok {
+		return
+	}
+
+	var req VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	variant.SKU = req.SKU
+	variant.Barcode = req.Barcode
+	variant.Price = req.Price
+	variant.Attributes = req.Attributes
+	variant.SortOrder = req.SortOrder
+	if req.IsAvailable != nil {
+		variant.IsAvailable = *req.IsAvailable
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(variant); err != nil {
+		variantErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, variant)
+}
+
+// DeleteVariant 删除变体，变体仍有预留库存时返回 409
+// @Summary 删除变体
+// @Tags variants
+// @Param id path int true "产品ID"
+// @Param variant_id path int true "变体ID"
+// @Success 200 {object} utils.Response
+// @Router /products/{id}/variants/{variant_id} [delete]
+func (ctrl *VariantController) DeleteVariant(c *gin.Context) {
+	variant, ok := ctrl.findVariant(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(variant.ProductID, variant.ID); err != nil {
+		variantErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Variant deleted successfully"})
+}
+
+// findProduct 解析路径中的产品ID并确认产品存在
+func (ctrl *VariantController) findProduct(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid product ID")
+		return 0, false
+	}
+
+	if _, err := ctrl.products.WithContext(c.Request.Context()).FindByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Product not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findVariant 查找路径中产品下的变体，不属于该产品的变体按不存在处理
+func (ctrl *VariantController) findVariant(c *gin.Context) (*models.ProductVariant, bool) {
+	productID, ok := ctrl.findProduct(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("variant_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid variant ID")
+		return nil, false
+	}
+
+	variant, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(productID, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Variant not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return variant, true
+}
+
+// variantErrorResponse 将变体操作错误转换为对应的 HTTP 响应
+func variantErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.NotFoundResponse(c, "Variant not found")
+	case errors.Is(err, repository.ErrSKUTaken),
+		errors.Is(err, repository.ErrDuplicateVariant),
+		errors.Is(err, repository.ErrProductHasStock),
+		errors.Is(err, repository.ErrVariantInUse):
+		utils.ConflictResponse(c, err.Error())
+	case errors.Is(err, models.ErrInvalidAttribute),
+		errors.Is(err, repository.ErrInvalidPrice),
+		errors.Is(err, repository.ErrInsufficientStock):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		utils.InternalServerErrorResponse(c, err.Error())
+	}
+}
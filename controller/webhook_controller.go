@@ -0,0 +1,343 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/fangyanlin/gin-gorm-app/webhooks"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	repo     *repository.WebhookRepository
+	webhooks *webhooks.Service
+}
+
+func NewWebhookController(db *gorm.DB, service *webhooks.Service) *WebhookController {
+	return &WebhookController{
+		repo:     repository.NewWebhookRepository(db),
+		webhooks: service,
+	}
+}
+
+// WebhookRequest 创建/更新 webhook 请求。Secret 为空时创建会自动生成，更新会保留原密钥；
+// Active 为 true 时重新启用已停用的 webhook 并清零失败次数
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=100"`
+	Active      *bool    `json:"active"`
+}
+
+// webhookWithSecret 创建 webhook 的响应，只在这里返回签名密钥
+type webhookWithSecret struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook 创建 webhook
+// @Summary 创建 webhook
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "webhook 信息"
+// @Success 201 {object} utils.Response
+// @Router /admin/webhooks [post]
+func (ctrl *WebhookController) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if err := validateWebhookRequest(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	webhook := &models.Webhook{Active: true}
+	applyWebhookRequest(webhook, &req)
+	if webhook.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			utils.InternalServerErrorResponse(c, err.Error())
+			return
+		}
+		webhook.Secret = secret
+	}
+	if err := ctrl.repo.WithContext(c.Request.Context()).Create(webhook); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.CreatedResponse(c, webhookWithSecret{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// GetWebhooks 获取 webhook 列表
+// @Summary 获取 webhook 列表
+// @Tags admin
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} utils.PaginatedResponse
+// @Router /admin/webhooks [get]
+func (ctrl *WebhookController) GetWebhooks(c *gin.Context) {
+	var pagination models.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination.Page = 1
+		pagination.PageSize = 10
+	}
+
+	webhooks, err := ctrl.repo.WithContext(c.Request.Context()).FindAll(&pagination)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, webhooks, pagination.Page, pagination.PageSize, pagination.Total)
+}
+
+// GetWebhook 获取单个 webhook
+// @Summary 获取 webhook
+// @Tags admin
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/webhooks/{id} [get]
+func (ctrl *WebhookController) GetWebhook(c *gin.Context) {
+	webhook, ok := ctrl.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, webhook)
+}
+
+// UpdateWebhook 更新 webhook，可以停用或重新启用
+// @Summary 更新 webhook
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param webhook body WebhookRequest true "webhook 信息"
+// @Success 200 {object} utils.Response
+// @Router /admin/webhooks/{id} [put]
+func (ctrl *WebhookController) UpdateWebhook(c *gin.Context) {
+	webhook, ok := ctrl.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if err := validateWebhookRequest(&req); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	applyWebhookRequest(webhook, &req)
+	if err := ctrl.repo.WithContext(c.Request.Context()).Update(webhook); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, webhook)
+}
+
+// DeleteWebhook 删除 webhook，尚未完成的投递不再发送
+// @Summary 删除 webhook
+// @Tags admin
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/webhooks/{id} [delete]
+func (ctrl *WebhookController) DeleteWebhook(c *gin.Context) {
+	webhook, ok := ctrl.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.repo.WithContext(c.Request.Context()).Delete(webhook.ID); err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries 获取 webhook 的投递记录，最新的在前
+// @Summary 获取 webhook 投递记录
+// @Tags admin
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param success query bool false "只返回成功或失败的记录"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} utils.PaginatedResponse
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (ctrl *WebhookController) GetDeliveries(c *gin.Context) {
+	webhook, ok := ctrl.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var filter repository.DeliveryFilter
+	if value := c.Query("success"); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid success filter")
+			return
+		}
+		filter.Success = &success
+	}
+
+	var pagination models.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination.Page = 1
+		pagination.PageSize = 10
+	}
+
+	deliveries, err := ctrl.repo.WithContext(c.Request.Context()).FindDeliveries(webhook.ID, filter, &pagination)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, deliveries, pagination.Page, pagination.PageSize, pagination.Total)
+}
+
+// GetDelivery 获取一条投递记录，包括请求体和响应
+// @Summary 获取 webhook 投递记录详情
+// @Tags admin
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id} [get]
+func (ctrl *WebhookController) GetDelivery(c *gin.Context) {
+	delivery, ok := ctrl.findDelivery(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, delivery)
+}
+
+// ReplayDelivery 重新投递一条记录的请求体，返回新建的投递任务
+// @Summary 重放 webhook 投递
+// @Tags admin
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id}/replay [post]
+func (ctrl *WebhookController) ReplayDelivery(c *gin.Context) {
+	delivery, ok := ctrl.findDelivery(c)
+	if !ok {
+		return
+	}
+
+	job, err := ctrl.webhooks.Replay(c.Request.Context(), delivery)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWebhookDisabled):
+			utils.ConflictResponse(c, "Webhook is disabled, enable it before replaying")
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFoundResponse(c, "Webhook not found")
+		default:
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, job)
+}
+
+func (ctrl *WebhookController) findWebhook(c *gin.Context) (*models.Webhook, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid webhook ID")
+		return nil, false
+	}
+
+	webhook, err := ctrl.repo.WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Webhook not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return webhook, true
+}
+
+func (ctrl *WebhookController) findDelivery(c *gin.Context) (*models.WebhookDelivery, bool) {
+	webhook, ok := ctrl.findWebhook(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid delivery ID")
+		return nil, false
+	}
+
+	delivery, err := ctrl.repo.WithContext(c.Request.Context()).FindDelivery(webhook.ID, uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Delivery not found")
+		} else {
+			utils.InternalServerErrorResponse(c, err.Error())
+		}
+		return nil, false
+	}
+	return delivery, true
+}
+
+// validateWebhookRequest 校验回调地址只能是 http(s)，事件类型必须是已知事件或通配
+func validateWebhookRequest(req *WebhookRequest) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	for _, event := range req.Events {
+		if !models.IsValidEventPattern(event) {
+			return fmt.Errorf("unknown event type %q", event)
+		}
+	}
+	return nil
+}
+
+// applyWebhookRequest 将请求写入 webhook，启用状态变化时更新停用信息
+func applyWebhookRequest(webhook *models.Webhook, req *WebhookRequest) {
+	webhook.URL = req.URL
+	webhook.Description = req.Description
+	webhook.Events = req.Events
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if req.Active == nil || *req.Active == webhook.Active {
+		return
+	}
+	webhook.Active = *req.Active
+	if webhook.Active {
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledAt = nil
+		webhook.DisabledReason = ""
+	} else {
+		now := time.Now().UTC()
+		webhook.DisabledAt = &now
+		webhook.DisabledReason = "disabled manually"
+	}
+}
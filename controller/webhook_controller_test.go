@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupWebhookRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Job{}, &models.Webhook{}, &models.WebhookDelivery{}))
+
+	ctrl := NewWebhookController(db, webhooks.NewService(db, webhooks.Config{}))
+	router := gin.New()
+	router.POST("/webhooks", ctrl.CreateWebhook)
+	router.GET("/webhooks/:id", ctrl.GetWebhook)
+	router.PUT("/webhooks/:id", ctrl.UpdateWebhook)
+	router.GET("/webhooks/:id/deliveries", ctrl.GetDeliveries)
+	router.POST("/webhooks/:id/deliveries/:delivery_id/replay", ctrl.ReplayDelivery)
+	return router, db
+}
+
+func sendJSON(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookController(t *testing.T) {
+	router, db := setupWebhookRouter(t)
+
+	// 地址必须是 http(s)，事件类型必须已知
+	w := sendJSON(router, "POST", "/webhooks", gin.H{"url": "ftp://example.com/hook", "events": []string{"*"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendJSON(router, "POST", "/webhooks", gin.H{"url": "https://example.com/hook", "events": []string{"order.*"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendJSON(router, "POST", "/webhooks", gin.H{"url": "https://example.com/hook"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 创建时返回自动生成的密钥，之后不再返回
+	w = sendJSON(router, "POST", "/webhooks", gin.H{
+		"url":    "https://example.com/hook",
+		"events": []string{models.EventProductPriceChanged, models.EventProductStockChanged, models.EventUserCreated},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data struct {
+			ID     uint     `json:"id"`
+			Secret string   `json:"secret"`
+			Events []string `json:"events"`
+			Active bool     `json:"active"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Contains(t, created.Data.Secret, "whsec_")
+	assert.Len(t, created.Data.Events, 3)
+	assert.True(t, created.Data.Active)
+
+	path := fmt.Sprintf("/webhooks/%d", created.Data.ID)
+	req, _ := http.NewRequest("GET", path, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	// 停用的 webhook 不能重放投递
+	active := false
+	w = sendJSON(router, "PUT", path, WebhookRequest{URL: "https://example.com/v2", Events: []string{"product.*"}, Active: &active})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var webhook models.Webhook
+	require.NoError(t, db.First(&webhook, created.Data.ID).Error)
+	assert.False(t, webhook.Active)
+	assert.NotNil(t, webhook.DisabledAt)
+	assert.Equal(t, created.Data.Secret, webhook.Secret)
+
+	delivery := models.WebhookDelivery{WebhookID: webhook.ID, EventKey: "key-1", EventType: models.EventProductUpdated, Payload: models.RawJSON(`{}`), Attempt: 1}
+	require.NoError(t, db.Create(&delivery).Error)
+	replayPath := fmt.Sprintf("%s/deliveries/%d/replay", path, delivery.ID)
+	w = sendJSON(router, "POST", replayPath, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendJSON(router, "POST", path+"/deliveries/999/replay", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	active = true
+	w = sendJSON(router, "PUT", path, WebhookRequest{URL: "https://example.com/v2", Events: []string{"product.*"}, Active: &active})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = sendJSON(router, "POST", replayPath, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), webhooks.JobType)
+
+	req, _ = http.NewRequest("GET", path+"/deliveries?success=false", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"event_key":"key-1"`)
+	req, _ = http.NewRequest("GET", path+"/deliveries?success=maybe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+)
+
+// Backfill 在迁移的 SQL 脚本之后、同一事务中执行的 Go 数据回填，
+// 用于无法在各方言 SQL 中一致表达的转换；bind 将 ? 占位符转换为当前方言的占位符
+type Backfill func(ctx context.Context, tx *sql.Tx, bind func(string) string) error
+
+// backfills 按迁移版本注册的回填步骤，只在升级时执行
+var backfills = map[int64]Backfill{
+	6: backfillCategories,
+}
+
+// driverBackfills 只在某种方言上执行的步骤，版本不与 backfills 重叠
+var driverBackfills = map[string]map[int64]Backfill{
+	"sqlite": {7: createSQLiteFTS},
+}
+
+// backfillCategories 将产品上的自由文本分类转换为顶级分类（000006_categories）。
+// slug 由 models.Slugify 生成，与应用创建和按名称查找分类时一致，slug 相同的名称合并为同一分类；
+// 没有 ASCII 字母数字的名称各自成为一个分类，slug 与应用一样使用 category、category-2……
+func backfillCategories(ctx context.Context, tx *sql.Tx, bind func(string) string) error {
+	// 在 Go 中去重，避免 MySQL 不区分大小写的排序规则合并名称后取到任意一个
+	rows, err := tx.QueryContext(ctx, "SELECT TRIM(category) FROM products WHERE category IS NOT NULL AND TRIM(category) <> ''")
+	if err != nil {
+		return err
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	// 先处理有 slug 的分组，没有 slug 的名称再依次分配不冲突的 slug
+	groups := make(map[string][]string)
+	var slugs, unnamed []string
+	for _, name := range names {
+		slug := models.Slugify(name)
+		if slug == "" {
+			unnamed = append(unnamed, name)
+			continue
+		}
+		if _, ok := groups[slug]; !ok {
+			slugs = append(slugs, slug)
+		}
+		groups[slug] = append(groups[slug], name)
+	}
+	sort.Strings(slugs)
+	for _, name := range unnamed {
+		slug := "category"
+		for i := 2; groups[slug] != nil; i++ {
+			slug = fmt.Sprintf("category-%d", i)
+		}
+		groups[slug] = []string{name}
+		slugs = append(slugs, slug)
+	}
+
+	now := time.Now().UTC()
+	for _, slug := range slugs {
+		members := groups[slug]
+		// 与旧的 MIN(TRIM(category)) 一致，使用排序后的第一个名称
+		name := members[0]
+		if _, err := tx.ExecContext(ctx, bind("INSERT INTO categories (created_at, updated_at, name, slug, sort_order) VALUES (?, ?, ?, ?, 0)"),
+			now, now, name, slug); err != nil {
+			return err
+		}
+		var id int64
+		if err := tx.QueryRowContext(ctx, bind("SELECT id FROM categories WHERE slug = ?"), slug).Scan(&id); err != nil {
+			return err
+		}
+		for _, member := range members {
+			if _, err := tx.ExecContext(ctx, bind("UPDATE products SET category_id = ?, category = ? WHERE TRIM(category) = ?"),
+				id, name, member); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sqliteFTSStatements 创建 FTS5 外部内容表和保持同步的触发器。
+// 软删除是 UPDATE，索引中保留记录，查询时由 products.deleted_at 过滤
+var sqliteFTSStatements = []string{
+	"DROP TRIGGER IF EXISTS products_fts_au",
+	"DROP TRIGGER IF EXISTS products_fts_ad",
+	"DROP TRIGGER IF EXISTS products_fts_ai",
+	"DROP TABLE IF EXISTS products_fts",
+	`CREATE VIRTUAL TABLE products_fts USING fts5(
+		name, description, category, variant_terms,
+		content='products', content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2'
+	)`,
+	`CREATE TRIGGER products_fts_ai AFTER INSERT ON products BEGIN
+		INSERT INTO products_fts(rowid, name, description, category, variant_terms)
+		VALUES (new.id, new.name, new.description, new.category, new.variant_terms);
+	END`,
+	`CREATE TRIGGER products_fts_ad AFTER DELETE ON products BEGIN
+		INSERT INTO products_fts(products_fts, rowid, name, description, category, variant_terms)
+		VALUES ('delete', old.id, old.name, old.description, old.category, old.variant_terms);
+	END`,
+	`CREATE TRIGGER products_fts_au AFTER UPDATE OF name, description, category, variant_terms ON products BEGIN
+		INSERT INTO products_fts(products_fts, rowid, name, description, category, variant_terms)
+		VALUES ('delete', old.id, old.name, old.description, old.category, old.variant_terms);
+		INSERT INTO products_fts(rowid, name, description, category, variant_terms)
+		VALUES (new.id, new.name, new.description, new.category, new.variant_terms);
+	END`,
+	// 为已有产品建立索引
+	"INSERT INTO products_fts(products_fts) VALUES ('rebuild')",
+}
+
+// createSQLiteFTS 创建产品搜索的 FTS5 索引（000007_product_variants 加入变体列后的索引列）。
+// FTS5 只有以 -tags sqlite_fts5 编译时可用，SQL 脚本无法按条件执行，因此放在 Go 步骤中；
+// 不可用时跳过，搜索退回 LIKE 查询
+func createSQLiteFTS(ctx context.Context, tx *sql.Tx, bind func(string) string) error {
+	var available int
+	if err := tx.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available); err != nil {
+		return err
+	}
+	if available == 0 {
+		var count int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'products_fts'").Scan(&count); err != nil {
+			return err
+		}
+		// 旧版本创建的索引无法在没有 FTS5 的情况下删除或重建
+		if count > 0 {
+			return errors.New("products_fts exists but SQLite FTS5 is not available, rebuild with -tags sqlite_fts5")
+		}
+		log.Println("SQLite FTS5 is not available (build with -tags sqlite_fts5), skipping the product search index")
+		return nil
+	}
+	for _, stmt := range sqliteFTSStatements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
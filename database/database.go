@@ -1,23 +1,46 @@
 package database
-package database
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
 
 	"github.com/fangyanlin/gin-gorm-app/config"
+	"github.com/fangyanlin/gin-gorm-app/logging"
 	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-// InitDB 初始化数据库连接
+// InitDB 初始化数据库连接，开发环境下可选执行 GORM 自动迁移
 func InitDB(cfg *config.Config) error {
+	if err := Connect(cfg); err != nil {
+		return err
+	}
+
+	// 为请求中的查询创建子 span，需要在调用前通过 tracing.SetDefault 设置 Tracer
+	if err := DB.Use(tracing.NewGormPlugin(tracing.Default())); err != nil {
+		return fmt.Errorf("failed to install tracing plugin: %w", err)
+	}
+
+	// GORM 自动迁移仅用于本地开发，其余环境使用 "migrate up" 执行版本化迁移
+	if cfg.Database.AutoMigrate {
+		return AutoMigrate()
+	}
+
+	warnPendingMigrations(cfg.Database.Driver)
+	return nil
+}
+
+// Connect 建立数据库连接
+func Connect(cfg *config.Config) error {
 	var err error
 	var dialector gorm.Dialector
 
@@ -33,9 +56,13 @@ func InitDB(cfg *config.Config) error {
 		return fmt.Errorf("unsupported database driver: %s", cfg.Database.Driver)
 	}
 
-	// GORM 配置
+	// GORM 日志写入 slog，带上查询 ctx 中的请求ID
+	logLevel, err := logging.ParseGormLevel(cfg.Database.LogLevel)
+	if err != nil {
+		return err
+	}
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logging.NewGormLogger(slog.Default(), logLevel, cfg.Database.SlowThreshold),
 	}
 
 	DB, err = gorm.Open(dialector, gormConfig)
@@ -44,22 +71,52 @@ func InitDB(cfg *config.Config) error {
 	}
 
 	log.Println("Database connected successfully")
+	return nil
+}
 
-	// 自动迁移数据库表
-	if err := AutoMigrate(); err != nil {
-		return err
+// warnPendingMigrations 启动时提示尚未执行的迁移
+func warnPendingMigrations(driver string) {
+	migrator, err := NewMigrator(DB, driver)
+	if err != nil {
+		log.Printf("Failed to load migrations: %v", err)
+		return
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		log.Printf("Failed to check migration status: %v", err)
+		return
+	}
+	if len(pending) > 0 {
+		log.Printf("WARNING: %d pending migration(s), run \"migrate up\" before serving traffic", len(pending))
 	}
-
-	return nil
 }
 
-// AutoMigrate 自动迁移所有模型
+// AutoMigrate 自动迁移所有模型（仅用于开发环境，不能重命名列、回填数据或回滚）
 func AutoMigrate() error {
 	log.Println("Running database migrations...")
 	
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.Category{},
 		&models.Product{},
+		&models.AttributeDefinition{},
+		&models.ProductVariant{},
+		&models.ProductPrice{},
+		&models.ProductImage{},
+		&models.RefreshToken{},
+		&models.Permission{},
+		&models.Role{},
+		&models.StockMovement{},
+		&models.StockReservation{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.Job{},
+		&models.OutboxEvent{},
+		&models.OutboxDelivery{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 		// 在这里添加更多模型
 	)
 	
@@ -76,6 +133,18 @@ func GetDB() *gorm.DB {
 	return DB
 }
 
+// SQLDB 返回底层的 *sql.DB，未连接时返回 nil
+func SQLDB() *sql.DB {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil
+	}
+	return sqlDB
+}
+
 // CloseDB 关闭数据库连接
 func CloseDB() error {
 	sqlDB, err := DB.DB()
@@ -0,0 +1,459 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/database/migrations"
+	"gorm.io/gorm"
+)
+
+const (
+	// migrationLockKey postgres advisory lock 和 mysql GET_LOCK 使用的锁标识
+	migrationLockKey  = 7263540912
+	migrationLockName = "schema_migrations"
+	// sqliteStaleLock sqlite 锁超过该时间视为进程异常退出遗留的锁
+	sqliteStaleLock = 10 * time.Minute
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Backfill Backfill
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator 版本化 SQL 迁移执行器，已执行的版本记录在 schema_migrations 表中
+type Migrator struct {
+	db          *sql.DB
+	driver      string
+	migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// NewMigrator 为指定驱动创建迁移执行器，迁移文件来自内嵌的 migrations.FS
+func NewMigrator(db *gorm.DB, driver string) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	list, err := LoadMigrations(migrations.FS, driver)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Backfill = backfills[list[i].Version]
+		if backfill, ok := driverBackfills[driver][list[i].Version]; ok {
+			list[i].Backfill = backfill
+		}
+	}
+	return &Migrator{
+		db:          sqlDB,
+		driver:      driver,
+		migrations:  list,
+		LockTimeout: time.Minute,
+	}, nil
+}
+
+// LoadMigrations 读取某个方言目录下的迁移文件并按版本排序
+func LoadMigrations(fsys fs.FS, driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %s: %w", driver, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(driver, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Up 执行未执行的迁移，steps <= 0 表示全部执行
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if err := m.run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 回滚最近执行的迁移，steps <= 0 时回滚一个
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s cannot be rolled back: no down file", migration.Version, migration.Name)
+			}
+			if err := m.run(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回所有迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			at := appliedAt
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending 返回未执行的迁移
+func (m *Migrator) Pending(ctx context.Context) ([]MigrationStatus, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []MigrationStatus
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status)
+		}
+	}
+	return pending, nil
+}
+
+// run 在事务中执行一个迁移（升级时包括其 Go 回填步骤）并更新 schema_migrations。
+// 注意 MySQL 的 DDL 会隐式提交，失败时可能需要手工清理
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range SplitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d_%s %s failed: %w\n%s", migration.Version, migration.Name, direction, err, stmt)
+		}
+	}
+	if up && migration.Backfill != nil {
+		if err := migration.Backfill(ctx, tx, m.bind); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d_%s backfill failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, m.bind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, m.bind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied 查询已执行的版本及执行时间
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// ensureTable 创建 schema_migrations 表
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	timestamp := "datetime"
+	switch m.driver {
+	case "postgres":
+		timestamp = "timestamptz"
+	case "mysql":
+		timestamp = "datetime(3)"
+	}
+	_, err := conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint NOT NULL PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    applied_at %s NOT NULL
+)`, timestamp))
+	return err
+}
+
+// withLock 在独占连接上获取迁移锁后执行 fn，防止多个实例同时迁移
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn(conn)
+}
+
+// lock 获取迁移锁：postgres 使用 advisory lock，mysql 使用 GET_LOCK，sqlite 使用锁表
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	lockCtx, cancel := context.WithTimeout(ctx, m.LockTimeout)
+	defer cancel()
+
+	switch m.driver {
+	case "postgres":
+		if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		}, nil
+
+	case "mysql":
+		var got sql.NullInt64
+		err := conn.QueryRowContext(lockCtx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(m.LockTimeout.Seconds())).Scan(&got)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if got.Int64 != 1 {
+			return nil, errors.New("failed to acquire migration lock: timeout")
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+		}, nil
+
+	default:
+		_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+    id integer NOT NULL PRIMARY KEY CHECK (id = 1),
+    locked_at datetime NOT NULL
+)`)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			conn.ExecContext(lockCtx, "DELETE FROM schema_migrations_lock WHERE locked_at < ?", time.Now().UTC().Add(-sqliteStaleLock))
+			_, err := conn.ExecContext(lockCtx, "INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, ?)", time.Now().UTC())
+			if err == nil {
+				return func() {
+					conn.ExecContext(context.Background(), "DELETE FROM schema_migrations_lock WHERE id = 1")
+				}, nil
+			}
+			select {
+			case <-lockCtx.Done():
+				return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	}
+}
+
+// bind 将 ? 占位符转换为当前方言的占位符
+func (m *Migrator) bind(query string) string {
+	if m.driver != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SplitStatements 按分号拆分 SQL 脚本，忽略注释行；
+// StatementBegin/StatementEnd 之间的内容作为一条语句
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	inBlock := false
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		stmt = strings.TrimSuffix(stmt, ";")
+		if strings.TrimSpace(stmt) != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "-- +migrate StatementBegin":
+			flush()
+			inBlock = true
+			continue
+		case trimmed == "-- +migrate StatementEnd":
+			flush()
+			inBlock = false
+			continue
+		case strings.HasPrefix(trimmed, "--") && !inBlock:
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	flush()
+	return statements
+}
+
+// CreateMigration 在 dir 下为三种方言创建新的空迁移文件，版本号为当前最大版本加一
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q, use letters, digits and underscores", name)
+	}
+
+	var next int64 = 1
+	for _, driver := range []string{"sqlite", "mysql", "postgres"} {
+		list, err := LoadMigrations(os.DirFS(dir), driver)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) > 0 && list[len(list)-1].Version >= next {
+			next = list[len(list)-1].Version + 1
+		}
+	}
+
+	var files []string
+	for _, driver := range []string{"sqlite", "mysql", "postgres"} {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, driver, fmt.Sprintf("%06d_%s.%s.sql", next, name, direction))
+			content := fmt.Sprintf("-- %s migration %06d_%s (%s)\n", strings.ToUpper(direction[:1])+direction[1:], next, name, driver)
+			if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+				return files, err
+			}
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
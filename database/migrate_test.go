@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/database/migrations"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var dialects = []string{"sqlite", "mysql", "postgres"}
+
+// forEachDialect 在每种数据库的空库上运行 fn。内存 SQLite 总会执行；
+// 设置 MIGRATION_TEST_MYSQL_DSN 或 MIGRATION_TEST_POSTGRES_DSN 时还会在真实数据库上执行
+// （MySQL 的 DSN 需带 parseTime=true）。这些必须是专用的空测试库，每个用例结束时回滚全部迁移
+func forEachDialect(t *testing.T, fn func(t *testing.T, db *gorm.DB, migrator *Migrator)) {
+	open := map[string]func(string) gorm.Dialector{
+		"sqlite":   sqlite.Open,
+		"mysql":    mysql.Open,
+		"postgres": postgres.Open,
+	}
+	for _, driver := range dialects {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			dsn := ":memory:"
+			if driver != "sqlite" {
+				env := "MIGRATION_TEST_" + strings.ToUpper(driver) + "_DSN"
+				if dsn = os.Getenv(env); dsn == "" {
+					t.Skipf("%s is not set", env)
+				}
+			}
+			db, err := gorm.Open(open[driver](dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+			if driver == "sqlite" {
+				sqlDB.SetMaxOpenConns(1)
+			}
+			t.Cleanup(func() { sqlDB.Close() })
+
+			migrator, err := NewMigrator(db, driver)
+			require.NoError(t, err)
+			pending, err := migrator.Pending(context.Background())
+			require.NoError(t, err)
+			require.Len(t, pending, len(migrator.migrations), "migration test database must be empty")
+			t.Cleanup(func() {
+				if _, err := migrator.Down(context.Background(), len(migrator.migrations)); err != nil {
+					t.Errorf("roll back migrations: %v", err)
+				}
+			})
+
+			fn(t, db, migrator)
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (id integer);
+CREATE INDEX idx_a ON a (id);
+
+-- +migrate StatementBegin
+CREATE TRIGGER a_ai AFTER INSERT ON a BEGIN
+    SELECT 1;
+    SELECT 2;
+END;
+-- +migrate StatementEnd
+`
+	statements := SplitStatements(script)
+	assert.Len(t, statements, 3)
+	assert.Equal(t, "CREATE TABLE a (id integer)", statements[0])
+	assert.Contains(t, statements[2], "SELECT 2;")
+}
+
+// TestMigrations_Dialects 各方言的迁移文件版本、名称一一对应，每个版本都有 up 和 down 脚本，
+// 脚本能正确拆分且没有混入其他方言的语法
+func TestMigrations_Dialects(t *testing.T) {
+	foreign := map[string][]string{
+		"sqlite":   {"AUTO_INCREMENT", "ENGINE=", "bigserial", "timestamptz", "NOW(3)", "`"},
+		"mysql":    {"AUTOINCREMENT", "bigserial", "timestamptz", "IF NOT EXISTS idx_", "now()"},
+		"postgres": {"AUTOINCREMENT", "AUTO_INCREMENT", "ENGINE=", "datetime", "`"},
+	}
+
+	var expected []string
+	for _, driver := range dialects {
+		list, err := LoadMigrations(migrations.FS, driver)
+		require.NoError(t, err, driver)
+
+		var names []string
+		for i, migration := range list {
+			name := fmt.Sprintf("%06d_%s", migration.Version, migration.Name)
+			names = append(names, name)
+			assert.Equal(t, int64(i+1), migration.Version, "%s: versions must be contiguous", driver)
+			// SQLite 的 000005 只有注释（FTS5 索引由 search 包创建），因此只要求文件非空
+			assert.NotEmpty(t, strings.TrimSpace(migration.Down), "%s/%s has no down file", driver, name)
+			for _, script := range []string{migration.Up, migration.Down} {
+				for _, stmt := range SplitStatements(script) {
+					assert.NotContains(t, stmt, "+migrate", "%s/%s has an unbalanced StatementBegin/End", driver, name)
+				}
+				for _, token := range foreign[driver] {
+					assert.NotContains(t, script, token, "%s/%s uses syntax of another dialect", driver, name)
+				}
+			}
+		}
+		if expected == nil {
+			expected = names
+			continue
+		}
+		assert.Equal(t, expected, names, "%s migrations differ from %s", driver, dialects[0])
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	forEachDialect(t, testUpDownStatus)
+}
+
+func testUpDownStatus(t *testing.T, db *gorm.DB, migrator *Migrator) {
+	ctx := context.Background()
+
+	pending, err := migrator.Pending(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pending)
+
+	done, err := migrator.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, done, len(pending))
+	assert.True(t, db.Migrator().HasTable("users"))
+
+	pending, _ = migrator.Pending(ctx)
+	assert.Empty(t, pending)
+
+	// 全部回滚后表被删除
+	done, err = migrator.Down(ctx, len(done))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, done)
+	assert.False(t, db.Migrator().HasTable("users"))
+}
+
+func TestMigrator_CategoriesBackfill(t *testing.T) {
+	forEachDialect(t, testCategoriesBackfill)
+}
+
+func testCategoriesBackfill(t *testing.T, db *gorm.DB, migrator *Migrator) {
+	ctx := context.Background()
+	_, err := migrator.Up(ctx, 5)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec(`INSERT INTO products (name, price, category) VALUES
+		('a', 1, 'Phones'), ('b', 1, ' phones'), ('c', 1, 'Home Garden'), ('d', 1, ''),
+		('e', 1, 'Phones & Tablets'), ('f', 1, 'phones tablets'), ('g', 1, '手机'), ('h', 1, '配件')`).Error)
+
+	_, err = migrator.Up(ctx, 1)
+	assert.NoError(t, err)
+
+	// slug 与 models.Slugify 一致，slug 相同的分类合并，产品指向新分类并使用统一的名称；
+	// 没有 ASCII 字母数字的名称各自成为分类
+	var categories []struct {
+		Name string
+		Slug string
+	}
+	db.Raw("SELECT name, slug FROM categories ORDER BY slug").Scan(&categories)
+	assert.Len(t, categories, 5)
+	slugs := make(map[string]string)
+	for _, category := range categories {
+		slugs[category.Slug] = category.Name
+		if models.Slugify(category.Name) != "" {
+			assert.Equal(t, models.Slugify(category.Name), category.Slug)
+		}
+	}
+	assert.Equal(t, "Home Garden", slugs["home-garden"])
+	assert.Equal(t, "Phones", slugs["phones"])
+	assert.Equal(t, "Phones & Tablets", slugs["phones-tablets"])
+	assert.Equal(t, "category", categories[0].Slug)
+	assert.Equal(t, "category-2", categories[1].Slug)
+
+	var products []struct {
+		Name       string
+		Category   string
+		CategoryID *uint
+	}
+	db.Raw("SELECT name, category, category_id FROM products ORDER BY name").Scan(&products)
+	assert.Equal(t, "Phones", products[1].Category)
+	assert.Equal(t, products[0].CategoryID, products[1].CategoryID)
+	assert.NotNil(t, products[2].CategoryID)
+	assert.Nil(t, products[3].CategoryID)
+	assert.Equal(t, "Phones & Tablets", products[5].Category)
+	assert.Equal(t, products[4].CategoryID, products[5].CategoryID)
+	assert.NotEqual(t, products[6].CategoryID, products[7].CategoryID)
+}
+
+func TestMigrator_MoneyBackfill(t *testing.T) {
+	forEachDialect(t, testMoneyBackfill)
+}
+
+func testMoneyBackfill(t *testing.T, db *gorm.DB, migrator *Migrator) {
+	ctx := context.Background()
+	_, err := migrator.Up(ctx, 7)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec(`INSERT INTO products (name, price, category) VALUES ('a', 19.99, ''), ('b', 0.1, '')`).Error)
+
+	_, err = migrator.Up(ctx, 1)
+	assert.NoError(t, err)
+
+	// 十进制价格换算为最小货币单位，币种回填为 USD
+	var prices []struct {
+		PriceMinor    int64
+		PriceCurrency string
+	}
+	db.Raw("SELECT price_minor, price_currency FROM products ORDER BY name").Scan(&prices)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, int64(1999), prices[0].PriceMinor)
+	assert.Equal(t, int64(10), prices[1].PriceMinor)
+	assert.Equal(t, "USD", prices[0].PriceCurrency)
+	assert.False(t, db.Migrator().HasColumn("products", "price"))
+	assert.True(t, db.Migrator().HasTable("product_prices"))
+}
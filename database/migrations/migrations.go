@@ -0,0 +1,13 @@
+// Package migrations 内嵌各数据库方言的 SQL 迁移文件。
+//
+// 文件命名为 <版本号>_<名称>.up.sql / <版本号>_<名称>.down.sql，按方言放在
+// sqlite、mysql、postgres 子目录中。包含多条语句且内部带分号的语句（如触发器），
+// 需要用 "-- +migrate StatementBegin" 和 "-- +migrate StatementEnd" 包裹。
+package migrations
+
+import "embed"
+
+// FS 所有迁移文件
+//
+//go:embed sqlite/*.sql mysql/*.sql postgres/*.sql
+var FS embed.FS
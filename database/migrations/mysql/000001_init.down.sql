@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS users;
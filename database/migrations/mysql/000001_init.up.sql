@@ -0,0 +1,84 @@
+CREATE TABLE IF NOT EXISTS users (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    username varchar(50) NOT NULL,
+    email varchar(100) NOT NULL,
+    password varchar(255) NOT NULL,
+    full_name varchar(100),
+    age bigint DEFAULT 0,
+    is_active boolean DEFAULT true,
+    UNIQUE INDEX idx_users_username (username),
+    UNIQUE INDEX idx_users_email (email),
+    INDEX idx_users_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS products (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    name varchar(200) NOT NULL,
+    description text,
+    price decimal(10,2) NOT NULL,
+    stock bigint DEFAULT 0,
+    category varchar(100),
+    is_available boolean DEFAULT true,
+    INDEX idx_products_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    user_id bigint unsigned NOT NULL,
+    family_id varchar(64) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    expires_at datetime(3) NOT NULL,
+    used_at datetime(3) NULL,
+    revoked_at datetime(3) NULL,
+    INDEX idx_refresh_tokens_user_id (user_id),
+    INDEX idx_refresh_tokens_family_id (family_id),
+    UNIQUE INDEX idx_refresh_tokens_token_hash (token_hash),
+    INDEX idx_refresh_tokens_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    name varchar(100) NOT NULL,
+    description varchar(255),
+    UNIQUE INDEX idx_permissions_name (name),
+    INDEX idx_permissions_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS roles (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    name varchar(50) NOT NULL,
+    description varchar(255),
+    UNIQUE INDEX idx_roles_name (name),
+    INDEX idx_roles_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id bigint unsigned NOT NULL,
+    permission_id bigint unsigned NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles (id),
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id bigint unsigned NOT NULL,
+    role_id bigint unsigned NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id) REFERENCES roles (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,31 @@
+ALTER TABLE products ADD COLUMN reserved bigint DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    product_id bigint unsigned NOT NULL,
+    type varchar(20) NOT NULL,
+    quantity bigint NOT NULL,
+    stock_after bigint NOT NULL,
+    reserved_after bigint NOT NULL,
+    reason varchar(255),
+    reference varchar(100),
+    actor_id bigint unsigned,
+    INDEX idx_stock_movements_created_at (created_at),
+    INDEX idx_stock_movements_product_id (product_id),
+    INDEX idx_stock_movements_reference (reference)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    product_id bigint unsigned NOT NULL,
+    quantity bigint NOT NULL,
+    reference varchar(100) NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'active',
+    INDEX idx_stock_reservations_product_id (product_id),
+    INDEX idx_stock_reservations_reference (reference),
+    INDEX idx_stock_reservations_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
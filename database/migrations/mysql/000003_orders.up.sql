@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    user_id bigint unsigned NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    total_amount decimal(10,2) NOT NULL,
+    note varchar(500),
+    paid_at datetime(3) NULL,
+    shipped_at datetime(3) NULL,
+    cancelled_at datetime(3) NULL,
+    refunded_at datetime(3) NULL,
+    INDEX idx_orders_user_id (user_id),
+    INDEX idx_orders_status (status),
+    INDEX idx_orders_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    order_id bigint unsigned NOT NULL,
+    product_id bigint unsigned NOT NULL,
+    product_name varchar(200) NOT NULL,
+    unit_price decimal(10,2) NOT NULL,
+    quantity bigint NOT NULL,
+    subtotal decimal(10,2) NOT NULL,
+    INDEX idx_order_items_order_id (order_id),
+    INDEX idx_order_items_product_id (product_id),
+    INDEX idx_order_items_deleted_at (deleted_at),
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
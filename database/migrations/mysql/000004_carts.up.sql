@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS carts (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    user_id bigint unsigned NULL,
+    token_hash varchar(64) NULL,
+    UNIQUE INDEX idx_carts_user_id (user_id),
+    UNIQUE INDEX idx_carts_token_hash (token_hash),
+    INDEX idx_carts_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    cart_id bigint unsigned NOT NULL,
+    product_id bigint unsigned NOT NULL,
+    quantity bigint NOT NULL,
+    UNIQUE INDEX idx_cart_items_cart_product (cart_id, product_id),
+    CONSTRAINT fk_carts_items FOREIGN KEY (cart_id) REFERENCES carts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_cart_items_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE products ADD FULLTEXT INDEX idx_products_fulltext (name, description, category);
@@ -0,0 +1,5 @@
+ALTER TABLE products
+    DROP FOREIGN KEY fk_products_category_ref,
+    DROP INDEX idx_products_category_id,
+    DROP COLUMN category_id;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    name varchar(100) NOT NULL,
+    slug varchar(100) NOT NULL,
+    description text,
+    parent_id bigint unsigned NULL,
+    sort_order bigint DEFAULT 0,
+    UNIQUE INDEX idx_categories_slug (slug),
+    INDEX idx_categories_parent_id (parent_id),
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories (id) ON DELETE RESTRICT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE products
+    ADD COLUMN category_id bigint unsigned NULL,
+    ADD INDEX idx_products_category_id (category_id),
+    ADD CONSTRAINT fk_products_category_ref FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE RESTRICT;
+
+-- 已有的分类字符串由 Go 回填步骤转换为顶级分类（见 database/backfill.go），
+-- slug 与应用中 models.Slugify 的结果一致
@@ -0,0 +1,19 @@
+ALTER TABLE products DROP INDEX idx_products_fulltext;
+ALTER TABLE products DROP COLUMN variant_terms;
+ALTER TABLE products ADD FULLTEXT INDEX idx_products_fulltext (name, description, category);
+
+ALTER TABLE order_items
+    DROP INDEX idx_order_items_variant_id,
+    DROP COLUMN sku,
+    DROP COLUMN variant_id;
+
+ALTER TABLE stock_reservations
+    DROP INDEX idx_stock_reservations_variant_id,
+    DROP COLUMN variant_id;
+
+ALTER TABLE stock_movements
+    DROP INDEX idx_stock_movements_variant_id,
+    DROP COLUMN variant_id;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS attribute_definitions;
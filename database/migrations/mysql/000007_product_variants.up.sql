@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    category_id bigint unsigned NOT NULL,
+    code varchar(50) NOT NULL,
+    name varchar(100) NOT NULL,
+    type varchar(20) NOT NULL,
+    options text,
+    required boolean DEFAULT false,
+    sort_order bigint DEFAULT 0,
+    UNIQUE INDEX idx_attribute_definitions_category_code (category_id, code),
+    CONSTRAINT fk_attribute_definitions_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    product_id bigint unsigned NOT NULL,
+    sku varchar(64) NOT NULL,
+    barcode varchar(64),
+    price decimal(10,2),
+    stock bigint DEFAULT 0,
+    reserved bigint DEFAULT 0,
+    attributes text,
+    is_available boolean DEFAULT true,
+    sort_order bigint DEFAULT 0,
+    INDEX idx_product_variants_product_id (product_id),
+    UNIQUE INDEX idx_product_variants_sku (sku),
+    INDEX idx_product_variants_barcode (barcode)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE stock_movements
+    ADD COLUMN variant_id bigint unsigned NULL,
+    ADD INDEX idx_stock_movements_variant_id (variant_id);
+
+ALTER TABLE stock_reservations
+    ADD COLUMN variant_id bigint unsigned NULL,
+    ADD INDEX idx_stock_reservations_variant_id (variant_id);
+
+ALTER TABLE order_items
+    ADD COLUMN variant_id bigint unsigned NULL,
+    ADD COLUMN sku varchar(64),
+    ADD INDEX idx_order_items_variant_id (variant_id);
+
+-- MATCH 的列必须与 FULLTEXT 索引一致，变体列加入后重建索引
+ALTER TABLE products ADD COLUMN variant_terms text;
+ALTER TABLE products DROP INDEX idx_products_fulltext;
+ALTER TABLE products ADD FULLTEXT INDEX idx_products_fulltext (name, description, category, variant_terms);
@@ -0,0 +1,30 @@
+-- 非 USD 的金额按原值写回，回滚前请确认只使用了 USD
+DROP TABLE IF EXISTS product_prices;
+
+ALTER TABLE order_items
+    ADD COLUMN unit_price decimal(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN subtotal decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE order_items SET unit_price = unit_price_minor / 100, subtotal = subtotal_minor / 100;
+ALTER TABLE order_items
+    DROP COLUMN subtotal_currency,
+    DROP COLUMN subtotal_minor,
+    DROP COLUMN unit_price_currency,
+    DROP COLUMN unit_price_minor;
+
+ALTER TABLE orders ADD COLUMN total_amount decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE orders SET total_amount = total_minor / 100;
+ALTER TABLE orders
+    DROP COLUMN total_currency,
+    DROP COLUMN total_minor;
+
+ALTER TABLE product_variants ADD COLUMN price decimal(10,2) NULL;
+UPDATE product_variants SET price = price_minor / 100 WHERE price_minor IS NOT NULL;
+ALTER TABLE product_variants
+    DROP COLUMN price_currency,
+    DROP COLUMN price_minor;
+
+ALTER TABLE products ADD COLUMN price decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE products SET price = price_minor / 100;
+ALTER TABLE products
+    DROP COLUMN price_currency,
+    DROP COLUMN price_minor;
@@ -0,0 +1,41 @@
+-- 金额改为最小货币单位的整数加 ISO 4217 货币代码，已有数据按 USD（两位小数）回填
+ALTER TABLE products
+    ADD COLUMN price_minor bigint NULL,
+    ADD COLUMN price_currency varchar(3) NULL;
+UPDATE products SET price_minor = ROUND(price * 100), price_currency = 'USD';
+ALTER TABLE products DROP COLUMN price;
+
+ALTER TABLE product_variants
+    ADD COLUMN price_minor bigint NULL,
+    ADD COLUMN price_currency varchar(3) NULL;
+UPDATE product_variants SET price_minor = ROUND(price * 100), price_currency = 'USD' WHERE price IS NOT NULL;
+ALTER TABLE product_variants DROP COLUMN price;
+
+ALTER TABLE orders
+    ADD COLUMN total_minor bigint NULL,
+    ADD COLUMN total_currency varchar(3) NULL;
+UPDATE orders SET total_minor = ROUND(total_amount * 100), total_currency = 'USD';
+ALTER TABLE orders DROP COLUMN total_amount;
+
+ALTER TABLE order_items
+    ADD COLUMN unit_price_minor bigint NULL,
+    ADD COLUMN unit_price_currency varchar(3) NULL,
+    ADD COLUMN subtotal_minor bigint NULL,
+    ADD COLUMN subtotal_currency varchar(3) NULL;
+UPDATE order_items SET
+    unit_price_minor = ROUND(unit_price * 100), unit_price_currency = 'USD',
+    subtotal_minor = ROUND(subtotal * 100), subtotal_currency = 'USD';
+ALTER TABLE order_items
+    DROP COLUMN unit_price,
+    DROP COLUMN subtotal;
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    product_id bigint unsigned NOT NULL,
+    currency varchar(3) NOT NULL,
+    amount_minor bigint NOT NULL,
+    UNIQUE INDEX idx_product_prices_product_currency (product_id, currency),
+    CONSTRAINT fk_product_prices_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS product_images (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    product_id bigint unsigned NOT NULL,
+    storage_key varchar(255) NOT NULL,
+    url varchar(500) NOT NULL,
+    content_type varchar(50) NOT NULL,
+    size bigint NOT NULL,
+    width bigint NULL,
+    height bigint NULL,
+    alt_text varchar(255) NULL,
+    sort_order bigint DEFAULT 0,
+    is_primary boolean DEFAULT false,
+    thumbnails text NULL,
+    INDEX idx_product_images_product_id (product_id),
+    CONSTRAINT fk_product_images_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+-- 可选的产品 SKU，批量导入时用于匹配已有产品
+ALTER TABLE products
+    ADD COLUMN sku varchar(64) NULL,
+    ADD UNIQUE INDEX idx_products_sku (sku);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    queue varchar(50) NOT NULL,
+    type varchar(100) NOT NULL,
+    payload text NULL,
+    status varchar(20) NOT NULL,
+    run_at datetime(3) NOT NULL,
+    attempts bigint NOT NULL,
+    max_attempts bigint NOT NULL,
+    last_error text NULL,
+    locked_by varchar(100) NULL,
+    locked_at datetime(3) NULL,
+    finished_at datetime(3) NULL,
+    INDEX idx_jobs_poll (queue, status, run_at),
+    INDEX idx_jobs_type (type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
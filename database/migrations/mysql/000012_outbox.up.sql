@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    event_key varchar(36) NOT NULL,
+    type varchar(100) NOT NULL,
+    aggregate_type varchar(50) NOT NULL,
+    aggregate_id bigint unsigned NOT NULL,
+    payload text NULL,
+    status varchar(20) NOT NULL,
+    attempts bigint NOT NULL,
+    next_attempt_at datetime(3) NOT NULL,
+    last_error text NULL,
+    locked_until datetime(3) NULL,
+    dispatched_at datetime(3) NULL,
+    UNIQUE INDEX idx_outbox_events_event_key (event_key),
+    INDEX idx_outbox_events_type (type),
+    INDEX idx_outbox_events_aggregate (aggregate_type, aggregate_id),
+    INDEX idx_outbox_events_poll (status, next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    event_id bigint unsigned NOT NULL,
+    subscriber varchar(100) NOT NULL,
+    UNIQUE INDEX idx_outbox_deliveries_event_subscriber (event_id, subscriber)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    updated_at datetime(3) NULL,
+    deleted_at datetime(3) NULL,
+    url varchar(500) NOT NULL,
+    description varchar(255) NULL,
+    events text NULL,
+    secret varchar(100) NOT NULL,
+    active boolean NOT NULL,
+    consecutive_failures bigint NOT NULL,
+    last_delivery_at datetime(3) NULL,
+    disabled_at datetime(3) NULL,
+    disabled_reason varchar(255) NULL,
+    INDEX idx_webhooks_deleted_at (deleted_at),
+    INDEX idx_webhooks_active (active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    created_at datetime(3) NULL,
+    webhook_id bigint unsigned NOT NULL,
+    event_key varchar(36) NOT NULL,
+    event_type varchar(100) NOT NULL,
+    payload text NULL,
+    attempt bigint NOT NULL,
+    success boolean NOT NULL,
+    status_code bigint NULL,
+    response_body text NULL,
+    error text NULL,
+    duration_ms bigint NULL,
+    replay_of bigint unsigned NULL,
+    INDEX idx_webhook_deliveries_created_at (created_at),
+    INDEX idx_webhook_deliveries_webhook_id (webhook_id),
+    INDEX idx_webhook_deliveries_event_key (event_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,7 @@
+-- 恢复每个产品只有一项的约束前，移除指定了变体的购物车商品
+DELETE FROM cart_items WHERE variant_id IS NOT NULL;
+ALTER TABLE cart_items
+    DROP FOREIGN KEY fk_cart_items_variant,
+    DROP INDEX idx_cart_items_cart_product,
+    ADD UNIQUE INDEX idx_cart_items_cart_product (cart_id, product_id),
+    DROP COLUMN variant_id;
@@ -0,0 +1,7 @@
+-- 购物车商品可以指定变体，同一产品的不同变体各占一项。
+-- 唯一索引在同一语句中替换，fk_carts_items 始终有可用的 cart_id 索引
+ALTER TABLE cart_items
+    ADD COLUMN variant_id bigint unsigned NULL,
+    DROP INDEX idx_cart_items_cart_product,
+    ADD UNIQUE INDEX idx_cart_items_cart_product (cart_id, product_id, variant_id),
+    ADD CONSTRAINT fk_cart_items_variant FOREIGN KEY (variant_id) REFERENCES product_variants (id) ON DELETE CASCADE;
@@ -0,0 +1,31 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reserved bigint DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    product_id bigint NOT NULL,
+    type varchar(20) NOT NULL,
+    quantity bigint NOT NULL,
+    stock_after bigint NOT NULL,
+    reserved_after bigint NOT NULL,
+    reason varchar(255),
+    reference varchar(100),
+    actor_id bigint
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements (created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reference ON stock_movements (reference);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    product_id bigint NOT NULL,
+    quantity bigint NOT NULL,
+    reference varchar(100) NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'active'
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product_id ON stock_reservations (product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_reference ON stock_reservations (reference);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_deleted_at ON stock_reservations (deleted_at);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    total_amount decimal(10,2) NOT NULL,
+    note varchar(500),
+    paid_at timestamptz,
+    shipped_at timestamptz,
+    cancelled_at timestamptz,
+    refunded_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id bigint NOT NULL,
+    product_id bigint NOT NULL,
+    product_name varchar(200) NOT NULL,
+    unit_price decimal(10,2) NOT NULL,
+    quantity bigint NOT NULL,
+    subtotal decimal(10,2) NOT NULL,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_deleted_at ON order_items (deleted_at);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    name varchar(100) NOT NULL,
+    slug varchar(100) NOT NULL,
+    description text,
+    parent_id bigint,
+    sort_order bigint DEFAULT 0,
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories (id) ON DELETE RESTRICT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id bigint
+    CONSTRAINT fk_products_category_ref REFERENCES categories (id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+
+-- 已有的分类字符串由 Go 回填步骤转换为顶级分类（见 database/backfill.go），
+-- slug 与应用中 models.Slugify 的结果一致
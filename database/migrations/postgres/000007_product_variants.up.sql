@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    category_id bigint NOT NULL,
+    code varchar(50) NOT NULL,
+    name varchar(100) NOT NULL,
+    type varchar(20) NOT NULL,
+    options text,
+    required boolean DEFAULT false,
+    sort_order bigint DEFAULT 0,
+    CONSTRAINT fk_attribute_definitions_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attribute_definitions_category_code ON attribute_definitions (category_id, code);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    product_id bigint NOT NULL,
+    sku varchar(64) NOT NULL,
+    barcode varchar(64),
+    price decimal(10,2),
+    stock bigint DEFAULT 0,
+    reserved bigint DEFAULT 0,
+    attributes text,
+    is_available boolean DEFAULT true,
+    sort_order bigint DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants (sku);
+CREATE INDEX IF NOT EXISTS idx_product_variants_barcode ON product_variants (barcode);
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS variant_id bigint;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_variant_id ON stock_movements (variant_id);
+
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS variant_id bigint;
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_variant_id ON stock_reservations (variant_id);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id bigint;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sku varchar(64);
+CREATE INDEX IF NOT EXISTS idx_order_items_variant_id ON order_items (variant_id);
+
+-- 生成列的表达式不能修改，变体列加入后重新生成 search_vector
+ALTER TABLE products ADD COLUMN IF NOT EXISTS variant_terms text;
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(category, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(variant_terms, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
@@ -0,0 +1,25 @@
+-- 非 USD 的金额按原值写回，回滚前请确认只使用了 USD
+DROP TABLE IF EXISTS product_prices;
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_price decimal(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS subtotal decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE order_items SET unit_price = unit_price_minor / 100.0, subtotal = subtotal_minor / 100.0;
+ALTER TABLE order_items DROP COLUMN IF EXISTS subtotal_currency;
+ALTER TABLE order_items DROP COLUMN IF EXISTS subtotal_minor;
+ALTER TABLE order_items DROP COLUMN IF EXISTS unit_price_currency;
+ALTER TABLE order_items DROP COLUMN IF EXISTS unit_price_minor;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_amount decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE orders SET total_amount = total_minor / 100.0;
+ALTER TABLE orders DROP COLUMN IF EXISTS total_currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS total_minor;
+
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS price decimal(10,2);
+UPDATE product_variants SET price = price_minor / 100.0 WHERE price_minor IS NOT NULL;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS price_currency;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS price_minor;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE products SET price = price_minor / 100.0;
+ALTER TABLE products DROP COLUMN IF EXISTS price_currency;
+ALTER TABLE products DROP COLUMN IF EXISTS price_minor;
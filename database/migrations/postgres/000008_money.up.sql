@@ -0,0 +1,36 @@
+-- 金额改为最小货币单位的整数加 ISO 4217 货币代码，已有数据按 USD（两位小数）回填
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price_minor bigint;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price_currency varchar(3);
+UPDATE products SET price_minor = ROUND(price * 100), price_currency = 'USD';
+ALTER TABLE products DROP COLUMN IF EXISTS price;
+
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS price_minor bigint;
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS price_currency varchar(3);
+UPDATE product_variants SET price_minor = ROUND(price * 100), price_currency = 'USD' WHERE price IS NOT NULL;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS price;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_minor bigint;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_currency varchar(3);
+UPDATE orders SET total_minor = ROUND(total_amount * 100), total_currency = 'USD';
+ALTER TABLE orders DROP COLUMN IF EXISTS total_amount;
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_price_minor bigint;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_price_currency varchar(3);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS subtotal_minor bigint;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS subtotal_currency varchar(3);
+UPDATE order_items SET
+    unit_price_minor = ROUND(unit_price * 100), unit_price_currency = 'USD',
+    subtotal_minor = ROUND(subtotal * 100), subtotal_currency = 'USD';
+ALTER TABLE order_items DROP COLUMN IF EXISTS unit_price;
+ALTER TABLE order_items DROP COLUMN IF EXISTS subtotal;
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    product_id bigint NOT NULL,
+    currency varchar(3) NOT NULL,
+    amount_minor bigint NOT NULL,
+    CONSTRAINT fk_product_prices_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_prices_product_currency ON product_prices (product_id, currency);
@@ -0,0 +1,2 @@
+-- 存储中的图片文件不会被删除
+DROP TABLE IF EXISTS product_images;
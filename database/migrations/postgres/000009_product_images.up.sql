@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS product_images (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    product_id bigint NOT NULL,
+    storage_key varchar(255) NOT NULL,
+    url varchar(500) NOT NULL,
+    content_type varchar(50) NOT NULL,
+    size bigint NOT NULL,
+    width bigint,
+    height bigint,
+    alt_text varchar(255),
+    sort_order bigint DEFAULT 0,
+    is_primary boolean DEFAULT false,
+    thumbnails text,
+    CONSTRAINT fk_product_images_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id ON product_images (product_id);
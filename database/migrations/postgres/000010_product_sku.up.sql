@@ -0,0 +1,3 @@
+-- 可选的产品 SKU，批量导入时用于匹配已有产品
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku varchar(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
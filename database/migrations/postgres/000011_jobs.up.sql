@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    queue varchar(50) NOT NULL,
+    type varchar(100) NOT NULL,
+    payload text,
+    status varchar(20) NOT NULL,
+    run_at timestamptz NOT NULL,
+    attempts bigint NOT NULL,
+    max_attempts bigint NOT NULL,
+    last_error text,
+    locked_by varchar(100),
+    locked_at timestamptz,
+    finished_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_poll ON jobs (queue, status, run_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs (type);
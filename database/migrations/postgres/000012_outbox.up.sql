@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    event_key varchar(36) NOT NULL,
+    type varchar(100) NOT NULL,
+    aggregate_type varchar(50) NOT NULL,
+    aggregate_id bigint NOT NULL,
+    payload text,
+    status varchar(20) NOT NULL,
+    attempts bigint NOT NULL,
+    next_attempt_at timestamptz NOT NULL,
+    last_error text,
+    locked_until timestamptz,
+    dispatched_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_event_key ON outbox_events (event_key);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_type ON outbox_events (type);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_poll ON outbox_events (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    event_id bigint NOT NULL,
+    subscriber varchar(100) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_deliveries_event_subscriber ON outbox_deliveries (event_id, subscriber);
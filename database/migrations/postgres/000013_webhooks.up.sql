@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    url varchar(500) NOT NULL,
+    description varchar(255),
+    events text,
+    secret varchar(100) NOT NULL,
+    active boolean NOT NULL,
+    consecutive_failures bigint NOT NULL,
+    last_delivery_at timestamptz,
+    disabled_at timestamptz,
+    disabled_reason varchar(255)
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_webhooks_active ON webhooks (active);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    webhook_id bigint NOT NULL,
+    event_key varchar(36) NOT NULL,
+    event_type varchar(100) NOT NULL,
+    payload text,
+    attempt bigint NOT NULL,
+    success boolean NOT NULL,
+    status_code bigint,
+    response_body text,
+    error text,
+    duration_ms bigint,
+    replay_of bigint
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_key ON webhook_deliveries (event_key);
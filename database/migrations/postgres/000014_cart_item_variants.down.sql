@@ -0,0 +1,5 @@
+-- 恢复每个产品只有一项的约束前，移除指定了变体的购物车商品
+DELETE FROM cart_items WHERE variant_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_cart_items_cart_product;
+ALTER TABLE cart_items DROP COLUMN IF EXISTS variant_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_cart_product ON cart_items (cart_id, product_id);
@@ -0,0 +1,5 @@
+-- 购物车商品可以指定变体，同一产品的不同变体各占一项
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS variant_id bigint
+    CONSTRAINT fk_cart_items_variant REFERENCES product_variants (id) ON DELETE CASCADE;
+DROP INDEX IF EXISTS idx_cart_items_cart_product;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_cart_product ON cart_items (cart_id, product_id, variant_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS stock_movements;
+ALTER TABLE products DROP COLUMN reserved;
@@ -0,0 +1,31 @@
+ALTER TABLE products ADD COLUMN reserved integer DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    product_id integer NOT NULL,
+    type varchar(20) NOT NULL,
+    quantity integer NOT NULL,
+    stock_after integer NOT NULL,
+    reserved_after integer NOT NULL,
+    reason varchar(255),
+    reference varchar(100),
+    actor_id integer
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements (created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reference ON stock_movements (reference);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    product_id integer NOT NULL,
+    quantity integer NOT NULL,
+    reference varchar(100) NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'active'
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product_id ON stock_reservations (product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_reference ON stock_reservations (reference);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_deleted_at ON stock_reservations (deleted_at);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS products_fts_au;
+DROP TRIGGER IF EXISTS products_fts_ad;
+DROP TRIGGER IF EXISTS products_fts_ai;
+DROP TABLE IF EXISTS products_fts;
@@ -0,0 +1,2 @@
+-- SQLite 的 FTS5 索引（products_fts 及同步触发器）由 000007 的 Go 步骤创建（database/backfill.go），
+-- 因为 FTS5 只有以 -tags sqlite_fts5 编译时可用，未启用时搜索退回 LIKE 查询
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    name varchar(100) NOT NULL,
+    slug varchar(100) NOT NULL,
+    description text,
+    parent_id integer,
+    sort_order integer DEFAULT 0,
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories (id) ON DELETE RESTRICT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+-- SQLite 不允许删除带外键约束的列，这里不声明约束以便回滚
+ALTER TABLE products ADD COLUMN category_id integer;
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+
+-- 已有的分类字符串由 Go 回填步骤转换为顶级分类（见 database/backfill.go），
+-- slug 与应用中 models.Slugify 的结果一致
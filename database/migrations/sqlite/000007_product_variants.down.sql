@@ -0,0 +1,20 @@
+-- 同步触发器引用了 variant_terms 列，先删除 FTS5 索引，重新升级时由 Go 步骤重建
+DROP TRIGGER IF EXISTS products_fts_au;
+DROP TRIGGER IF EXISTS products_fts_ad;
+DROP TRIGGER IF EXISTS products_fts_ai;
+DROP TABLE IF EXISTS products_fts;
+
+DROP INDEX IF EXISTS idx_order_items_variant_id;
+ALTER TABLE order_items DROP COLUMN sku;
+ALTER TABLE order_items DROP COLUMN variant_id;
+
+DROP INDEX IF EXISTS idx_stock_reservations_variant_id;
+ALTER TABLE stock_reservations DROP COLUMN variant_id;
+
+DROP INDEX IF EXISTS idx_stock_movements_variant_id;
+ALTER TABLE stock_movements DROP COLUMN variant_id;
+
+ALTER TABLE products DROP COLUMN variant_terms;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS attribute_definitions;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    category_id integer NOT NULL,
+    code varchar(50) NOT NULL,
+    name varchar(100) NOT NULL,
+    type varchar(20) NOT NULL,
+    options text,
+    required numeric DEFAULT false,
+    sort_order integer DEFAULT 0,
+    CONSTRAINT fk_attribute_definitions_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attribute_definitions_category_code ON attribute_definitions (category_id, code);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    product_id integer NOT NULL,
+    sku varchar(64) NOT NULL,
+    barcode varchar(64),
+    price decimal(10,2),
+    stock integer DEFAULT 0,
+    reserved integer DEFAULT 0,
+    attributes text,
+    is_available numeric DEFAULT true,
+    sort_order integer DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants (sku);
+CREATE INDEX IF NOT EXISTS idx_product_variants_barcode ON product_variants (barcode);
+
+ALTER TABLE products ADD COLUMN variant_terms text;
+
+ALTER TABLE stock_movements ADD COLUMN variant_id integer;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_variant_id ON stock_movements (variant_id);
+
+ALTER TABLE stock_reservations ADD COLUMN variant_id integer;
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_variant_id ON stock_reservations (variant_id);
+
+ALTER TABLE order_items ADD COLUMN variant_id integer;
+ALTER TABLE order_items ADD COLUMN sku varchar(64);
+CREATE INDEX IF NOT EXISTS idx_order_items_variant_id ON order_items (variant_id);
+
+-- FTS5 索引由本迁移的 Go 步骤创建（database/backfill.go 中的 createSQLiteFTS）
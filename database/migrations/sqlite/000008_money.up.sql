@@ -0,0 +1,36 @@
+-- 金额改为最小货币单位的整数加 ISO 4217 货币代码，已有数据按 USD（两位小数）回填
+ALTER TABLE products ADD COLUMN price_minor integer;
+ALTER TABLE products ADD COLUMN price_currency varchar(3);
+UPDATE products SET price_minor = CAST(ROUND(price * 100) AS integer), price_currency = 'USD';
+ALTER TABLE products DROP COLUMN price;
+
+ALTER TABLE product_variants ADD COLUMN price_minor integer;
+ALTER TABLE product_variants ADD COLUMN price_currency varchar(3);
+UPDATE product_variants SET price_minor = CAST(ROUND(price * 100) AS integer), price_currency = 'USD' WHERE price IS NOT NULL;
+ALTER TABLE product_variants DROP COLUMN price;
+
+ALTER TABLE orders ADD COLUMN total_minor integer;
+ALTER TABLE orders ADD COLUMN total_currency varchar(3);
+UPDATE orders SET total_minor = CAST(ROUND(total_amount * 100) AS integer), total_currency = 'USD';
+ALTER TABLE orders DROP COLUMN total_amount;
+
+ALTER TABLE order_items ADD COLUMN unit_price_minor integer;
+ALTER TABLE order_items ADD COLUMN unit_price_currency varchar(3);
+ALTER TABLE order_items ADD COLUMN subtotal_minor integer;
+ALTER TABLE order_items ADD COLUMN subtotal_currency varchar(3);
+UPDATE order_items SET
+    unit_price_minor = CAST(ROUND(unit_price * 100) AS integer), unit_price_currency = 'USD',
+    subtotal_minor = CAST(ROUND(subtotal * 100) AS integer), subtotal_currency = 'USD';
+ALTER TABLE order_items DROP COLUMN unit_price;
+ALTER TABLE order_items DROP COLUMN subtotal;
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    product_id integer NOT NULL,
+    currency varchar(3) NOT NULL,
+    amount_minor integer NOT NULL,
+    CONSTRAINT fk_product_prices_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_prices_product_currency ON product_prices (product_id, currency);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    queue varchar(50) NOT NULL,
+    type varchar(100) NOT NULL,
+    payload text,
+    status varchar(20) NOT NULL,
+    run_at datetime NOT NULL,
+    attempts integer NOT NULL,
+    max_attempts integer NOT NULL,
+    last_error text,
+    locked_by varchar(100),
+    locked_at datetime,
+    finished_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_poll ON jobs (queue, status, run_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs (type);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    event_key varchar(36) NOT NULL,
+    type varchar(100) NOT NULL,
+    aggregate_type varchar(50) NOT NULL,
+    aggregate_id integer NOT NULL,
+    payload text,
+    status varchar(20) NOT NULL,
+    attempts integer NOT NULL,
+    next_attempt_at datetime NOT NULL,
+    last_error text,
+    locked_until datetime,
+    dispatched_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_event_key ON outbox_events (event_key);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_type ON outbox_events (type);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_poll ON outbox_events (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    event_id integer NOT NULL,
+    subscriber varchar(100) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_deliveries_event_subscriber ON outbox_deliveries (event_id, subscriber);
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    url varchar(500) NOT NULL,
+    description varchar(255),
+    events text,
+    secret varchar(100) NOT NULL,
+    active numeric NOT NULL,
+    consecutive_failures integer NOT NULL,
+    last_delivery_at datetime,
+    disabled_at datetime,
+    disabled_reason varchar(255)
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_webhooks_active ON webhooks (active);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    webhook_id integer NOT NULL,
+    event_key varchar(36) NOT NULL,
+    event_type varchar(100) NOT NULL,
+    payload text,
+    attempt integer NOT NULL,
+    success numeric NOT NULL,
+    status_code integer,
+    response_body text,
+    error text,
+    duration_ms integer,
+    replay_of integer
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_key ON webhook_deliveries (event_key);
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// SeedRBAC 初始化内置权限和 admin 角色，并将 adminUsername 对应的用户设为管理员
+func SeedRBAC(adminUsername string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, name := range models.DefaultPermissions {
+			if err := tx.Where(models.Permission{Name: name}).FirstOrCreate(&models.Permission{}).Error; err != nil {
+				return fmt.Errorf("failed to seed permission %s: %w", name, err)
+			}
+		}
+
+		var all models.Permission
+		if err := tx.Where("name = ?", models.PermissionAll).First(&all).Error; err != nil {
+			return err
+		}
+
+		var admin models.Role
+		err := tx.Where(models.Role{Name: models.RoleAdmin}).
+			Attrs(models.Role{Description: "Built-in administrator role"}).
+			FirstOrCreate(&admin).Error
+		if err != nil {
+			return fmt.Errorf("failed to seed admin role: %w", err)
+		}
+		if err := tx.Model(&admin).Association("Permissions").Append(&all); err != nil {
+			return err
+		}
+
+		if adminUsername == "" {
+			return nil
+		}
+		var user models.User
+		if err := tx.Where("username = ?", adminUsername).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				log.Printf("Bootstrap admin %q not found, skipping role assignment", adminUsername)
+				return nil
+			}
+			return err
+		}
+		return tx.Model(&user).Association("Roles").Append(&admin)
+	})
+}
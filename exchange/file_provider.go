@@ -0,0 +1,93 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+)
+
+// rateFile 汇率文件格式，汇率使用字符串以保持精度：
+//
+//	{"base": "USD", "rates": {"EUR": "0.92", "JPY": "151.37"}}
+type rateFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// FileProvider 从本地 JSON 文件读取汇率，文件修改后在下次查询时自动重新加载，
+// 适用于测试和由外部任务定期写入汇率文件的部署
+type FileProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	static  *Static
+}
+
+// NewFileProvider 创建文件汇率来源并立即加载一次，文件不存在或格式错误时返回错误
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if _, err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Rate 实现 Provider
+func (p *FileProvider) Rate(ctx context.Context, from, to string) (*big.Rat, error) {
+	static, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	return static.Rate(ctx, from, to)
+}
+
+// load 文件修改时间变化时重新解析，否则返回缓存的汇率表
+func (p *FileProvider) load() (*Static, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.static != nil && info.ModTime().Equal(p.modTime) {
+		return p.static, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse exchange rates %s: %w", p.path, err)
+	}
+	base, err := models.NormalizeCurrency(file.Base)
+	if err != nil {
+		return nil, fmt.Errorf("exchange rates %s: %w", p.path, err)
+	}
+
+	rates := make(map[string]*big.Rat, len(file.Rates))
+	for code, raw := range file.Rates {
+		currency, err := models.NormalizeCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("exchange rates %s: %w", p.path, err)
+		}
+		rate, ok := new(big.Rat).SetString(raw)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("exchange rates %s: invalid rate %q for %s", p.path, raw, currency)
+		}
+		rates[currency] = rate
+	}
+
+	p.static = NewStatic(base, rates)
+	p.modTime = info.ModTime()
+	return p.static, nil
+}
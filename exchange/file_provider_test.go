@@ -0,0 +1,59 @@
+package exchange
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRates(t *testing.T, path, content string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestFileProvider_CrossRatesAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	now := time.Now()
+	writeRates(t, path, `{"base":"USD","rates":{"EUR":"0.5","GBP":"0.25"}}`, now)
+
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	rate, err := provider.Rate(ctx, "EUR", "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(1, 2), rate)
+
+	price, err := Convert(ctx, provider, models.NewMoney(1000, "USD"), "eur")
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(500, "EUR"), price)
+
+	_, err = provider.Rate(ctx, "USD", "JPY")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	// 文件修改后下一次查询使用新汇率
+	writeRates(t, path, `{"base":"USD","rates":{"EUR":"0.8","JPY":"150"}}`, now.Add(time.Minute))
+	rate, err = provider.Rate(ctx, "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(4, 5), rate)
+	_, err = provider.Rate(ctx, "USD", "JPY")
+	assert.NoError(t, err)
+}
+
+func TestNewFileProvider_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewFileProvider(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "rates.json")
+	writeRates(t, path, `{"base":"USD","rates":{"EUR":"-1"}}`, time.Now())
+	_, err = NewFileProvider(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+)
+
+// ErrRateNotFound 没有两种货币之间的汇率
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Provider 汇率来源，可以替换为外部汇率服务
+type Provider interface {
+	// Rate 返回 1 单位 from 可兑换的 to 数量
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
+
+var (
+	mu              sync.RWMutex
+	defaultProvider Provider = NewStatic(models.DefaultCurrency, nil)
+)
+
+// SetDefault 设置全局汇率来源，启动时根据配置调用
+func SetDefault(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultProvider = p
+}
+
+// Default 返回全局汇率来源，未配置时只能在相同币种间换算
+func Default() Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultProvider
+}
+
+// Convert 使用汇率来源将金额换算为目标币种，币种相同时原样返回
+func Convert(ctx context.Context, p Provider, money models.Money, currency string) (models.Money, error) {
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if money.Currency == currency {
+		return money, nil
+	}
+	rate, err := p.Rate(ctx, money.Currency, currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+	return money.Convert(rate, currency)
+}
+
+// Static 固定汇率表，所有汇率相对于同一基准货币，其他币种之间经由基准货币交叉换算
+type Static struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// NewStatic 创建固定汇率表，rates 为 1 单位基准货币可兑换的各币种数量
+func NewStatic(base string, rates map[string]*big.Rat) *Static {
+	normalized := make(map[string]*big.Rat, len(rates))
+	for currency, rate := range rates {
+		normalized[strings.ToUpper(currency)] = rate
+	}
+	return &Static{base: strings.ToUpper(base), rates: normalized}
+}
+
+// Rate 实现 Provider
+func (s *Static) Rate(ctx context.Context, from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	fromRate, err := s.baseRate(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := s.baseRate(to)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// baseRate 1 单位基准货币可兑换的 currency 数量
+func (s *Static) baseRate(currency string) (*big.Rat, error) {
+	if currency == s.base {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := s.rates[currency]
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRateNotFound, currency)
+	}
+	return rate, nil
+}
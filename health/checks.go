@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/database"
+	"gorm.io/gorm"
+)
+
+// DBPing 检查数据库连接是否可用
+func DBPing(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Migrations 检查是否所有版本化迁移都已执行，代码比数据库结构新时不接收流量
+func Migrations(db *gorm.DB, driver string) CheckFunc {
+	return func(ctx context.Context) error {
+		migrator, err := database.NewMigrator(db, driver)
+		if err != nil {
+			return err
+		}
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		names := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			names = append(names, fmt.Sprintf("%06d_%s", migration.Version, migration.Name))
+		}
+		return fmt.Errorf("%d pending migrations: %s", len(pending), strings.Join(names, ", "))
+	}
+}
+
+// DiskSpace 检查 dir 所在文件系统的可用空间不少于 minFree 字节，用于 SQLite 数据库所在目录
+func DiskSpace(dir string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeSpace(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("only %d MB free in %s, need at least %d MB", free>>20, dir, minFree>>20)
+		}
+		return nil
+	}
+}
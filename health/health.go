@@ -0,0 +1,230 @@
+// Package health 提供存活和就绪探针使用的依赖检查注册表
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 检查状态
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrShuttingDown 服务正在停止，就绪检查失败以便负载均衡摘除流量
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc 依赖检查，返回 nil 表示正常，应在 ctx 取消时尽快返回
+type CheckFunc func(ctx context.Context) error
+
+// Config 检查配置
+type Config struct {
+	// Timeout 单项检查的默认超时时间，默认 2s
+	Timeout time.Duration
+	// CacheTTL 检查结果的缓存时间，期间的探针请求直接返回上次结果，为 0 时不缓存
+	CacheTTL time.Duration
+}
+
+// Result 单项检查结果
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// Report 探针结果，任意一项检查失败时 Status 为 fail
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// OK 是否所有检查都通过
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Option 注册检查的选项
+type Option func(*check)
+
+// WithTimeout 设置检查的超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// Liveness 检查同时用于存活探针。存活检查失败会导致进程被重启，只应检查进程自身的状态
+func Liveness() Option {
+	return func(c *check) {
+		c.liveness = true
+	}
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	liveness bool
+
+	// mu 保证同一项检查同时只执行一次，并发的探针请求等待并共享结果
+	mu   sync.Mutex
+	last *Result
+}
+
+// Registry 检查注册表，所有检查用于就绪探针，标记为 Liveness 的检查同时用于存活探针
+type Registry struct {
+	cfg Config
+
+	mu     sync.RWMutex
+	checks []*check
+
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry 创建检查注册表
+func NewRegistry(cfg Config) *Registry {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	return &Registry{cfg: cfg}
+}
+
+// Register 注册检查，名称重复时替换原有检查
+func (r *Registry) Register(name string, fn CheckFunc, opts ...Option) {
+	c := &check{name: name, fn: fn, timeout: r.cfg.Timeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.checks {
+		if existing.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// SetShuttingDown 标记服务正在停止，之后的就绪检查都失败
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown 服务是否正在停止
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Liveness 执行存活检查
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+// Readiness 执行就绪检查，服务正在停止时额外返回失败的 shutdown 检查
+func (r *Registry) Readiness(ctx context.Context) Report {
+	report := r.run(ctx, false)
+	if r.ShuttingDown() {
+		report.Status = StatusFail
+		report.Checks = append(report.Checks, Result{
+			Name:      "shutdown",
+			Status:    StatusFail,
+			Error:     ErrShuttingDown.Error(),
+			CheckedAt: time.Now().UTC(),
+		})
+	}
+	return report
+}
+
+// run 并发执行检查，结果按注册顺序排列
+func (r *Registry) run(ctx context.Context, livenessOnly bool) Report {
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if !livenessOnly || c.liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			report.Checks[i] = r.result(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// result 返回检查结果，缓存未过期时直接返回上次结果
+func (r *Registry) result(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && r.cfg.CacheTTL > 0 && time.Since(c.last.CheckedAt) < r.cfg.CacheTTL {
+		cached := *c.last
+		cached.Cached = true
+		return cached
+	}
+
+	result := execute(ctx, c)
+	c.last = &result
+	return result
+}
+
+// execute 在超时时间内执行检查。探针请求断开不会中断检查，避免把取消当作失败缓存下来
+func execute(ctx context.Context, c *check) Result {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.New("check panicked")
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.New("timed out after " + c.timeout.String())
+	}
+
+	result := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
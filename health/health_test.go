@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(Config{Timeout: 50 * time.Millisecond, CacheTTL: time.Minute})
+
+	var calls int32
+	var failing atomic.Bool
+	registry.Register("database", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	registry.Register("process", func(ctx context.Context) error { return nil }, Liveness())
+
+	report := registry.Readiness(context.Background())
+	assert.True(t, report.OK())
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+	assert.False(t, report.Checks[0].Cached)
+
+	// 缓存期间不重复执行检查
+	failing.Store(true)
+	report = registry.Readiness(context.Background())
+	assert.True(t, report.OK())
+	assert.True(t, report.Checks[0].Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 存活探针只执行标记为 Liveness 的检查
+	report = registry.Liveness(context.Background())
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "process", report.Checks[0].Name)
+
+	// 停止期间就绪探针失败，存活探针不受影响
+	registry.SetShuttingDown()
+	report = registry.Readiness(context.Background())
+	assert.False(t, report.OK())
+	assert.Equal(t, "shutdown", report.Checks[2].Name)
+	assert.Equal(t, ErrShuttingDown.Error(), report.Checks[2].Error)
+	assert.True(t, registry.Liveness(context.Background()).OK())
+}
+
+func TestRegistryFailures(t *testing.T) {
+	registry := NewRegistry(Config{Timeout: 20 * time.Millisecond})
+	registry.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	registry.Register("panics", func(ctx context.Context) error {
+		panic("boom")
+	})
+	registry.Register("flaky", func(ctx context.Context) error {
+		return errors.New("first")
+	})
+	// 同名检查替换原有检查
+	registry.Register("flaky", func(ctx context.Context) error {
+		return nil
+	}, WithTimeout(time.Second))
+
+	// 已取消的请求不影响检查
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	report := registry.Readiness(ctx)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.False(t, report.OK())
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, StatusFail, report.Checks[0].Status)
+	assert.Equal(t, "timed out after 20ms", report.Checks[0].Error)
+	assert.GreaterOrEqual(t, report.Checks[0].LatencyMs, float64(20))
+	assert.Equal(t, "check panicked", report.Checks[1].Error)
+	assert.Equal(t, StatusOK, report.Checks[2].Status)
+}
+
+func TestChecks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// 未执行的迁移导致检查失败
+	err = Migrations(db, "sqlite")(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pending migrations: 000001_")
+	migrator, err := database.NewMigrator(db, "sqlite")
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx, 0)
+	require.NoError(t, err)
+	assert.NoError(t, Migrations(db, "sqlite")(ctx))
+
+	assert.NoError(t, DiskSpace(t.TempDir(), 0)(ctx))
+	assert.ErrorContains(t, DiskSpace(t.TempDir(), math.MaxUint64)(ctx), "free in")
+
+	assert.NoError(t, DBPing(db)(ctx))
+	require.NoError(t, sqlDB.Close())
+	assert.Error(t, DBPing(db)(ctx))
+}
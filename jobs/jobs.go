@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultQueue 未指定队列时使用的队列
+	DefaultQueue = "default"
+	// DefaultMaxAttempts 未指定时任务的最大尝试次数
+	DefaultMaxAttempts = 5
+)
+
+// Handler 任务处理函数，返回错误时任务按退避策略重试，返回 Permanent 包装的错误时直接进入死信状态
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Handle 将参数类型为 T 的处理函数包装为 Handler，任务参数按 JSON 解码为 T，解码失败的任务不再重试
+func Handle[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, job *models.Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// permanentError 不需要重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记错误不可重试，例如参数错误或引用的数据已被删除
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否被标记为不可重试
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Options 任务入队选项
+type Options struct {
+	// Queue 任务所在队列，为空时使用 DefaultQueue
+	Queue string
+	// RunAt 计划执行时间，零值表示立即执行
+	RunAt time.Time
+	// Delay 相对当前时间延迟执行，设置了 RunAt 时忽略
+	Delay time.Duration
+	// MaxAttempts 最大尝试次数，为 0 时使用 DefaultMaxAttempts
+	MaxAttempts int
+}
+
+// Enqueue 将任务加入队列，payload 按 JSON 编码。db 可以是事务，任务与业务数据一起提交或回滚
+func Enqueue(ctx context.Context, db *gorm.DB, jobType string, payload interface{}, opts Options) (*models.Job, error) {
+	if jobType == "" {
+		return nil, errors.New("job type is required")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", jobType, err)
+	}
+
+	job := &models.Job{
+		Queue:       opts.Queue,
+		Type:        jobType,
+		Payload:     data,
+		RunAt:       opts.RunAt,
+		MaxAttempts: opts.MaxAttempts,
+	}
+	if job.Queue == "" {
+		job.Queue = DefaultQueue
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now().Add(opts.Delay)
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	if err := repository.NewJobRepository(db.WithContext(ctx)).Create(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Backoff 第 attempt 次失败后的重试间隔：base * 2^(attempt-1)，不超过 max
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+type jobContextKey struct{}
+
+// FromContext 返回处理函数正在执行的任务，可用于读取尝试次数等信息
+func FromContext(ctx context.Context) (*models.Job, bool) {
+	job, ok := ctx.Value(jobContextKey{}).(*models.Job)
+	return job, ok
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"gorm.io/gorm"
+)
+
+// Config worker 配置，零值字段使用默认值
+type Config struct {
+	// ID worker 标识，记录在领取的任务上，默认为 "主机名-进程号"
+	ID string
+	// Queues 要处理的队列及每个队列同时执行的任务数，默认只处理 DefaultQueue，并发 1
+	Queues map[string]int
+	// PollInterval 队列为空时的轮询间隔，默认 1s
+	PollInterval time.Duration
+	// LockTimeout 单个任务的最长执行时间，超过后处理函数的 ctx 被取消，
+	// 仍处于 running 的任务被视为 worker 已丢失并重新排队。默认 5m
+	LockTimeout time.Duration
+	// BackoffBase、BackoffMax 失败重试的初始间隔和最大间隔，默认 10s 和 1h
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention 已完成任务的保留时间，为 0 时不清理
+	Retention time.Duration
+}
+
+// Worker 从数据库队列中领取并执行任务。每个队列由独立的协程轮询，并发数按队列限制
+type Worker struct {
+	repo *repository.JobRepository
+	cfg  Config
+	now  func() time.Time
+
+	handlersMu sync.RWMutex
+	handlers   map[string]Handler
+
+	mu        sync.Mutex
+	started   bool
+	cancel    context.CancelFunc
+	jobCancel context.CancelFunc
+	jobCtx    context.Context
+	pollers   sync.WaitGroup
+	running   sync.WaitGroup
+}
+
+// NewWorker 创建 worker，处理函数需要在 Start 之前通过 Register 注册
+func NewWorker(db *gorm.DB, cfg Config) *Worker {
+	if cfg.ID == "" {
+		host, _ := os.Hostname()
+		cfg.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if len(cfg.Queues) == 0 {
+		cfg.Queues = map[string]int{DefaultQueue: 1}
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = 5 * time.Minute
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = 10 * time.Second
+	}
+	if cfg.BackoffMax <= 0 {
+		cfg.BackoffMax = time.Hour
+	}
+	return &Worker{
+		repo:     repository.NewJobRepository(db),
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+		now:      time.Now,
+	}
+}
+
+// Register 注册任务类型的处理函数，同一类型重复注册时后者生效
+func (w *Worker) Register(jobType string, handler Handler) {
+	w.handlersMu.Lock()
+	defer w.handlersMu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// Start 启动各队列的轮询协程和过期任务的回收协程
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.started {
+		return errors.New("worker already started")
+	}
+	w.started = true
+
+	pollCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.jobCtx, w.jobCancel = context.WithCancel(context.Background())
+
+	w.pollers.Add(1)
+	go w.maintain(pollCtx)
+	for queue, concurrency := range w.cfg.Queues {
+		if concurrency <= 0 {
+			continue
+		}
+		w.pollers.Add(1)
+		go w.poll(pollCtx, queue, concurrency)
+	}
+	return nil
+}
+
+// Stop 停止领取新任务并等待执行中的任务完成。ctx 到期时取消执行中任务的 ctx，
+// 这些任务立即重新排队，Stop 返回 ctx 的错误
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started {
+		return nil
+	}
+	w.started = false
+	w.cancel()
+	w.pollers.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		w.jobCancel()
+		return nil
+	case <-ctx.Done():
+		w.jobCancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// poll 轮询一个队列，有空闲并发时领取任务，任务完成后立即再次领取
+func (w *Worker) poll(ctx context.Context, queue string, concurrency int) {
+	defer w.pollers.Done()
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, concurrency)
+	wake := make(chan struct{}, 1)
+	for {
+		if free := concurrency - len(slots); free > 0 {
+			jobs, err := w.repo.Claim(queue, w.cfg.ID, free, w.now())
+			if err != nil {
+				log.Printf("Failed to claim jobs from queue %s: %v", queue, err)
+			}
+			for i := range jobs {
+				slots <- struct{}{}
+				w.running.Add(1)
+				go func(job models.Job) {
+					defer w.running.Done()
+					w.run(&job)
+					<-slots
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				}(jobs[i])
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// maintain 定期回收超时的任务并清理过期的已完成任务
+func (w *Worker) maintain(ctx context.Context) {
+	defer w.pollers.Done()
+	interval := w.cfg.LockTimeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := w.now()
+		if n, err := w.repo.RequeueStale(now.Add(-w.cfg.LockTimeout), now); err != nil {
+			log.Printf("Failed to requeue stale jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("Requeued %d stale jobs", n)
+		}
+		if w.cfg.Retention > 0 {
+			if _, err := w.repo.DeleteFinished(now.Add(-w.cfg.Retention)); err != nil {
+				log.Printf("Failed to delete finished jobs: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run 执行任务并记录结果
+func (w *Worker) run(job *models.Job) {
+	w.handlersMu.RLock()
+	handler := w.handlers[job.Type]
+	w.handlersMu.RUnlock()
+
+	var err error
+	if handler == nil {
+		err = Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	} else {
+		ctx, cancel := context.WithTimeout(context.WithValue(w.jobCtx, jobContextKey{}, job), w.cfg.LockTimeout)
+		err = call(ctx, handler, job)
+		cancel()
+	}
+
+	now := w.now()
+	switch {
+	case err == nil:
+		err = w.repo.Complete(job, now)
+	case w.jobCtx.Err() != nil:
+		// 停止期限已到被中断的任务立即重新排队，由其他 worker 或下次启动后继续执行
+		err = w.repo.Reschedule(job, "interrupted by worker shutdown: "+err.Error(), now)
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %d (%s) failed permanently after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		err = w.repo.Bury(job, err.Error(), now)
+	default:
+		delay := w.backoff(job.Attempts)
+		log.Printf("Job %d (%s) failed, retrying in %s: %v", job.ID, job.Type, delay, err)
+		err = w.repo.Reschedule(job, err.Error(), now.Add(delay))
+	}
+	if err != nil {
+		log.Printf("Failed to record result of job %d: %v", job.ID, err)
+	}
+}
+
+// backoff 在指数退避间隔上增加最多 10% 的随机抖动，避免大量任务同时重试
+func (w *Worker) backoff(attempt int) time.Duration {
+	d := Backoff(attempt, w.cfg.BackoffBase, w.cfg.BackoffMax)
+	return d + time.Duration(rand.Int63n(int64(d)/10+1))
+}
+
+// call 执行处理函数，将 panic 转换为错误
+func call(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %d (%s) panicked: %v\n%s", job.ID, job.Type, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
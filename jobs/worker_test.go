@@ -0,0 +1,195 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.Job{}))
+	return db
+}
+
+func newTestWorker(db *gorm.DB, queues map[string]int) *Worker {
+	return NewWorker(db, Config{
+		ID:           "test",
+		Queues:       queues,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  time.Minute,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+	})
+}
+
+// waitForStatus 等待任务进入指定状态并返回任务
+func waitForStatus(t *testing.T, db *gorm.DB, id uint, status string) *models.Job {
+	var job models.Job
+	require.Eventually(t, func() bool {
+		return db.First(&job, id).Error == nil && job.Status == status
+	}, 5*time.Second, 10*time.Millisecond, "job %d did not reach %s (status %s)", id, status, job.Status)
+	return &job
+}
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+func TestWorker_HandlersRetriesAndDeadLetter(t *testing.T) {
+	db := setupTestDB(t)
+	worker := newTestWorker(db, map[string]int{DefaultQueue: 2})
+	ctx := context.Background()
+
+	var greeted atomic.Value
+	worker.Register("greet", Handle(func(ctx context.Context, payload greeting) error {
+		job, _ := FromContext(ctx)
+		greeted.Store(fmt.Sprintf("%s attempt %d", payload.Name, job.Attempts))
+		return nil
+	}))
+	var flakyCalls int32
+	worker.Register("flaky", func(ctx context.Context, job *models.Job) error {
+		if atomic.AddInt32(&flakyCalls, 1) == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	worker.Register("broken", func(ctx context.Context, job *models.Job) error {
+		return errors.New("always fails")
+	})
+	worker.Register("invalid", func(ctx context.Context, job *models.Job) error {
+		return Permanent(errors.New("bad input"))
+	})
+	worker.Register("panics", func(ctx context.Context, job *models.Job) error {
+		panic("boom")
+	})
+
+	greet, err := Enqueue(ctx, db, "greet", greeting{Name: "Ada"}, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultQueue, greet.Queue)
+	assert.Equal(t, DefaultMaxAttempts, greet.MaxAttempts)
+	flaky, _ := Enqueue(ctx, db, "flaky", nil, Options{})
+	broken, _ := Enqueue(ctx, db, "broken", nil, Options{MaxAttempts: 3})
+	invalid, _ := Enqueue(ctx, db, "invalid", nil, Options{})
+	typed, _ := Enqueue(ctx, db, "greet", "not an object", Options{})
+	panics, _ := Enqueue(ctx, db, "panics", nil, Options{MaxAttempts: 1})
+	unknown, _ := Enqueue(ctx, db, "unknown", nil, Options{})
+
+	require.NoError(t, worker.Start(ctx))
+	defer worker.Stop(ctx)
+
+	job := waitForStatus(t, db, greet.ID, models.JobStatusSucceeded)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, "Ada attempt 1", greeted.Load())
+
+	job = waitForStatus(t, db, flaky.ID, models.JobStatusSucceeded)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "temporary failure", job.LastError)
+
+	job = waitForStatus(t, db, broken.ID, models.JobStatusDead)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, "always fails", job.LastError)
+
+	// 不可重试的错误、参数解码失败和未注册的类型直接进入死信状态
+	job = waitForStatus(t, db, invalid.ID, models.JobStatusDead)
+	assert.Equal(t, 1, job.Attempts)
+	job = waitForStatus(t, db, typed.ID, models.JobStatusDead)
+	assert.Contains(t, job.LastError, "invalid payload")
+	job = waitForStatus(t, db, panics.ID, models.JobStatusDead)
+	assert.Equal(t, "panic: boom", job.LastError)
+	job = waitForStatus(t, db, unknown.ID, models.JobStatusDead)
+	assert.Contains(t, job.LastError, "no handler registered")
+}
+
+func TestWorker_ConcurrencyAndScheduling(t *testing.T) {
+	db := setupTestDB(t)
+	worker := newTestWorker(db, map[string]int{"slow": 2})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	worker.Register("sleep", func(ctx context.Context, job *models.Job) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		job, err := Enqueue(ctx, db, "sleep", nil, Options{Queue: "slow"})
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+	delayed, _ := Enqueue(ctx, db, "sleep", nil, Options{Queue: "slow", Delay: time.Hour})
+	scheduled, _ := Enqueue(ctx, db, "sleep", nil, Options{Queue: "slow", RunAt: time.Now().Add(50 * time.Millisecond)})
+	// 没有 worker 处理的队列中的任务保持 pending
+	ignored, _ := Enqueue(ctx, db, "sleep", nil, Options{Queue: "other"})
+
+	require.NoError(t, worker.Start(ctx))
+	for _, id := range append(ids, scheduled.ID) {
+		waitForStatus(t, db, id, models.JobStatusSucceeded)
+	}
+	require.NoError(t, worker.Stop(ctx))
+
+	mu.Lock()
+	assert.Equal(t, 2, maxRunning)
+	mu.Unlock()
+	waitForStatus(t, db, delayed.ID, models.JobStatusPending)
+	waitForStatus(t, db, ignored.ID, models.JobStatusPending)
+}
+
+func TestWorker_StopRequeuesInterruptedJobs(t *testing.T) {
+	db := setupTestDB(t)
+	worker := newTestWorker(db, nil)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	worker.Register("block", func(ctx context.Context, job *models.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job, _ := Enqueue(ctx, db, "block", nil, Options{MaxAttempts: 1})
+
+	require.NoError(t, worker.Start(ctx))
+	<-started
+
+	stopCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, worker.Stop(stopCtx), context.DeadlineExceeded)
+
+	// 即使尝试次数已用完，被停止中断的任务也重新排队而不是进入死信状态
+	reloaded := waitForStatus(t, db, job.ID, models.JobStatusPending)
+	assert.Contains(t, reloaded.LastError, "interrupted by worker shutdown")
+	assert.Empty(t, reloaded.LockedBy)
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+	assert.Equal(t, 10*time.Second, Backoff(1, base, max))
+	assert.Equal(t, 20*time.Second, Backoff(2, base, max))
+	assert.Equal(t, 40*time.Second, Backoff(3, base, max))
+	assert.Equal(t, time.Minute, Backoff(4, base, max))
+	assert.Equal(t, time.Minute, Backoff(100, base, max))
+}
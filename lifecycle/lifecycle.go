@@ -0,0 +1,89 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Hook 组件的启动和停止回调，两者都可以为空
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle 按注册顺序启动组件，按相反顺序停止组件。
+// 先注册的组件（如数据库）会在后注册的组件（如 HTTP 服务、后台任务）停止之后才关闭
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+	stopped bool
+}
+
+// New 创建生命周期管理器
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Append 注册组件，必须在 Start 之前调用
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Start 依次执行启动回调。某个组件启动失败时，会停止已经启动的组件并返回错误
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.started < len(l.hooks) {
+		hook := l.hooks[l.started]
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				startErr := fmt.Errorf("failed to start %s: %w", hook.Name, err)
+				if stopErr := l.stop(ctx); stopErr != nil {
+					return errors.Join(startErr, stopErr)
+				}
+				return startErr
+			}
+		}
+		log.Printf("Started %s", hook.Name)
+		l.started++
+	}
+	return nil
+}
+
+// Stop 按相反顺序执行已启动组件的停止回调，单个组件失败不影响其他组件停止。
+// ctx 的截止时间是所有组件共享的停止期限
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stop(ctx)
+}
+
+func (l *Lifecycle) stop(ctx context.Context) error {
+	if l.stopped {
+		return nil
+	}
+	l.stopped = true
+
+	var errs []error
+	for i := l.started - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := hook.OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+			continue
+		}
+		log.Printf("Stopped %s", hook.Name)
+	}
+	l.started = 0
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordingHook(name string, events *[]string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			*events = append(*events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycle_StopsInReverseOrder(t *testing.T) {
+	var events []string
+	app := New()
+	app.Append(recordingHook("db", &events, nil))
+	app.Append(recordingHook("worker", &events, nil))
+	app.Append(recordingHook("http", &events, nil))
+
+	assert.NoError(t, app.Start(context.Background()))
+	assert.NoError(t, app.Stop(context.Background()))
+	assert.Equal(t, []string{
+		"start db", "start worker", "start http",
+		"stop http", "stop worker", "stop db",
+	}, events)
+
+	// 重复停止不会再次执行回调
+	assert.NoError(t, app.Stop(context.Background()))
+	assert.Len(t, events, 6)
+}
+
+func TestLifecycle_RollsBackOnStartFailure(t *testing.T) {
+	var events []string
+	app := New()
+	app.Append(recordingHook("db", &events, nil))
+	app.Append(recordingHook("worker", &events, errors.New("boom")))
+	app.Append(recordingHook("http", &events, nil))
+
+	err := app.Start(context.Background())
+	assert.ErrorContains(t, err, "failed to start worker")
+	assert.Equal(t, []string{"start db", "start worker", "stop db"}, events)
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// ParseGormLevel 解析 GORM 日志级别：silent、error、warn、info，空字符串为 warn
+func ParseGormLevel(level string) (gormlogger.LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "silent":
+		return gormlogger.Silent, nil
+	case "error":
+		return gormlogger.Error, nil
+	case "", "warn", "warning":
+		return gormlogger.Warn, nil
+	case "info":
+		return gormlogger.Info, nil
+	}
+	return 0, fmt.Errorf("unknown database log level %q", level)
+}
+
+// GormLogger 将 GORM 日志写入 slog。info 级别记录所有 SQL，warn 级别只记录慢查询和错误，
+// 记录不存在的错误不记录。使用 db.WithContext(ctx) 执行的查询会带上 ctx 中的请求ID
+type GormLogger struct {
+	logger        *slog.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger 创建 GORM logger，slowThreshold 为 0 时不记录慢查询
+func NewGormLogger(logger *slog.Logger, level gormlogger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{logger: logger, level: level, slowThreshold: slowThreshold}
+}
+
+// LogMode 实现 gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info 实现 gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...), "source", utils.FileWithLineNum())
+	}
+}
+
+// Warn 实现 gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...), "source", utils.FileWithLineNum())
+	}
+}
+
+// Error 实现 gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...), "source", utils.FileWithLineNum())
+	}
+}
+
+// Trace 实现 gormlogger.Interface，记录 SQL、耗时和影响行数
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	var level slog.Level
+	var msg string
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "sql error"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "slow sql"
+	case l.level >= gormlogger.Info:
+		level, msg = slog.LevelInfo, "sql"
+	default:
+		return
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Float64("duration_ms", float64(elapsed.Nanoseconds())/1e6),
+		slog.String("source", utils.FileWithLineNum()),
+	}
+	if rows >= 0 {
+		attrs = append(attrs, slog.Int64("rows", rows))
+	}
+	if err != nil && level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(Config{Level: "warn", Output: &buf})
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "dropped")
+	logger.WarnContext(ctx, "kept", "key", "value")
+	logger.With("component", "test").ErrorContext(ctx, "grouped")
+	logger.Warn("no context")
+
+	records := decodeLines(t, &buf)
+	require.Len(t, records, 3)
+	assert.Equal(t, "kept", records[0]["msg"])
+	assert.Equal(t, "req-1", records[0]["request_id"])
+	assert.Equal(t, "value", records[0]["key"])
+	assert.Equal(t, "req-1", records[1]["request_id"])
+	assert.Equal(t, "test", records[1]["component"])
+	assert.NotContains(t, records[2], "request_id")
+
+	buf.Reset()
+	logger, err = New(Config{Format: "text", Output: &buf})
+	require.NoError(t, err)
+	logger.InfoContext(ctx, "hello")
+	assert.Contains(t, buf.String(), "msg=hello request_id=req-1")
+
+	_, err = New(Config{Level: "verbose"})
+	assert.Error(t, err)
+	_, err = New(Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestGormLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(Config{Level: "debug", Output: &buf})
+	require.NoError(t, err)
+	ctx := WithRequestID(context.Background(), "req-2")
+	query := func() (string, int64) { return "SELECT 1", 1 }
+
+	// warn 级别只记录慢查询和错误，记录不存在不算错误
+	gormLog := NewGormLogger(logger, gormlogger.Warn, 100*time.Millisecond)
+	gormLog.Trace(ctx, time.Now(), query, nil)
+	gormLog.Trace(ctx, time.Now(), query, gorm.ErrRecordNotFound)
+	gormLog.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+	gormLog.Trace(ctx, time.Now(), query, errors.New("no such table"))
+
+	records := decodeLines(t, &buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, "slow sql", records[0]["msg"])
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "SELECT 1", records[0]["sql"])
+	assert.Equal(t, "req-2", records[0]["request_id"])
+	assert.GreaterOrEqual(t, records[0]["duration_ms"], float64(1000))
+	assert.Equal(t, "sql error", records[1]["msg"])
+	assert.Equal(t, "no such table", records[1]["error"])
+
+	// info 级别记录所有 SQL，silent 不记录
+	buf.Reset()
+	gormLog.LogMode(gormlogger.Info).Trace(ctx, time.Now(), query, nil)
+	gormLog.LogMode(gormlogger.Silent).Trace(ctx, time.Now(), query, errors.New("ignored"))
+	records = decodeLines(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "sql", records[0]["msg"])
+	assert.Equal(t, float64(1), records[0]["rows"])
+
+	level, err := ParseGormLevel("")
+	require.NoError(t, err)
+	assert.Equal(t, gormlogger.Warn, level)
+	_, err = ParseGormLevel("trace")
+	assert.Error(t, err)
+}
+
+func TestSetup(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	var buf bytes.Buffer
+	_, err := Setup(Config{Output: &buf})
+	require.NoError(t, err)
+	slog.InfoContext(WithRequestID(context.Background(), "req-3"), "default")
+	assert.Contains(t, buf.String(), `"request_id":"req-3"`)
+	assert.Empty(t, RequestID(context.Background()))
+}
@@ -1,13 +1,35 @@
 package main
-package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/fangyanlin/gin-gorm-app/config"
 	"github.com/fangyanlin/gin-gorm-app/database"
+	"github.com/fangyanlin/gin-gorm-app/exchange"
+	"github.com/fangyanlin/gin-gorm-app/health"
+	"github.com/fangyanlin/gin-gorm-app/jobs"
+	"github.com/fangyanlin/gin-gorm-app/lifecycle"
+	"github.com/fangyanlin/gin-gorm-app/logging"
+	"github.com/fangyanlin/gin-gorm-app/metrics"
 	"github.com/fangyanlin/gin-gorm-app/middleware"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/outbox"
+	"github.com/fangyanlin/gin-gorm-app/ratelimit"
 	"github.com/fangyanlin/gin-gorm-app/routes"
+	"github.com/fangyanlin/gin-gorm-app/search"
+	"github.com/fangyanlin/gin-gorm-app/storage"
+	"github.com/fangyanlin/gin-gorm-app/tracing"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/fangyanlin/gin-gorm-app/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,32 +39,293 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
-	
+
+	// 结构化日志，标准库 log 的输出也写入同一个 logger
+	if _, err := logging.Setup(logging.Config{Level: cfg.Log.Level, Format: cfg.Log.Format}); err != nil {
+		log.Fatalf("Invalid log configuration: %v", err)
+	}
+
+	// 基础货币和汇率来源
+	if err := models.SetDefaultCurrency(cfg.Currency.Default); err != nil {
+		log.Fatalf("Invalid CURRENCY_DEFAULT: %v", err)
+	}
+	if cfg.Currency.ExchangeRatesFile != "" {
+		rates, err := exchange.NewFileProvider(cfg.Currency.ExchangeRatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+		exchange.SetDefault(rates)
+	} else {
+		exchange.SetDefault(exchange.NewStatic(models.DefaultCurrency, nil))
+	}
+
+	// 数据库迁移子命令：app migrate up|down|status|create
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
+	// 组件按注册顺序启动、按相反顺序停止
+	app := lifecycle.New()
+
+	// 链路追踪，数据库插件使用默认 Tracer，需要在初始化数据库之前设置。
+	// 最先注册，其余组件都停止后再导出剩余的 span
+	var tracer *tracing.Tracer
+	if cfg.Tracing.Enabled {
+		tracer = tracing.NewTracer(tracing.Config{
+			ServiceName: cfg.Tracing.ServiceName,
+			Exporter: tracing.NewOTLPExporter(tracing.OTLPConfig{
+				Endpoint:    cfg.Tracing.Endpoint,
+				Headers:     tracing.ParseHeaders(cfg.Tracing.Headers),
+				ServiceName: cfg.Tracing.ServiceName,
+			}),
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		tracing.SetDefault(tracer)
+		app.Append(lifecycle.Hook{
+			Name:   "tracer",
+			OnStop: tracer.Shutdown,
+		})
+	}
+
 	// 初始化数据库
 	if err := database.InitDB(cfg); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.CloseDB()
-	
+	app.Append(lifecycle.Hook{
+		Name: "database",
+		OnStop: func(ctx context.Context) error {
+			return database.CloseDB()
+		},
+	})
+
+	// 指标：HTTP 请求、数据库连接池、GORM 查询和 Go 运行时
+	var registry *metrics.Registry
+	if cfg.Metrics.Enabled {
+		registry = metrics.NewRegistry()
+		if err := database.GetDB().Use(metrics.NewGormPlugin(registry)); err != nil {
+			log.Fatalf("Failed to install metrics plugin: %v", err)
+		}
+		registry.MustRegister(
+			metrics.NewDBStatsCollector(database.SQLDB),
+			metrics.NewRuntimeCollector(),
+		)
+	}
+
+	// 初始化内置角色和权限
+	if err := database.SeedRBAC(cfg.RBAC.BootstrapAdmin); err != nil {
+		log.Fatalf("Failed to seed roles: %v", err)
+	}
+
+	// 检查迁移创建的全文搜索索引，缺失或失败时搜索仍可用（退回 LIKE 查询）
+	searchEngine := search.New(database.GetDB())
+	if err := searchEngine.Setup(context.Background()); err != nil {
+		log.Printf("Warning: failed to set up %s search index: %v", searchEngine.Name(), err)
+	}
+
+	// 初始化 JWT 服务
+	tokenService, err := utils.NewTokenService(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to initialize token service: %v", err)
+	}
+
+	// 初始化限流存储
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "redis":
+		redisStore := ratelimit.NewRedisStore(ratelimit.RedisOptions{
+			Addr:     cfg.RateLimit.RedisAddr,
+			Password: cfg.RateLimit.RedisPassword,
+			DB:       cfg.RateLimit.RedisDB,
+		})
+		app.Append(lifecycle.Hook{
+			Name: "rate limit store",
+			OnStop: func(ctx context.Context) error {
+				return redisStore.Close()
+			},
+		})
+		rateLimitStore = redisStore
+	default:
+		memoryStore := ratelimit.NewMemoryStore(time.Minute)
+		app.Append(lifecycle.Hook{
+			Name: "rate limit store",
+			OnStop: func(ctx context.Context) error {
+				return memoryStore.Close()
+			},
+		})
+		rateLimitStore = memoryStore
+	}
+
+	// 初始化文件存储
+	store, err := storage.New(storage.Config{
+		Driver:    cfg.Storage.Driver,
+		LocalDir:  cfg.Storage.LocalDir,
+		PublicURL: cfg.Storage.PublicURL,
+		S3: storage.S3Options{
+			Endpoint:  cfg.Storage.S3Endpoint,
+			Region:    cfg.Storage.S3Region,
+			Bucket:    cfg.Storage.S3Bucket,
+			AccessKey: cfg.Storage.S3AccessKey,
+			SecretKey: cfg.Storage.S3SecretKey,
+			PathStyle: cfg.Storage.S3PathStyle,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	// 后台任务 worker 在 HTTP 服务之后停止，停止时等待执行中的任务完成
+	worker := jobs.NewWorker(database.GetDB(), jobs.Config{
+		Queues:       cfg.Jobs.Queues,
+		PollInterval: cfg.Jobs.PollInterval,
+		LockTimeout:  cfg.Jobs.LockTimeout,
+		BackoffBase:  cfg.Jobs.BackoffBase,
+		BackoffMax:   cfg.Jobs.BackoffMax,
+		Retention:    cfg.Jobs.Retention,
+	})
+	// 在这里注册任务处理函数，如 worker.Register("email.send", jobs.Handle(sendEmail))
+	hooks := webhooks.NewService(database.GetDB(), webhooks.Config{
+		Queue:        cfg.Webhooks.Queue,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		Timeout:      cfg.Webhooks.Timeout,
+		DisableAfter: cfg.Webhooks.DisableAfter,
+	})
+	worker.Register(webhooks.JobType, jobs.Handle(hooks.Deliver))
+	if cfg.Jobs.Enabled {
+		app.Append(lifecycle.Hook{
+			Name:    "job worker",
+			OnStart: worker.Start,
+			OnStop:  worker.Stop,
+		})
+	}
+
+	// 领域事件 dispatcher，将仓库写入 outbox 表的事件投递给进程内的订阅者
+	dispatcher := outbox.NewDispatcher(database.GetDB(), outbox.Config{
+		PollInterval: cfg.Outbox.PollInterval,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+		Retention:    cfg.Outbox.Retention,
+	})
+	// 在这里订阅领域事件，如 dispatcher.Subscribe("search", reindexProduct, "product.*")
+	dispatcher.Subscribe("webhooks", hooks.HandleEvent)
+	if registry != nil {
+		dispatcher.Subscribe("metrics", metrics.NewDomainMetrics(registry).HandleEvent)
+	}
+	if cfg.Outbox.Enabled {
+		app.Append(lifecycle.Hook{
+			Name:    "outbox dispatcher",
+			OnStart: dispatcher.Start,
+			OnStop:  dispatcher.Stop,
+		})
+	}
+
+	// 就绪检查：数据库连接、迁移状态和 SQLite 所在磁盘的可用空间
+	checks := health.NewRegistry(health.Config{
+		Timeout:  cfg.Health.CheckTimeout,
+		CacheTTL: cfg.Health.CacheTTL,
+	})
+	checks.Register("database", health.DBPing(database.GetDB()))
+	if !cfg.Database.AutoMigrate {
+		checks.Register("migrations", health.Migrations(database.GetDB(), cfg.Database.Driver))
+	}
+	if cfg.Database.Driver == "sqlite" && cfg.Database.SQLitePath != ":memory:" {
+		checks.Register("disk", health.DiskSpace(filepath.Dir(cfg.Database.SQLitePath), uint64(cfg.Health.DiskMinFreeMB)<<20))
+	}
+
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
-	
+
 	// 创建路由
 	router := gin.New()
-	
-	// 使用中间件
+
+	// 使用中间件，RequestID 和链路追踪需要在日志和错误恢复之前，指标需要在错误恢复之前以记录 panic 的请求
+	router.Use(middleware.RequestID())
+	if tracer != nil {
+		router.Use(middleware.Tracing(tracer))
+	}
+	if registry != nil {
+		router.Use(middleware.Metrics(metrics.NewHTTPMetrics(registry)))
+	}
 	router.Use(middleware.Logger())
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS())
-	
+
 	// 设置路由
-	routes.SetupRoutes(router, database.GetDB())
-	
-	// 启动服务器
-	addr := ":" + cfg.Server.Port
-	log.Printf("Server starting on %s", addr)
-	
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	routes.SetupRoutes(router, routes.Dependencies{
+		DB:             database.GetDB(),
+		Config:         cfg,
+		Tokens:         tokenService,
+		RateLimitStore: rateLimitStore,
+		Storage:        store,
+		Webhooks:       hooks,
+		Metrics:        registry,
+		Health:         checks,
+	})
+
+	// HTTP 服务最后注册，停止时最先停止接收新请求并等待进行中的请求完成
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	app.Append(lifecycle.Hook{
+		Name: "http server",
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			log.Printf("Server starting on %s", srv.Addr)
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					serverErr <- err
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	})
+
+	// 就绪探针最后注册、最先停止：先让 /readyz 失败，等待负载均衡摘除实例后再停止 HTTP 服务
+	app.Append(lifecycle.Hook{
+		Name: "readiness",
+		OnStop: func(ctx context.Context) error {
+			checks.SetShuttingDown()
+			select {
+			case <-time.After(cfg.Health.ShutdownDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	// 监听退出信号
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.Start(ctx); err != nil {
+		log.Fatalf("Failed to start application: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Println("Shutdown signal received")
+	case err := <-serverErr:
+		log.Printf("Server error: %v", err)
+	}
+	stop()
+
+	// 在期限内完成进行中的请求，然后关闭其余组件
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := app.Stop(shutdownCtx); err != nil {
+		log.Printf("Shutdown completed with errors: %v", err)
+		os.Exit(1)
 	}
+	log.Println("Server exited")
 }
@@ -0,0 +1,217 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册 GIF 解码器
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedType 文件内容不是支持的图片格式
+	ErrUnsupportedType = errors.New("unsupported image type")
+	// ErrTypeMismatch 声明的 Content-Type 与文件内容不一致
+	ErrTypeMismatch = errors.New("declared content type does not match file content")
+	// ErrInvalidImage 图片无法解码或尺寸超出限制
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// allowedTypes 支持的图片格式及扩展名，必须是标准库可以解码的格式
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// DefaultMaxPixels 默认允许的最大像素数，防止解压炸弹耗尽内存
+const DefaultMaxPixels = 40_000_000
+
+// Image 通过校验的上传图片
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	decoded     image.Image
+}
+
+// Inspect 按文件内容识别类型（不信任扩展名和客户端声明的类型），
+// declared 为客户端声明的 Content-Type，为空或 application/octet-stream 时不比较
+func Inspect(data []byte, declared string, maxPixels int) (*Image, error) {
+	sniffed := http.DetectContentType(data)
+	ext, ok := allowedTypes[sniffed]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, sniffed)
+	}
+	declared = strings.ToLower(strings.TrimSpace(strings.Split(declared, ";")[0]))
+	if declared == "image/jpg" || declared == "image/pjpeg" {
+		declared = "image/jpeg"
+	}
+	if declared != "" && declared != "application/octet-stream" && declared != sniffed {
+		return nil, fmt.Errorf("%w: declared %s, detected %s", ErrTypeMismatch, declared, sniffed)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrInvalidImage, config.Width, config.Height, maxPixels)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	return &Image{
+		Data:        data,
+		ContentType: sniffed,
+		Ext:         ext,
+		Width:       config.Width,
+		Height:      config.Height,
+		decoded:     decoded,
+	}, nil
+}
+
+// Size 缩略图规格，图片按比例缩放到宽高都不超过 Max，不放大
+type Size struct {
+	Name string
+	Max  int
+}
+
+// ParseSizes 解析 "small:150,medium:400,large:800" 形式的缩略图规格，按尺寸从小到大排序
+func ParseSizes(spec string) ([]Size, error) {
+	var sizes []Size
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, ":")
+		max, err := strconv.Atoi(strings.TrimSpace(raw))
+		name = strings.TrimSpace(name)
+		if !ok || err != nil || max <= 0 || name == "" || seen[name] {
+			return nil, fmt.Errorf("invalid thumbnail size %q, expected name:pixels", part)
+		}
+		seen[name] = true
+		sizes = append(sizes, Size{Name: name, Max: max})
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Max < sizes[j].Max })
+	return sizes, nil
+}
+
+// Thumbnail 缩略图
+type Thumbnail struct {
+	Size        Size
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Thumbnails 按规格生成缩略图。JPEG 输出 JPEG，PNG 和 GIF 输出 PNG 以保留透明度；
+// 原图小于规格时按原尺寸重新编码
+func (img *Image) Thumbnails(sizes []Size) ([]Thumbnail, error) {
+	thumbnails := make([]Thumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		width, height := fit(img.Width, img.Height, size.Max)
+		scaled := resize(img.decoded, width, height)
+
+		var buf bytes.Buffer
+		thumb := Thumbnail{Size: size, Width: width, Height: height}
+		if img.ContentType == "image/jpeg" {
+			thumb.ContentType, thumb.Ext = "image/jpeg", ".jpg"
+			if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85}); err != nil {
+				return nil, err
+			}
+		} else {
+			thumb.ContentType, thumb.Ext = "image/png", ".png"
+			if err := png.Encode(&buf, scaled); err != nil {
+				return nil, err
+			}
+		}
+		thumb.Data = buf.Bytes()
+		thumbnails = append(thumbnails, thumb)
+	}
+	return thumbnails, nil
+}
+
+// fit 按比例计算宽高都不超过 max 的尺寸
+func fit(width, height, max int) (int, int) {
+	if width <= max && height <= max {
+		return width, height
+	}
+	if width >= height {
+		h := height * max / width
+		if h < 1 {
+			h = 1
+		}
+		return max, h
+	}
+	w := width * max / height
+	if w < 1 {
+		w = 1
+	}
+	return w, max
+}
+
+// resize 使用区域平均（box filter）缩放，缩小时不会产生明显的锯齿
+func resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok || bounds.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	}
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if sw == width && sh == height {
+		copy(dst.Pix, rgba.Pix)
+		return dst
+	}
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,84 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	data := encodePNG(t, 300, 200)
+	img, err := Inspect(data, "image/png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Equal(t, ".png", img.Ext)
+	assert.Equal(t, 300, img.Width)
+
+	// 扩展名和声明类型不可信，按内容识别
+	_, err = Inspect(data, "image/jpeg", 0)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	_, err = Inspect(data, "application/octet-stream", 0)
+	assert.NoError(t, err)
+	_, err = Inspect([]byte("<html><script>alert(1)</script></html>"), "image/png", 0)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = Inspect(data, "", 300*200-1)
+	assert.ErrorIs(t, err, ErrInvalidImage)
+	_, err = Inspect(data[:100], "", 0)
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestThumbnails(t *testing.T) {
+	sizes, err := ParseSizes("large:800, small:100,medium:250")
+	require.NoError(t, err)
+	assert.Equal(t, []Size{{"small", 100}, {"medium", 250}, {"large", 800}}, sizes)
+	_, err = ParseSizes("small:0")
+	assert.Error(t, err)
+	_, err = ParseSizes("small:10,small:20")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 200)), nil))
+	img, err := Inspect(buf.Bytes(), "image/jpeg", 0)
+	require.NoError(t, err)
+
+	thumbs, err := img.Thumbnails(sizes)
+	require.NoError(t, err)
+	require.Len(t, thumbs, 3)
+	assert.Equal(t, [2]int{100, 66}, [2]int{thumbs[0].Width, thumbs[0].Height})
+	assert.Equal(t, [2]int{250, 166}, [2]int{thumbs[1].Width, thumbs[1].Height})
+	// 不放大
+	assert.Equal(t, [2]int{300, 200}, [2]int{thumbs[2].Width, thumbs[2].Height})
+
+	decoded, format, err := image.Decode(bytes.NewReader(thumbs[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 100, decoded.Bounds().Dx())
+
+	// PNG 缩略图保持 PNG，并保留颜色
+	img, err = Inspect(encodePNG(t, 40, 20), "", 0)
+	require.NoError(t, err)
+	thumbs, err = img.Thumbnails([]Size{{"tiny", 4}})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", thumbs[0].ContentType)
+	decoded, _ = png.Decode(bytes.NewReader(thumbs[0].Data))
+	_, _, b, _ := decoded.At(0, 0).RGBA()
+	assert.Equal(t, uint32(200), b>>8)
+}
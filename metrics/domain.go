@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+)
+
+// DomainMetrics 业务指标，作为 outbox 订阅者根据领域事件计数。
+// 事件由 dispatcher 在任意一个实例上投递一次，多实例部署时需要在 Prometheus 中求和
+type DomainMetrics struct {
+	events          *CounterVec
+	usersCreated    *Counter
+	productsCreated *Counter
+	outOfStock      *Counter
+}
+
+// NewDomainMetrics 创建业务指标并注册到 reg
+func NewDomainMetrics(reg *Registry) *DomainMetrics {
+	events := NewCounterVec("domain_events_total", "Total number of domain events handled by type.", "type")
+	users := NewCounterVec("users_created_total", "Total number of users created.")
+	products := NewCounterVec("products_created_total", "Total number of products created.")
+	outOfStock := NewCounterVec("products_out_of_stock_total", "Total number of times a product or variant ran out of available stock.")
+	reg.MustRegister(events, users, products, outOfStock)
+
+	return &DomainMetrics{
+		events:          events,
+		usersCreated:    users.WithLabelValues(),
+		productsCreated: products.WithLabelValues(),
+		outOfStock:      outOfStock.WithLabelValues(),
+	}
+}
+
+// HandleEvent outbox 订阅者，统计领域事件
+func (m *DomainMetrics) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	m.events.WithLabelValues(event.Type).Inc()
+	switch event.Type {
+	case models.EventUserCreated:
+		m.usersCreated.Inc()
+	case models.EventProductCreated:
+		m.productsCreated.Inc()
+	case models.EventProductStockChanged:
+		var movement models.StockMovement
+		if err := json.Unmarshal(event.Payload, &movement); err != nil {
+			return err
+		}
+		if soldOut(&movement) {
+			m.outOfStock.Inc()
+		}
+	}
+	return nil
+}
+
+// soldOut 判断这次库存变动是否使可用库存（库存减预留）从正数变为 0
+func soldOut(movement *models.StockMovement) bool {
+	after := movement.StockAfter - movement.ReservedAfter
+	before := after
+	switch movement.Type {
+	case models.StockMovementAdjust, models.StockMovementRelease:
+		before = after - movement.Quantity
+	case models.StockMovementReserve:
+		before = after + movement.Quantity
+	}
+	return before > 0 && after <= 0
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPlugin GORM 插件，按操作类型和表名记录查询耗时和错误数
+type GormPlugin struct {
+	duration *HistogramVec
+	errors   *CounterVec
+}
+
+// NewGormPlugin 创建 GORM 插件并注册指标，通过 db.Use 安装
+func NewGormPlugin(reg *Registry) *GormPlugin {
+	p := &GormPlugin{
+		duration: NewHistogramVec("gorm_query_duration_seconds", "GORM query latency in seconds by operation and table.",
+			[]float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}, "operation", "table"),
+		errors: NewCounterVec("gorm_query_errors_total", "Total number of failed GORM queries by operation and table.",
+			"operation", "table"),
+	}
+	reg.MustRegister(p.duration, p.errors)
+	return p
+}
+
+// Name 实现 gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "metrics"
+}
+
+type gormRegistrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// Initialize 实现 gorm.Plugin，在每类回调的最前开始计时、最后记录耗时
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation     string
+		before, after gormRegistrar
+	}{
+		{"create", cb.Create().Before("*"), cb.Create().After("*")},
+		{"query", cb.Query().Before("*"), cb.Query().After("*")},
+		{"update", cb.Update().Before("*"), cb.Update().After("*")},
+		{"delete", cb.Delete().Before("*"), cb.Delete().After("*")},
+		{"row", cb.Row().Before("*"), cb.Row().After("*")},
+		{"raw", cb.Raw().Before("*"), cb.Raw().After("*")},
+	}
+	for _, hook := range hooks {
+		if err := hook.before.Register("metrics:before_"+hook.operation, startTimer); err != nil {
+			return err
+		}
+		if err := hook.after.Register("metrics:after_"+hook.operation, p.observe(hook.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func (p *GormPlugin) observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.duration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.errors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package metrics
+
+// UnmatchedRoute 没有匹配到路由的请求使用的 route 标签，避免原始路径导致标签数量无限增长
+const UnmatchedRoute = "unmatched"
+
+// HTTPMetrics HTTP 请求指标，route 标签为路由模板（如 /api/v1/products/:id）
+type HTTPMetrics struct {
+	Requests *CounterVec
+	Duration *HistogramVec
+	InFlight *Gauge
+}
+
+// NewHTTPMetrics 创建 HTTP 请求指标并注册到 reg
+func NewHTTPMetrics(reg *Registry) *HTTPMetrics {
+	requests := NewCounterVec("http_requests_total", "Total number of HTTP requests.", "method", "route", "status")
+	duration := NewHistogramVec("http_request_duration_seconds", "HTTP request latency in seconds.", DefaultBuckets, "method", "route")
+	inFlight := NewGaugeVec("http_requests_in_flight", "Number of HTTP requests currently being served.")
+	reg.MustRegister(requests, duration, inFlight)
+
+	return &HTTPMetrics{
+		Requests: requests,
+		Duration: duration,
+		InFlight: inFlight.WithLabelValues(),
+	}
+}
@@ -0,0 +1,428 @@
+// Package metrics 以 Prometheus 文本格式导出指标，提供计数器、仪表盘和直方图，
+// 以及数据库连接池、GORM 查询、Go 运行时和领域事件的采集器
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets 默认的耗时直方图分桶（秒）
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Label 标签
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample 一个样本，Suffix 为指标名后缀，如直方图的 "_bucket"
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family 同名指标的所有样本
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector 指标采集器，每次导出时调用 Collect
+type Collector interface {
+	Collect() []Family
+}
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Registry 指标注册表，同名指标只能注册一次
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+	names      map[string]bool
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Register 注册采集器，指标名不合法或已注册时返回错误
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := c.Collect()
+	for _, family := range families {
+		if !nameRegexp.MatchString(family.Name) {
+			return fmt.Errorf("metrics: invalid metric name %q", family.Name)
+		}
+		if r.names[family.Name] {
+			return fmt.Errorf("metrics: duplicate metric %q", family.Name)
+		}
+	}
+	for _, family := range families {
+		r.names[family.Name] = true
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// MustRegister 注册采集器，失败时 panic
+func (r *Registry) MustRegister(collectors ...Collector) {
+	for _, c := range collectors {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Gather 采集所有指标，按名称排序，同名的样本合并
+func (r *Registry) Gather() []Family {
+	r.mu.RLock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	byName := make(map[string]*Family)
+	var names []string
+	for _, c := range collectors {
+		for _, family := range c.Collect() {
+			if existing, ok := byName[family.Name]; ok {
+				existing.Samples = append(existing.Samples, family.Samples...)
+				continue
+			}
+			f := family
+			byName[f.Name] = &f
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+
+	families := make([]Family, 0, len(names))
+	for _, name := range names {
+		families = append(families, *byName[name])
+	}
+	return families
+}
+
+// labelKey 将标签值拼接为 map 的键
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func makeLabels(names, values []string, extra ...Label) []Label {
+	labels := make([]Label, 0, len(names)+len(extra))
+	for i, name := range names {
+		labels = append(labels, Label{Name: name, Value: values[i]})
+	}
+	return append(labels, extra...)
+}
+
+// value 可以原子更新的 float64
+type value struct {
+	bits uint64
+}
+
+func (v *value) Load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&v.bits))
+}
+
+func (v *value) Store(f float64) {
+	atomic.StoreUint64(&v.bits, math.Float64bits(f))
+}
+
+func (v *value) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&v.bits, old, next) {
+			return
+		}
+	}
+}
+
+// vec 带标签的指标的公共部分，按标签值保存子指标
+type vec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu       sync.RWMutex
+	children map[string]interface{}
+	values   map[string][]string
+}
+
+func newVec(name, help string, labelNames []string) vec {
+	return vec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		children:   make(map[string]interface{}),
+		values:     make(map[string][]string),
+	}
+}
+
+// child 返回标签值对应的子指标，不存在时用 create 创建
+func (v *vec) child(values []string, create func() interface{}) interface{} {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labelNames), len(values)))
+	}
+	key := labelKey(values)
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok {
+		return c
+	}
+	c = create()
+	v.children[key] = c
+	v.values[key] = append([]string(nil), values...)
+	return c
+}
+
+// each 按标签值排序遍历子指标，保证输出稳定
+func (v *vec) each(fn func(values []string, child interface{})) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	type entry struct {
+		values []string
+		child  interface{}
+	}
+	entries := make([]entry, len(keys))
+	for i, key := range keys {
+		entries[i] = entry{v.values[key], v.children[key]}
+	}
+	v.mu.RUnlock()
+
+	for _, e := range entries {
+		fn(e.values, e.child)
+	}
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	v value
+}
+
+// Inc 加 1
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Add 增加 delta，delta 不能为负数
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.v.Add(delta)
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	return c.v.Load()
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec 创建带标签的计数器，labelNames 为空时用 WithLabelValues() 取得唯一的计数器
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{vec: newVec(name, help, labelNames)}
+}
+
+// WithLabelValues 返回标签值对应的计数器，值的顺序与 labelNames 一致
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.child(values, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+// Collect 实现 Collector
+func (v *CounterVec) Collect() []Family {
+	family := Family{Name: v.name, Help: v.help, Type: TypeCounter}
+	v.each(func(values []string, child interface{}) {
+		family.Samples = append(family.Samples, Sample{
+			Labels: makeLabels(v.labelNames, values),
+			Value:  child.(*Counter).Value(),
+		})
+	})
+	return []Family{family}
+}
+
+// Gauge 可增可减的仪表盘
+type Gauge struct {
+	v value
+}
+
+// Set 设置当前值
+func (g *Gauge) Set(f float64) {
+	g.v.Store(f)
+}
+
+// Inc 加 1
+func (g *Gauge) Inc() {
+	g.v.Add(1)
+}
+
+// Dec 减 1
+func (g *Gauge) Dec() {
+	g.v.Add(-1)
+}
+
+// Add 增加 delta，可以为负数
+func (g *Gauge) Add(delta float64) {
+	g.v.Add(delta)
+}
+
+// Value 当前值
+func (g *Gauge) Value() float64 {
+	return g.v.Load()
+}
+
+// GaugeVec 带标签的仪表盘
+type GaugeVec struct {
+	vec
+}
+
+// NewGaugeVec 创建带标签的仪表盘
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{vec: newVec(name, help, labelNames)}
+}
+
+// WithLabelValues 返回标签值对应的仪表盘
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return v.child(values, func() interface{} { return &Gauge{} }).(*Gauge)
+}
+
+// Collect 实现 Collector
+func (v *GaugeVec) Collect() []Family {
+	family := Family{Name: v.name, Help: v.help, Type: TypeGauge}
+	v.each(func(values []string, child interface{}) {
+		family.Samples = append(family.Samples, Sample{
+			Labels: makeLabels(v.labelNames, values),
+			Value:  child.(*Gauge).Value(),
+		})
+	})
+	return []Family{family}
+}
+
+// Histogram 直方图，记录观测值落在各个分桶中的次数、总和和总次数
+type Histogram struct {
+	count       uint64
+	sum         value
+	upperBounds []float64
+	counts      []uint64
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(f float64) {
+	i := sort.SearchFloat64s(h.upperBounds, f)
+	if i < len(h.counts) {
+		atomic.AddUint64(&h.counts[i], 1)
+	}
+	h.sum.Add(f)
+	atomic.AddUint64(&h.count, 1)
+}
+
+// Count 观测次数
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// Sum 观测值总和
+func (h *Histogram) Sum() float64 {
+	return h.sum.Load()
+}
+
+// samples 输出累计分桶、总和和总次数
+func (h *Histogram) samples(labelNames, values []string) []Sample {
+	samples := make([]Sample, 0, len(h.upperBounds)+3)
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		samples = append(samples, Sample{
+			Suffix: "_bucket",
+			Labels: makeLabels(labelNames, values, Label{Name: "le", Value: formatFloat(bound)}),
+			Value:  float64(cumulative),
+		})
+	}
+	count := h.Count()
+	labels := makeLabels(labelNames, values)
+	return append(samples,
+		Sample{Suffix: "_bucket", Labels: makeLabels(labelNames, values, Label{Name: "le", Value: "+Inf"}), Value: float64(count)},
+		Sample{Suffix: "_sum", Labels: labels, Value: h.Sum()},
+		Sample{Suffix: "_count", Labels: labels, Value: float64(count)},
+	)
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// NewHistogramVec 创建带标签的直方图，buckets 为各分桶的上限，为空时使用 DefaultBuckets
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{vec: newVec(name, help, labelNames), buckets: buckets}
+}
+
+// WithLabelValues 返回标签值对应的直方图
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.child(values, func() interface{} {
+		return &Histogram{upperBounds: v.buckets, counts: make([]uint64, len(v.buckets))}
+	}).(*Histogram)
+}
+
+// Collect 实现 Collector
+func (v *HistogramVec) Collect() []Family {
+	family := Family{Name: v.name, Help: v.help, Type: TypeHistogram}
+	v.each(func(values []string, child interface{}) {
+		family.Samples = append(family.Samples, child.(*Histogram).samples(v.labelNames, values)...)
+	})
+	return []Family{family}
+}
+
+// CollectorFunc 将函数转换为 Collector，用于导出时才读取的指标
+type CollectorFunc func() []Family
+
+// Collect 实现 Collector
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// NewGaugeFunc 创建导出时调用 fn 取值的仪表盘
+func NewGaugeFunc(name, help string, fn func() float64) Collector {
+	return CollectorFunc(func() []Family {
+		return []Family{{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Value: fn()}}}}
+	})
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func gatherText(t *testing.T, reg *Registry) string {
+	var buf bytes.Buffer
+	require.NoError(t, WriteText(&buf, reg.Gather()))
+	return buf.String()
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	requests := NewCounterVec("requests_total", "Total requests.", "path")
+	inFlight := NewGaugeVec("in_flight", "In flight.\nSecond line.")
+	latency := NewHistogramVec("latency_seconds", "Latency.", []float64{0.5, 0.1}, "path")
+	reg.MustRegister(requests, inFlight, latency)
+
+	requests.WithLabelValues(`/a"b`).Add(2)
+	requests.WithLabelValues("/").Inc()
+	inFlight.WithLabelValues().Inc()
+	inFlight.WithLabelValues().Inc()
+	inFlight.WithLabelValues().Dec()
+	latency.WithLabelValues("/").Observe(0.05)
+	latency.WithLabelValues("/").Observe(0.3)
+	latency.WithLabelValues("/").Observe(2)
+
+	expected := `# HELP in_flight In flight.\nSecond line.
+# TYPE in_flight gauge
+in_flight 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/",le="0.1"} 1
+latency_seconds_bucket{path="/",le="0.5"} 2
+latency_seconds_bucket{path="/",le="+Inf"} 3
+latency_seconds_sum{path="/"} 2.35
+latency_seconds_count{path="/"} 3
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{path="/"} 1
+requests_total{path="/a\"b"} 2
+`
+	assert.Equal(t, expected, gatherText(t, reg))
+
+	assert.Error(t, reg.Register(NewCounterVec("requests_total", "Duplicate.")))
+	assert.Error(t, reg.Register(NewCounterVec("bad-name", "Invalid.")))
+	assert.Panics(t, func() { requests.WithLabelValues() })
+	assert.Panics(t, func() { requests.WithLabelValues("/").Add(-1) })
+
+	w := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "in_flight 1")
+}
+
+func TestGormPluginAndDBStats(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	reg := NewRegistry()
+	require.NoError(t, db.Use(NewGormPlugin(reg)))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	reg.MustRegister(NewDBStatsCollector(func() *sql.DB { return sqlDB }), NewRuntimeCollector())
+
+	type item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.Exec("CREATE TABLE items (id integer PRIMARY KEY, name text)").Error)
+	require.NoError(t, db.Create(&item{Name: "a"}).Error)
+	var found item
+	require.NoError(t, db.First(&found).Error)
+	assert.ErrorIs(t, db.First(&found, 999).Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.Table("missing").Find(&[]item{}).Error)
+
+	text := gatherText(t, reg)
+	assert.Contains(t, text, `gorm_query_duration_seconds_count{operation="create",table="items"} 1`)
+	assert.Contains(t, text, `gorm_query_duration_seconds_count{operation="query",table="items"} 2`)
+	assert.Contains(t, text, `gorm_query_duration_seconds_count{operation="raw",table="unknown"} 1`)
+	assert.Contains(t, text, `gorm_query_errors_total{operation="query",table="missing"} 1`)
+	assert.NotContains(t, text, `gorm_query_errors_total{operation="query",table="items"}`)
+	assert.Contains(t, text, "db_open_connections 1")
+	assert.Contains(t, text, "# TYPE db_wait_count_total counter")
+	assert.Contains(t, text, "go_goroutines ")
+	assert.Contains(t, text, `go_info{version="`)
+}
+
+func TestDomainMetrics(t *testing.T) {
+	reg := NewRegistry()
+	m := NewDomainMetrics(reg)
+
+	event := func(eventType string, payload interface{}) *models.OutboxEvent {
+		data, _ := json.Marshal(payload)
+		return &models.OutboxEvent{Type: eventType, Payload: models.RawJSON(data)}
+	}
+	movement := func(movementType string, quantity, stock, reserved int) *models.OutboxEvent {
+		return event(models.EventProductStockChanged, models.StockMovement{
+			Type: movementType, Quantity: quantity, StockAfter: stock, ReservedAfter: reserved,
+		})
+	}
+
+	ctx := context.Background()
+	events := []*models.OutboxEvent{
+		event(models.EventUserCreated, nil),
+		event(models.EventUserCreated, nil),
+		event(models.EventProductCreated, nil),
+		movement(models.StockMovementAdjust, 5, 5, 0),  // 0 -> 5
+		movement(models.StockMovementReserve, 5, 5, 5), // 5 -> 0，售罄
+		movement(models.StockMovementCommit, 5, 0, 0),  // 仍为 0
+		movement(models.StockMovementAdjust, 3, 3, 0),  // 0 -> 3
+		movement(models.StockMovementAdjust, -3, 0, 0), // 3 -> 0，售罄
+		movement(models.StockMovementRelease, 2, 2, 0), // 0 -> 2
+		movement(models.StockMovementReserve, 1, 2, 1), // 2 -> 1
+	}
+	for _, e := range events {
+		require.NoError(t, m.HandleEvent(ctx, e))
+	}
+	assert.Error(t, m.HandleEvent(ctx, &models.OutboxEvent{Type: models.EventProductStockChanged, Payload: models.RawJSON("{")}))
+
+	text := gatherText(t, reg)
+	assert.Contains(t, text, "users_created_total 2\n")
+	assert.Contains(t, text, "products_created_total 1\n")
+	assert.Contains(t, text, "products_out_of_stock_total 2\n")
+	assert.Contains(t, text, `domain_events_total{type="product.stock_changed"} 8`)
+}
@@ -1,25 +1,35 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"strings"
 
+	"github.com/fangyanlin/gin-gorm-app/models"
 	"github.com/fangyanlin/gin-gorm-app/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware 认证中间件（示例，需要配合JWT使用）
-func AuthMiddleware() gin.HandlerFunc {
+const (
+	// ContextUserIDKey 上下文中保存当前用户ID的键
+	ContextUserIDKey = "user_id"
+	// ContextClaimsKey 上下文中保存 JWT 声明的键
+	ContextClaimsKey = "claims"
+	// ContextPermissionsKey 上下文中保存当前用户权限的键
+	ContextPermissionsKey = "permissions"
+)
+
+// AuthMiddleware 认证中间件，校验 Bearer token 并将用户信息写入上下文
+func AuthMiddleware(tokens *utils.TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取token
 		authHeader := c.GetHeader("Authorization")
-		
+
 		if authHeader == "" {
 			utils.UnauthorizedResponse(c, "Authorization header is required")
 			c.Abort()
 			return
 		}
-		
+
 		// 验证token格式 "Bearer <token>"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
@@ -27,52 +37,98 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		
-		token := parts[1]
-		
-		// TODO: 在这里添加JWT验证逻辑
-		// 示例：验证token是否有效
+
+		token := strings.TrimSpace(parts[1])
 		if token == "" {
 			utils.UnauthorizedResponse(c, "Invalid token")
 			c.Abort()
 			return
 		}
-		
+
+		// 校验签名、有效期、签发者和受众
+		claims, err := tokens.ParseToken(token)
+		if err != nil {
+			if errors.Is(err, utils.ErrExpiredToken) {
+				utils.UnauthorizedResponse(c, "Token has expired")
+			} else {
+				utils.UnauthorizedResponse(c, "Invalid token")
+			}
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到上下文中
-		// c.Set("user_id", userID)
-		
+		c.Set(ContextUserIDKey, claims.UserID)
+		c.Set(ContextClaimsKey, claims)
+
 		c.Next()
 	}
 }
 
-// AdminMiddleware 管理员权限中间件
-func AdminMiddleware() gin.HandlerFunc {
+// OptionalAuthMiddleware 可选认证中间件，未携带 Authorization 时按匿名请求放行，携带时必须有效
+func OptionalAuthMiddleware(tokens *utils.TokenService) gin.HandlerFunc {
+	auth := AuthMiddleware(tokens)
 	return func(c *gin.Context) {
-		// 从上下文获取用户信息
-		// userID, exists := c.Get("user_id")
-		// if !exists {
-		// 	utils.UnauthorizedResponse(c, "Unauthorized")
-		// 	c.Abort()
-		// 	return
-		// }
-		
-		// TODO: 检查用户是否为管理员
-		// isAdmin := checkIsAdmin(userID)
-		// if !isAdmin {
-		// 	utils.ForbiddenResponse(c, "Admin access required")
-		// 	c.Abort()
-		// 	return
-		// }
-		
-		c.Next()
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// GetUserID 从上下文获取当前认证用户ID
+func GetUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get(ContextUserIDKey)
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(uint)
+	return userID, ok
+}
+
+// GetClaims 从上下文获取当前请求的 JWT 声明
+func GetClaims(c *gin.Context) (*utils.Claims, bool) {
+	value, exists := c.Get(ContextClaimsKey)
+	if !exists {
+		return nil, false
 	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok
 }
 
-// RateLimitMiddleware 限流中间件（简单示例）
-func RateLimitMiddleware() gin.HandlerFunc {
+// PermissionLoader 加载用户权限，由 repository.RoleRepository 实现
+type PermissionLoader interface {
+	GetUserPermissions(userID uint) ([]string, error)
+}
+
+// RequirePermissions 权限校验中间件，需在 AuthMiddleware 之后使用，
+// 用户必须拥有全部所需权限
+func RequirePermissions(loader PermissionLoader, permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现限流逻辑
-		// 可以使用 redis 或内存存储来实现
+		userID, exists := GetUserID(c)
+		if !exists {
+			utils.UnauthorizedResponse(c, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		granted, err := loader.GetUserPermissions(userID)
+		if err != nil {
+			utils.InternalServerErrorResponse(c, "Failed to load permissions")
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !models.PermissionGranted(granted, permission) {
+				utils.ForbiddenResponse(c, "Permission denied: "+permission)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set(ContextPermissionsKey, granted)
 		c.Next()
 	}
 }
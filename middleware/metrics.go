@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 指标中间件，记录请求数、耗时和进行中的请求数。
+// route 标签使用路由模板，没有匹配到路由的请求记为 metrics.UnmatchedRoute
+func Metrics(m *metrics.HTTPMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.InFlight.Inc()
+		defer m.InFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = metrics.UnmatchedRoute
+		}
+		method := c.Request.Method
+		m.Requests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.Duration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
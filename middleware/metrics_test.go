@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	captureLogs(t)
+
+	reg := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(reg)
+	router := gin.New()
+	router.Use(Metrics(httpMetrics), Recovery())
+	router.GET("/products/:id", func(c *gin.Context) {
+		assert.Equal(t, float64(1), httpMetrics.InFlight.Value())
+		c.Status(http.StatusOK)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	for _, path := range []string{"/products/1", "/products/2", "/missing/1", "/panic"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, float64(0), httpMetrics.InFlight.Value())
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.WriteText(&buf, reg.Gather()))
+	text := buf.String()
+	assert.Contains(t, text, `http_requests_total{method="GET",route="/products/:id",status="200"} 2`)
+	assert.Contains(t, text, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, text, `http_requests_total{method="GET",route="/panic",status="500"} 1`)
+	assert.Contains(t, text, `http_request_duration_seconds_count{method="GET",route="/products/:id"} 2`)
+	assert.NotContains(t, text, "/products/1")
+}
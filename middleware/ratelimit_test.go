@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitKeyBy_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyOf := func(keyFunc RateLimitKeyFunc, apiKey string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		if apiKey != "" {
+			c.Request.Header.Set("X-API-Key", apiKey)
+		}
+		return keyFunc(c)
+	}
+
+	valid := func(apiKey string) bool { return apiKey == "valid-key" }
+	keyFunc := RateLimitKeyBy("api_key", nil, valid)
+	assert.Equal(t, "ip:10.0.0.1", keyOf(keyFunc, ""))
+	// 无效的 key 不能用来换取新的限流桶
+	assert.Equal(t, "ip:10.0.0.1", keyOf(keyFunc, "random-1"))
+	assert.Equal(t, "ip:10.0.0.1", keyOf(keyFunc, "random-2"))
+	assert.NotEqual(t, "ip:10.0.0.1", keyOf(keyFunc, "valid-key"))
+
+	// 未配置校验时始终按 IP 限流
+	assert.Equal(t, "ip:10.0.0.1", keyOf(RateLimitKeyBy("api_key", nil, nil), "valid-key"))
+}
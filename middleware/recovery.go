@@ -1,27 +1,34 @@
 package middleware
-package middleware
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 
 	"github.com/fangyanlin/gin-gorm-app/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// Recovery 错误恢复中间件
+// Recovery 错误恢复中间件，记录 panic 和调用栈（带请求ID）并返回 500
 func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
 				// 记录错误日志
-				// log.Printf("Panic recovered: %v", err)
-				
+				slog.ErrorContext(c.Request.Context(), "panic recovered",
+					"error", fmt.Sprint(err),
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+					"stack", string(debug.Stack()),
+				)
+
 				// 返回500错误
 				utils.ErrorResponse(c, http.StatusInternalServerError, "Internal server error")
 				c.Abort()
 			}
 		}()
-		
+
 		c.Next()
 	}
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/fangyanlin/gin-gorm-app/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader 请求ID的请求头和响应头
+	RequestIDHeader = "X-Request-ID"
+	// ContextRequestIDKey 上下文中保存请求ID的键
+	ContextRequestIDKey = "request_id"
+)
+
+// RequestID 请求ID中间件。沿用客户端或网关传入的 X-Request-ID（格式不合法时重新生成），
+// 写入响应头，并保存到 gin 上下文和 c.Request.Context() 中，供日志和数据库查询使用
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(ContextRequestIDKey, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// GetRequestID 从上下文获取当前请求ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(ContextRequestIDKey)
+}
+
+// validRequestID 只接受长度不超过 128 的字母、数字和 -_.: 字符，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs 将默认 logger 替换为写入 buf 的 JSON logger
+func captureLogs(t *testing.T) *bytes.Buffer {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	_, err := logging.Setup(logging.Config{Level: "debug", Output: &buf})
+	require.NoError(t, err)
+	return &buf
+}
+
+func findLog(t *testing.T, buf *bytes.Buffer, msg string) map[string]interface{} {
+	for _, line := range strings.Split(buf.String(), "\n") {
+		var record map[string]interface{}
+		if json.Unmarshal([]byte(line), &record) == nil && record["msg"] == msg {
+			return record
+		}
+	}
+	t.Fatalf("log %q not found in %s", msg, buf.String())
+	return nil
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := captureLogs(t)
+
+	router := gin.New()
+	router.Use(RequestID(), Logger(), Recovery())
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context())+"|"+GetRequestID(c))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	// 沿用合法的请求ID
+	req, _ := http.NewRequest("GET", "/items/1", nil)
+	req.Header.Set(RequestIDHeader, "client-id:42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "client-id:42", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "client-id:42|client-id:42", w.Body.String())
+
+	record := findLog(t, buf, "request")
+	assert.Equal(t, "client-id:42", record["request_id"])
+	assert.Equal(t, "/items/:id", record["route"])
+	assert.Equal(t, float64(http.StatusOK), record["status"])
+
+	// 不合法的请求ID重新生成
+	req, _ = http.NewRequest("GET", "/items/1", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	generated := w.Header().Get(RequestIDHeader)
+	assert.Len(t, generated, 32)
+	assert.Equal(t, generated+"|"+generated, w.Body.String())
+
+	// panic 日志带有请求ID和堆栈
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "/panic", nil)
+	req.Header.Set(RequestIDHeader, "panic-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "panic-1", w.Header().Get(RequestIDHeader))
+
+	record = findLog(t, buf, "panic recovered")
+	assert.Equal(t, "panic-1", record["request_id"])
+	assert.Equal(t, "boom", record["error"])
+	assert.Contains(t, record["stack"], "request_id_test.go")
+	assert.Equal(t, "ERROR", findLog(t, buf, "request")["level"])
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/fangyanlin/gin-gorm-app/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing 链路追踪中间件。请求头中有合法的 traceparent 时加入上游链路，否则开始新的链路；
+// 为每个请求创建名为 "方法 路由模板" 的 server span，并写入 c.Request.Context() 供后续的数据库查询使用
+func Tracing(tracer *tracing.Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if parent, err := tracing.ParseTraceparent(c.GetHeader(tracing.TraceparentHeader)); err == nil {
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+		}
+
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			tracing.WithSpanKind(tracing.SpanKindServer),
+			tracing.WithAttributes(
+				tracing.String("http.request.method", c.Request.Method),
+				tracing.String("url.path", c.Request.URL.Path),
+				tracing.String("client.address", c.ClientIP()),
+				tracing.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+		if route != "" {
+			span.SetAttributes(tracing.String("http.route", route))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(tracing.Int("http.response.status_code", int64(status)))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.SetAttributes(tracing.String("error.message", c.Errors.String()))
+		}
+	}
+}
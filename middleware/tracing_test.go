@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/tracing"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := captureLogs(t)
+
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.NewTracer(tracing.Config{Exporter: exporter, SampleRatio: 1})
+	defer tracer.Shutdown(context.Background())
+
+	router := gin.New()
+	router.Use(RequestID(), Tracing(tracer), Recovery())
+	router.GET("/products/:id", func(c *gin.Context) {
+		slog.InfoContext(c.Request.Context(), "loading product")
+		utils.NotFoundResponse(c, "Product not found")
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	// 加入上游链路，错误响应和日志带上链路ID
+	req, _ := http.NewRequest("GET", "/products/1", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var body utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusNotFound, body.Code)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", body.TraceID)
+	record := findLog(t, buf, "loading product")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.NotEqual(t, "00f067aa0ba902b7", record["span_id"])
+
+	// 没有上游链路时开始新的链路，panic 记为错误
+	req, _ = http.NewRequest("GET", "/panic", nil)
+	req.Header.Set(tracing.TraceparentHeader, "invalid")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.TraceID, 32)
+	assert.Equal(t, body.TraceID, findLog(t, buf, "panic recovered")["trace_id"])
+
+	require.NoError(t, tracer.ForceFlush(context.Background()))
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "GET /products/:id", spans[0].Name)
+	assert.Equal(t, tracing.SpanKindServer, spans[0].Kind)
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].ParentSpanID.String())
+	assert.Contains(t, spans[0].Attributes, tracing.String("http.route", "/products/:id"))
+	assert.Contains(t, spans[0].Attributes, tracing.Int("http.response.status_code", 404))
+	assert.Equal(t, tracing.StatusUnset, spans[0].StatusCode)
+	assert.Equal(t, "GET /panic", spans[1].Name)
+	assert.False(t, spans[1].ParentSpanID.IsValid())
+	assert.Equal(t, tracing.StatusError, spans[1].StatusCode)
+}
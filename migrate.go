@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fangyanlin/gin-gorm-app/config"
+	"github.com/fangyanlin/gin-gorm-app/database"
+)
+
+const migrateUsage = `Usage: app migrate <command> [arguments]
+
+Commands:
+  up [n]                 执行所有（或 n 个）未执行的迁移
+  down [n]               回滚最近的 1 个（或 n 个）迁移
+  status                 查看迁移状态
+  create [-dir d] <name> 为 sqlite、mysql、postgres 创建新的迁移文件
+`
+
+// runMigrate 执行 migrate 子命令，返回进程退出码
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command, args := args[0], args[1:]
+	if command == "create" {
+		fs := flag.NewFlagSet("create", flag.ContinueOnError)
+		dir := fs.String("dir", "database/migrations", "迁移文件目录")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		files, err := database.CreateMigration(*dir, fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create migration: %v\n", err)
+			return 1
+		}
+		for _, file := range files {
+			fmt.Println("Created", file)
+		}
+		return 0
+	}
+
+	steps := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			fmt.Fprintf(os.Stderr, "Invalid step count %q\n", args[0])
+			return 2
+		}
+		steps = n
+	}
+
+	if err := database.Connect(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect database: %v\n", err)
+		return 1
+	}
+	defer database.CloseDB()
+
+	migrator, err := database.NewMigrator(database.GetDB(), cfg.Database.Driver)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load migrations: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx, steps)
+		for _, m := range done {
+			fmt.Printf("Applied %06d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		for _, m := range done {
+			fmt.Printf("Rolled back %06d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Rollback failed: %v\n", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read migration status: %v\n", err)
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Cart 购物车，登录用户按 UserID 识别，匿名用户按购物车令牌识别
+type Cart struct {
+	BaseModel
+	UserID    *uint      `gorm:"uniqueIndex" json:"user_id"`
+	TokenHash *string    `gorm:"uniqueIndex;size:64" json:"-"`
+	Items     []CartItem `gorm:"constraint:OnDelete:CASCADE" json:"items"`
+}
+
+// TableName 指定表名
+func (Cart) TableName() string {
+	return "carts"
+}
+
+// CartItem 购物车商品，同一购物车中每个产品（有变体时为每个变体）只有一项
+type CartItem struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	CartID    uint            `gorm:"uniqueIndex:idx_cart_items_cart_product;not null" json:"cart_id"`
+	ProductID uint            `gorm:"uniqueIndex:idx_cart_items_cart_product;not null" json:"product_id"`
+	VariantID *uint           `gorm:"uniqueIndex:idx_cart_items_cart_product" json:"variant_id"`
+	Quantity  int             `gorm:"not null" json:"quantity"`
+	Product   *Product        `gorm:"foreignKey:ProductID" json:"-"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (CartItem) TableName() string {
+	return "cart_items"
+}
+
+// CartLine 购物车中一项商品按当前价格计算的结果
+type CartLine struct {
+	ProductID      uint   `json:"product_id"`
+	VariantID      *uint  `json:"variant_id,omitempty"`
+	SKU            string `json:"sku,omitempty"`
+	ProductName    string `json:"product_name"`
+	UnitPrice      Money  `json:"unit_price"`
+	Quantity       int    `json:"quantity"`
+	Subtotal       Money  `json:"subtotal"`
+	Available      bool   `json:"available"`
+	AvailableStock int    `json:"available_stock"`
+}
+
+// CartSummary 购物车视图，金额使用基础货币，总价只计算当前可购买的商品
+type CartSummary struct {
+	ID        uint       `json:"id"`
+	CartToken string     `json:"cart_token,omitempty"`
+	Items     []CartLine `json:"items"`
+	ItemCount int        `json:"item_count"`
+	Total     Money      `json:"total"`
+}
+
+// Summary 根据产品的当前价格、上架状态和库存重新计算购物车，指定了变体的商品按变体的价格和库存计算。
+// Items 需预加载 Product 和 Variant
+func (c *Cart) Summary() CartSummary {
+	summary := CartSummary{ID: c.ID, Items: make([]CartLine, 0, len(c.Items)), Total: NewMoney(0, DefaultCurrency)}
+	for _, item := range c.Items {
+		line := CartLine{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity, UnitPrice: NewMoney(0, DefaultCurrency)}
+		if product := item.Product; product != nil && product.ID != 0 {
+			line.ProductName = product.Name
+			if item.VariantID == nil {
+				line.UnitPrice = product.Price
+				line.AvailableStock = product.AvailableStock()
+				line.Available = product.IsAvailable && item.Quantity <= line.AvailableStock
+			} else if variant := item.Variant; variant != nil && variant.ID != 0 {
+				// 变体已被删除时该项不可购买
+				line.SKU = variant.SKU
+				line.UnitPrice = variant.EffectivePrice(product)
+				line.AvailableStock = variant.AvailableStock()
+				line.Available = product.IsAvailable && variant.IsAvailable && item.Quantity <= line.AvailableStock
+			}
+		}
+		line.Subtotal = NewMoney(0, line.UnitPrice.Currency)
+		if line.Available {
+			subtotal, err := line.UnitPrice.Multiply(item.Quantity)
+			total, addErr := summary.Total.Add(subtotal)
+			if err == nil && addErr == nil {
+				line.Subtotal = subtotal
+				summary.Total = total
+				summary.ItemCount += item.Quantity
+			} else {
+				// 价格不是基础货币或金额溢出时不计入总价
+				line.Available = false
+			}
+		}
+		summary.Items = append(summary.Items, line)
+	}
+	return summary
+}
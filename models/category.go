@@ -0,0 +1,92 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Category 产品分类，通过 ParentID 组成树。分类直接删除而不是软删除，
+// 以便外键约束和 slug 唯一索引保持有效
+type Category struct {
+	ID          uint        `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Name        string      `gorm:"not null;size:100" json:"name" binding:"required,max=100"`
+	Slug        string      `gorm:"uniqueIndex;not null;size:100" json:"slug" binding:"omitempty,max=100"`
+	Description string      `gorm:"type:text" json:"description"`
+	ParentID    *uint       `gorm:"index" json:"parent_id"`
+	SortOrder   int         `gorm:"default:0" json:"sort_order"`
+	Parent      *Category   `gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT" json:"-"`
+	Children    []*Category `gorm:"-" json:"children,omitempty"`
+}
+
+// TableName 指定表名
+func (Category) TableName() string {
+	return "categories"
+}
+
+// slugPattern 合法的 slug：小写字母、数字和单个连字符
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// IsValidSlug 判断 slug 是否合法
+func IsValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// Slugify 由名称生成 slug，非字母数字字符替换为连字符；名称中没有 ASCII 字母数字时返回空字符串
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// BuildCategoryTree 将分类列表组装为树，同级按 SortOrder、名称排序；
+// 父分类不在列表中的分类作为根节点
+func BuildCategoryTree(categories []Category) []*Category {
+	nodes := make(map[uint]*Category, len(categories))
+	for i := range categories {
+		categories[i].Children = nil
+		nodes[categories[i].ID] = &categories[i]
+	}
+
+	var roots []*Category
+	for i := range categories {
+		node := &categories[i]
+		if node.ParentID != nil {
+			if parent, ok := nodes[*node.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	sortCategories(roots)
+	return roots
+}
+
+func sortCategories(nodes []*Category) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].SortOrder != nodes[j].SortOrder {
+			return nodes[i].SortOrder < nodes[j].SortOrder
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortCategories(node.Children)
+	}
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCursor 游标无效、被篡改或与当前排序不匹配
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor 游标内容：排序签名、边界记录各排序列的值以及翻页方向
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// CursorPage 游标分页参数和结果
+type CursorPage struct {
+	Limit     int  `form:"limit"`
+	WithTotal bool `form:"with_total"`
+	// Cursor 为 nil 时从第一页开始
+	Cursor *Cursor `form:"-"`
+
+	Total *int64  `form:"-"`
+	Next  *Cursor `form:"-"`
+	Prev  *Cursor `form:"-"`
+}
+
+// GetLimit 获取每页数量
+func (p *CursorPage) GetLimit() int {
+	if p.Limit <= 0 {
+		p.Limit = 10
+	}
+	if p.Limit > 100 {
+		p.Limit = 100
+	}
+	return p.Limit
+}
+
+// SortKey 排序签名，游标只能在相同排序下使用
+func (q *ListQuery) SortKey() string {
+	parts := make([]string, 0, len(q.keysetSorts()))
+	for _, s := range q.keysetSorts() {
+		if s.Desc {
+			parts = append(parts, "-"+s.Column)
+		} else {
+			parts = append(parts, s.Column)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// FindCursorPage 按键集分页查询：以游标中边界记录的排序键为起点，
+// 用 WHERE 条件代替 OFFSET，并通过多取一条判断是否还有下一页
+func FindCursorPage[T any](db *gorm.DB, q *ListQuery, schema QuerySchema, page *CursorPage, dest *[]T) error {
+	if q == nil {
+		q = &ListQuery{}
+	}
+	sorts := q.keysetSorts()
+	limit := page.GetLimit()
+
+	query := q.ApplyFilters(db)
+	if page.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+		page.Total = &total
+	}
+
+	backward := false
+	if page.Cursor != nil {
+		if page.Cursor.Sort != q.SortKey() || len(page.Cursor.Values) != len(sorts) {
+			return ErrInvalidCursor
+		}
+		values, err := decodeCursorValues(page.Cursor.Values, sorts, schema)
+		if err != nil {
+			return err
+		}
+		backward = page.Cursor.Backward
+		query = query.Where(keysetCondition(sorts, values, backward))
+	}
+
+	for _, s := range sorts {
+		query = query.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: s.Column},
+			Desc:   s.Desc != backward,
+		})
+	}
+
+	var rows []T
+	if err := query.Limit(limit + 1).Find(&rows).Error; err != nil {
+		return err
+	}
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	*dest = rows
+
+	page.Next, page.Prev = nil, nil
+	if len(rows) == 0 {
+		return nil
+	}
+	if hasMore || backward {
+		cursor, err := newCursor(db, q.SortKey(), sorts, &rows[len(rows)-1], false)
+		if err != nil {
+			return err
+		}
+		page.Next = cursor
+	}
+	if (page.Cursor != nil && !backward) || (backward && hasMore) {
+		cursor, err := newCursor(db, q.SortKey(), sorts, &rows[0], true)
+		if err != nil {
+			return err
+		}
+		page.Prev = cursor
+	}
+	return nil
+}
+
+// keysetSorts 键集分页使用的排序列，没有排序条件时按 id 升序
+func (q *ListQuery) keysetSorts() []SortField {
+	if len(q.Sorts) == 0 {
+		return []SortField{{Field: "id", Column: "id"}}
+	}
+	return q.Sorts
+}
+
+// keysetCondition 构造 (a > ?) OR (a = ? AND b > ?) ... 形式的条件，
+// 每一列按自身的排序方向（向前翻页时取反）选择比较符
+func keysetCondition(sorts []SortField, values []interface{}, backward bool) clause.Expression {
+	var or []clause.Expression
+	for i, s := range sorts {
+		var and []clause.Expression
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: sorts[j].Column}, Value: values[j]})
+		}
+		column := clause.Column{Table: clause.CurrentTable, Name: s.Column}
+		if s.Desc != backward {
+			and = append(and, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column, Value: values[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
+
+// newCursor 读取记录中各排序列的值生成游标
+func newCursor(db *gorm.DB, sortKey string, sorts []SortField, row interface{}, backward bool) (*Cursor, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(row); err != nil {
+		return nil, err
+	}
+
+	cursor := &Cursor{Sort: sortKey, Backward: backward}
+	rv := reflect.ValueOf(row)
+	for _, s := range sorts {
+		field := stmt.Schema.LookUpField(s.Column)
+		if field == nil {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCursor, s.Column)
+		}
+		value, _ := field.ValueOf(context.Background(), rv)
+		cursor.Values = append(cursor.Values, encodeCursorValue(value))
+	}
+	return cursor, nil
+}
+
+func encodeCursorValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// decodeCursorValues 按白名单中的字段类型还原游标中的排序键
+func decodeCursorValues(raw []string, sorts []SortField, schema QuerySchema) ([]interface{}, error) {
+	values := make([]interface{}, len(sorts))
+	for i, s := range sorts {
+		fieldType := FieldInt
+		if field, ok := schema.Fields[s.Field]; ok {
+			fieldType = field.Type
+		}
+		// 游标中保存的是列的原始值，金额已是最小货币单位
+		if fieldType == FieldMoney {
+			fieldType = FieldInt
+		}
+		value, err := parseScalar(fieldType, raw[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFindCursorPage(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&Product{})
+	for i := 1; i <= 7; i++ {
+		// 价格有重复，验证 id 作为第二排序键
+		db.Create(&Product{Name: fmt.Sprintf("p%d", i), Price: NewMoney(int64(1000*((i+1)/2)), "USD"), IsAvailable: true})
+	}
+
+	values, _ := url.ParseQuery("sort=-price")
+	q, err := ParseListQuery(values, ProductQuerySchema)
+	assert.NoError(t, err)
+
+	names := func(products []Product) []string {
+		var out []string
+		for _, p := range products {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+
+	page := &CursorPage{Limit: 3, WithTotal: true}
+	var products []Product
+	assert.NoError(t, FindCursorPage(db.Model(&Product{}), q, ProductQuerySchema, page, &products))
+	assert.Equal(t, []string{"p7", "p6", "p5"}, names(products))
+	assert.Equal(t, int64(7), *page.Total)
+	assert.Nil(t, page.Prev)
+	assert.NotNil(t, page.Next)
+
+	page = &CursorPage{Limit: 3, Cursor: page.Next}
+	assert.NoError(t, FindCursorPage(db.Model(&Product{}), q, ProductQuerySchema, page, &products))
+	assert.Equal(t, []string{"p4", "p3", "p2"}, names(products))
+	assert.Nil(t, page.Total)
+	next := page.Next
+
+	// 向前翻页回到第一页
+	page = &CursorPage{Limit: 3, Cursor: page.Prev}
+	assert.NoError(t, FindCursorPage(db.Model(&Product{}), q, ProductQuerySchema, page, &products))
+	assert.Equal(t, []string{"p7", "p6", "p5"}, names(products))
+	assert.Nil(t, page.Prev)
+
+	page = &CursorPage{Limit: 3, Cursor: next}
+	assert.NoError(t, FindCursorPage(db.Model(&Product{}), q, ProductQuerySchema, page, &products))
+	assert.Equal(t, []string{"p1"}, names(products))
+	assert.Nil(t, page.Next)
+	assert.NotNil(t, page.Prev)
+
+	// 游标不能用于不同的排序
+	values, _ = url.ParseQuery("sort=name")
+	other, _ := ParseListQuery(values, ProductQuerySchema)
+	err = FindCursorPage(db.Model(&Product{}), other, ProductQuerySchema, &CursorPage{Cursor: next}, &products)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
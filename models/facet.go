@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// 可请求的产品分面
+const (
+	FacetCategory     = "category"
+	FacetAvailability = "availability"
+	FacetPrice        = "price"
+)
+
+// 分面参数限制
+const (
+	maxPriceBoundaries = 20
+	// MaxCategoryFacets 分类分面最多返回的分类数，按数量降序截取
+	MaxCategoryFacets = 50
+)
+
+// DefaultPriceBoundaries 未指定 price_buckets 时的价格区间分界点，按基础货币解析
+var DefaultPriceBoundaries = "25,50,100,250,500,1000"
+
+// FacetRequest 需要计算的分面
+type FacetRequest struct {
+	Category     bool
+	Availability bool
+	Price        bool
+	// PriceBoundaries 以基础货币表示的升序价格分界点，n 个分界点产生 n+1 个区间
+	PriceBoundaries []Money
+}
+
+// ProductFacets 与搜索结果相同过滤范围内的分面统计，只包含请求的分面
+type ProductFacets struct {
+	Category     []FacetCount       `json:"category,omitempty"`
+	Availability *AvailabilityFacet `json:"availability,omitempty"`
+	Price        []PriceBucket      `json:"price,omitempty"`
+}
+
+// FacetCount 分面取值及其产品数
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// AvailabilityFacet 按可售库存统计的产品数
+type AvailabilityFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
+}
+
+// PriceBucket 价格区间 [Min, Max) 内的产品数，首尾区间分别没有下限和上限
+type PriceBucket struct {
+	Min   *Money `json:"min,omitempty"`
+	Max   *Money `json:"max,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// ParseFacetRequest 解析 facets 和 price_buckets 参数，例如
+// ?facets=category,availability,price&price_buckets=50,100。
+// 未指定 facets 时返回 nil
+func ParseFacetRequest(values url.Values) (*FacetRequest, error) {
+	param := strings.TrimSpace(values.Get("facets"))
+	if param == "" {
+		return nil, nil
+	}
+
+	req := &FacetRequest{}
+	for _, name := range strings.Split(param, ",") {
+		switch strings.TrimSpace(name) {
+		case FacetCategory:
+			req.Category = true
+		case FacetAvailability:
+			req.Availability = true
+		case FacetPrice:
+			req.Price = true
+		case "":
+		default:
+			return nil, fmt.Errorf("%w: unsupported facet %q", ErrInvalidQuery, name)
+		}
+	}
+
+	if !req.Price {
+		return req, nil
+	}
+	raw := values.Get("price_buckets")
+	if raw == "" {
+		raw = DefaultPriceBoundaries
+	}
+	boundaries, err := parsePriceBoundaries(raw)
+	if err != nil {
+		return nil, err
+	}
+	req.PriceBoundaries = boundaries
+	return req, nil
+}
+
+// PriceBuckets 按分界点生成价格区间，counts 与区间一一对应
+func (r *FacetRequest) PriceBuckets(counts []int64) []PriceBucket {
+	buckets := make([]PriceBucket, len(r.PriceBoundaries)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = &r.PriceBoundaries[i-1]
+		}
+		if i < len(r.PriceBoundaries) {
+			buckets[i].Max = &r.PriceBoundaries[i]
+		}
+		if i < len(counts) {
+			buckets[i].Count = counts[i]
+		}
+	}
+	return buckets
+}
+
+// parsePriceBoundaries 按基础货币解析逗号分隔的价格分界点，去重并升序排列
+func parsePriceBoundaries(raw string) ([]Money, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxPriceBoundaries {
+		return nil, fmt.Errorf("%w: too many price buckets (max %d)", ErrInvalidQuery, maxPriceBoundaries)
+	}
+
+	seen := make(map[int64]bool, len(parts))
+	boundaries := make([]Money, 0, len(parts))
+	for _, part := range parts {
+		value, err := ParseMoney(part, DefaultCurrency)
+		if err != nil || value.Amount < 0 {
+			return nil, fmt.Errorf("%w: invalid price bucket %q", ErrInvalidQuery, part)
+		}
+		if !seen[value.Amount] {
+			seen[value.Amount] = true
+			boundaries = append(boundaries, value)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Amount < boundaries[j].Amount })
+	return boundaries, nil
+}
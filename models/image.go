@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ProductImage 产品图片。文件保存在存储中，这里只保存元数据；
+// 每个产品最多一张主图（IsPrimary），列表按 SortOrder 排序
+type ProductImage struct {
+	ID          uint            `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ProductID   uint            `gorm:"index;not null" json:"product_id"`
+	Key         string          `gorm:"column:storage_key;size:255;not null" json:"key"`
+	URL         string          `gorm:"size:500;not null" json:"url"`
+	ContentType string          `gorm:"size:50;not null" json:"content_type"`
+	Size        int64           `gorm:"not null" json:"size"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	AltText     string          `gorm:"size:255" json:"alt_text"`
+	SortOrder   int             `gorm:"default:0" json:"sort_order"`
+	IsPrimary   bool            `gorm:"default:false" json:"is_primary"`
+	Thumbnails  ImageThumbnails `gorm:"type:text" json:"thumbnails"`
+	Product     *Product        `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+// ImageThumbnail 一种规格的缩略图
+type ImageThumbnail struct {
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ImageThumbnails 以 JSON 对象存储的缩略图，键为规格名称（如 small、medium）
+type ImageThumbnails map[string]ImageThumbnail
+
+// Value 实现 driver.Valuer
+func (t ImageThumbnails) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]ImageThumbnail(t))
+	return string(b), err
+}
+
+// Scan 实现 sql.Scanner
+func (t *ImageThumbnails) Scan(value interface{}) error {
+	return scanJSON(value, t)
+}
+
+// Keys 原图和所有缩略图在存储中的键
+func (img *ProductImage) Keys() []string {
+	keys := []string{img.Key}
+	for _, thumb := range img.Thumbnails {
+		keys = append(keys, thumb.Key)
+	}
+	return keys
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// 后台任务状态
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+	JobStatusCancelled = "cancelled"
+)
+
+// IsValidJobStatus 判断任务状态是否有效
+func IsValidJobStatus(status string) bool {
+	switch status {
+	case JobStatusPending, JobStatusRunning, JobStatusSucceeded, JobStatusDead, JobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Job 后台任务。pending 的任务在 RunAt 之后被所属队列的 worker 领取并标记为 running，
+// 成功后为 succeeded；失败后按指数退避重新排队，超过 MaxAttempts 次后进入 dead（死信）状态
+type Job struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Queue       string     `gorm:"size:50;not null;index:idx_jobs_poll,priority:1" json:"queue"`
+	Type        string     `gorm:"size:100;not null;index" json:"type"`
+	Payload     RawJSON    `gorm:"type:text" json:"payload"`
+	Status      string     `gorm:"size:20;not null;index:idx_jobs_poll,priority:2" json:"status"`
+	RunAt       time.Time  `gorm:"not null;index:idx_jobs_poll,priority:3" json:"run_at"`
+	Attempts    int        `gorm:"not null" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	LockedBy    string     `gorm:"size:100" json:"locked_by,omitempty"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName 指定表名
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// JobStats 按队列和状态统计的任务数量
+type JobStats struct {
+	Queue  string `json:"queue"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
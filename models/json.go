@@ -0,0 +1,47 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// RawJSON 以文本存储的 JSON 值（如任务参数、事件内容），接口中原样输出
+type RawJSON json.RawMessage
+
+// Value 实现 driver.Valuer
+func (p RawJSON) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return "null", nil
+	}
+	return string(p), nil
+}
+
+// Scan 实现 sql.Scanner
+func (p *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+	case []byte:
+		*p = append(RawJSON(nil), v...)
+	case string:
+		*p = RawJSON(v)
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", value)
+	}
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (p RawJSON) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (p *RawJSON) UnmarshalJSON(data []byte) error {
+	*p = append(RawJSON(nil), data...)
+	return nil
+}
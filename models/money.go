@@ -0,0 +1,316 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidMoney 金额格式不正确或超出范围
+	ErrInvalidMoney = errors.New("invalid money amount")
+	// ErrInvalidCurrency 不支持的 ISO 4217 货币代码
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrCurrencyMismatch 不同币种的金额不能直接运算
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// currencyExponents ISO 4217 货币代码及其小数位数（最小货币单位的指数）
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2,
+	"NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "RUB": 2, "SAR": 2, "SEK": 2,
+	"SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// DefaultCurrency 商店的基础货币，产品价格以该货币存储，价格过滤和分面也按该货币解析
+var DefaultCurrency = "USD"
+
+// SetDefaultCurrency 设置基础货币，启动时根据配置调用
+func SetDefaultCurrency(code string) error {
+	currency, err := NormalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+	DefaultCurrency = currency
+	return nil
+}
+
+// NormalizeCurrency 将货币代码转为大写并校验是否受支持
+func NormalizeCurrency(code string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencyExponents[currency]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	return currency, nil
+}
+
+// CurrencyExponent 货币的小数位数，例如 USD 为 2，JPY 为 0
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[currency]
+	return exponent, ok
+}
+
+// Money 金额，以最小货币单位（如分）的整数和 ISO 4217 货币代码表示，避免浮点误差。
+// 嵌入模型时使用 embeddedPrefix，例如 price_ 对应 price_minor 和 price_currency 两列
+type Money struct {
+	Amount   int64  `gorm:"column:minor"`
+	Currency string `gorm:"column:currency;size:3"`
+}
+
+// NewMoney 以最小货币单位创建金额
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney 按货币的小数位数精确解析十进制金额，如 "19.99"，小数位超出货币精度时返回错误
+func ParseMoney(s, currency string) (Money, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	exponent := currencyExponents[currency]
+
+	raw := strings.TrimSpace(s)
+	negative := strings.HasPrefix(raw, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(raw, "-"), "+")
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" || hasPoint && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", ErrInvalidMoney, s, exponent, currency)
+	}
+
+	minor := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if minor == "" {
+		minor = "0"
+	}
+	amount, err := strconv.ParseInt(minor, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String 以十进制表示金额，不含货币代码，例如 "19.99"、"-0.50"、"1500"
+func (m Money) String() string {
+	exponent := currencyExponents[m.Currency]
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absInt64(amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Format 展示用格式，整数部分按千位分组并附加货币代码，例如 "1,234.50 USD"
+func (m Money) Format() string {
+	s := m.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	var grouped strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(r)
+	}
+	if hasPoint {
+		grouped.WriteString("." + fraction)
+	}
+	return sign + grouped.String() + " " + m.Currency
+}
+
+// IsZero 金额是否为 0
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add 相加，币种必须相同
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Multiply 乘以数量
+func (m Money) Multiply(quantity int) (Money, error) {
+	if quantity != 0 && absInt64(m.Amount) > math.MaxInt64/absInt64(int64(quantity)) {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}, nil
+}
+
+// Convert 按汇率（1 单位原币种兑换的目标币种数量）换算为目标币种，
+// 结果按目标币种精度以银行家舍入法（四舍六入五成双）取整
+func (m Money) Convert(rate *big.Rat, currency string) (Money, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	if m.Currency == currency {
+		return m, nil
+	}
+	fromExponent, ok := currencyExponents[m.Currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, m.Currency)
+	}
+
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	scale := currencyExponents[currency] - fromExponent
+	factor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absInt(scale))), nil))
+	if scale >= 0 {
+		value.Mul(value, factor)
+	} else {
+		value.Quo(value, factor)
+	}
+
+	amount := roundHalfEven(value)
+	if !amount.IsInt64() {
+		return Money{}, fmt.Errorf("%w: overflow", ErrInvalidMoney)
+	}
+	return Money{Amount: amount.Int64(), Currency: currency}, nil
+}
+
+// roundHalfEven 将有理数舍入为最接近的整数，恰好为 .5 时取偶数
+func roundHalfEven(value *big.Rat) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	// 比较 2*|余数| 与分母，判断小数部分是否超过一半
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	cmp := twice.Cmp(value.Denom())
+	if cmp > 0 || cmp == 0 && quotient.Bit(0) == 1 {
+		if value.Sign() < 0 {
+			return quotient.Sub(quotient, big.NewInt(1))
+		}
+		return quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// moneyJSON 金额的 JSON 表示：amount 为十进制字符串，minor_units 为最小货币单位，
+// formatted 为展示用格式
+type moneyJSON struct {
+	Amount     string `json:"amount"`
+	Currency   string `json:"currency"`
+	MinorUnits int64  `json:"minor_units"`
+	Formatted  string `json:"formatted"`
+}
+
+// MarshalJSON 实现 json.Marshaler，所有接口中的金额使用相同格式
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:     m.String(),
+		Currency:   m.Currency,
+		MinorUnits: m.Amount,
+		Formatted:  m.Format(),
+	})
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，接受 {"amount": "19.99", "currency": "USD"}、
+// {"minor_units": 1999, "currency": "USD"}，以及兼容旧客户端的数字或字符串（使用基础货币）。
+// 数字按字面值精确解析，不经过 float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) == 0 || data[0] != '{' {
+		parsed, err := ParseMoney(jsonLiteral(data), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var value struct {
+		Amount     json.RawMessage `json:"amount"`
+		Currency   string          `json:"currency"`
+		MinorUnits *int64          `json:"minor_units"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	currency := value.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if len(value.Amount) == 0 {
+		if value.MinorUnits == nil {
+			return fmt.Errorf("%w: amount is required", ErrInvalidMoney)
+		}
+		normalized, err := NormalizeCurrency(currency)
+		if err != nil {
+			return err
+		}
+		*m = Money{Amount: *value.MinorUnits, Currency: normalized}
+		return nil
+	}
+	parsed, err := ParseMoney(jsonLiteral(value.Amount), currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// jsonLiteral 取出 JSON 数字或字符串的字面值
+func jsonLiteral(data []byte) string {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s
+	}
+	return string(data)
+}
+
+func absInt64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	m, err := ParseMoney("19.99", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, Money{Amount: 1999, Currency: "USD"}, m)
+
+	m, err = ParseMoney("1500", "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), m.Amount)
+
+	m, err = ParseMoney("-0.5", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-50), m.Amount)
+
+	for _, raw := range []string{"", ".", "1.", "1.999", "1e3", "abc", "99999999999999999999"} {
+		_, err := ParseMoney(raw, "USD")
+		assert.ErrorIs(t, err, ErrInvalidMoney, raw)
+	}
+	_, err = ParseMoney("1.5", "JPY")
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+	_, err = ParseMoney("1", "XYZ")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestMoney_Format(t *testing.T) {
+	assert.Equal(t, "0.05", NewMoney(5, "USD").String())
+	assert.Equal(t, "-12.30", NewMoney(-1230, "EUR").String())
+	assert.Equal(t, "1,234,567.89 USD", NewMoney(123456789, "USD").Format())
+	assert.Equal(t, "1,500 JPY", NewMoney(1500, "JPY").Format())
+	assert.Equal(t, "1.000 KWD", NewMoney(1000, "KWD").Format())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	sum, err := NewMoney(150, "USD").Add(NewMoney(275, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(425), sum.Amount)
+
+	_, err = NewMoney(1, "USD").Add(NewMoney(1, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	product, err := NewMoney(1999, "USD").Multiply(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5997), product.Amount)
+
+	_, err = NewMoney(1<<62, "USD").Multiply(4)
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+}
+
+func TestMoney_ConvertRoundsHalfEven(t *testing.T) {
+	// 0.125 EUR 与 0.135 EUR 分别舍入为 0.12 和 0.14
+	half := big.NewRat(1, 2)
+	m, err := NewMoney(25, "USD").Convert(half, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), m.Amount)
+	m, err = NewMoney(27, "USD").Convert(half, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), m.Amount)
+
+	// 小数位数不同的币种之间换算
+	m, err = NewMoney(1000, "USD").Convert(big.NewRat(15137, 100), "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1514, "JPY"), m)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(123450, "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1234.50","currency":"USD","minor_units":123450,"formatted":"1,234.50 USD"}`, string(data))
+
+	cases := map[string]Money{
+		`{"amount":"19.99","currency":"eur"}`:  NewMoney(1999, "EUR"),
+		`{"amount":19.99}`:                     NewMoney(1999, DefaultCurrency),
+		`{"minor_units":500,"currency":"JPY"}`: NewMoney(500, "JPY"),
+		`0.1`:                                  NewMoney(10, DefaultCurrency),
+		`"42"`:                                 NewMoney(4200, DefaultCurrency),
+	}
+	for input, expected := range cases {
+		var m Money
+		require.NoError(t, json.Unmarshal([]byte(input), &m), input)
+		assert.Equal(t, expected, m, input)
+	}
+
+	var m Money
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"currency":"USD"}`), &m), ErrInvalidMoney)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"1.234"}`), &m), ErrInvalidMoney)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// 订单状态
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+// orderTransitions 订单状态机允许的状态流转
+var orderTransitions = map[string][]string{
+	OrderStatusPending: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:    {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped: {OrderStatusRefunded},
+}
+
+// Order 订单，所有金额使用下单时选择的币种
+type Order struct {
+	BaseModel
+	UserID      uint        `gorm:"index;not null" json:"user_id"`
+	Status      string      `gorm:"index;size:20;not null;default:pending" json:"status"`
+	Total       Money       `gorm:"embedded;embeddedPrefix:total_" json:"total"`
+	Note        string      `gorm:"size:500" json:"note"`
+	Items       []OrderItem `gorm:"constraint:OnDelete:CASCADE" json:"items"`
+	PaidAt      *time.Time  `json:"paid_at"`
+	ShippedAt   *time.Time  `json:"shipped_at"`
+	CancelledAt *time.Time  `json:"cancelled_at"`
+	RefundedAt  *time.Time  `json:"refunded_at"`
+}
+
+// TableName 指定表名
+func (Order) TableName() string {
+	return "orders"
+}
+
+// OrderItem 订单项，下单时快照产品名称、变体 SKU 和价格
+type OrderItem struct {
+	BaseModel
+	OrderID     uint   `gorm:"index;not null" json:"order_id"`
+	ProductID   uint   `gorm:"index;not null" json:"product_id"`
+	VariantID   *uint  `gorm:"index" json:"variant_id,omitempty"`
+	SKU         string `gorm:"column:sku;size:64" json:"sku,omitempty"`
+	ProductName string `gorm:"size:200;not null" json:"product_name"`
+	UnitPrice   Money  `gorm:"embedded;embeddedPrefix:unit_price_" json:"unit_price"`
+	Quantity    int    `gorm:"not null" json:"quantity"`
+	Subtotal    Money  `gorm:"embedded;embeddedPrefix:subtotal_" json:"subtotal"`
+}
+
+// TableName 指定表名
+func (OrderItem) TableName() string {
+	return "order_items"
+}
+
+// StockReference 订单在库存预留和流水中使用的关联单据号
+func (o *Order) StockReference() string {
+	return fmt.Sprintf("order:%d", o.ID)
+}
+
+// CanTransitionTo 判断订单能否从当前状态流转到 status
+func (o *Order) CanTransitionTo(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidOrderStatus 判断是否为已定义的订单状态
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
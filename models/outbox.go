@@ -0,0 +1,116 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// 领域事件类型
+const (
+	EventUserCreated         = "user.created"
+	EventUserUpdated         = "user.updated"
+	EventUserDeleted         = "user.deleted"
+	EventProductCreated      = "product.created"
+	EventProductUpdated      = "product.updated"
+	EventProductDeleted      = "product.deleted"
+	EventProductPriceChanged = "product.price_changed"
+	EventProductStockChanged = "product.stock_changed"
+)
+
+// EventTypes 所有领域事件类型
+var EventTypes = []string{
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventProductCreated,
+	EventProductUpdated,
+	EventProductDeleted,
+	EventProductPriceChanged,
+	EventProductStockChanged,
+}
+
+// MatchEventType 判断事件类型是否匹配 patterns 中的任意一项，支持 "*" 和 "product.*" 形式的通配；
+// patterns 为空时匹配所有事件
+func MatchEventType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == eventType ||
+			(strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidEventPattern 判断 pattern 是否是已知的事件类型或能匹配到已知事件的通配
+func IsValidEventPattern(pattern string) bool {
+	if pattern != "*" && strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+		return false
+	}
+	for _, eventType := range EventTypes {
+		if MatchEventType([]string{pattern}, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceChange product.price_changed 事件内容。Currency 为基础货币时是产品价格，否则是价目表中的标价；
+// 价目表中新增标价时 OldPrice 为空，删除标价时 Price 为空
+type PriceChange struct {
+	ProductID uint   `json:"product_id"`
+	Currency  string `json:"currency"`
+	OldPrice  *Money `json:"old_price"`
+	Price     *Money `json:"price"`
+}
+
+// 领域事件所属的聚合类型
+const (
+	AggregateUser    = "user"
+	AggregateProduct = "product"
+)
+
+// 事件投递状态
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusDispatched = "dispatched"
+	OutboxStatusFailed     = "failed"
+)
+
+// OutboxEvent 领域事件，与业务数据在同一个事务中写入 outbox 表，提交后由 dispatcher 投递给订阅者。
+// 投递至少一次，订阅者应以 Key 作为幂等键去重
+type OutboxEvent struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Key           string     `gorm:"column:event_key;size:36;not null;uniqueIndex" json:"key"`
+	Type          string     `gorm:"size:100;not null;index" json:"type"`
+	AggregateType string     `gorm:"size:50;not null;index:idx_outbox_events_aggregate,priority:1" json:"aggregate_type"`
+	AggregateID   uint       `gorm:"not null;index:idx_outbox_events_aggregate,priority:2" json:"aggregate_id"`
+	Payload       RawJSON    `gorm:"type:text" json:"payload"`
+	Status        string     `gorm:"size:20;not null;index:idx_outbox_events_poll,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_poll,priority:2" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	LockedUntil   *time.Time `json:"-"`
+	DispatchedAt  *time.Time `json:"dispatched_at,omitempty"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxDelivery 事件已投递给某个订阅者的记录，重试时跳过已成功的订阅者
+type OutboxDelivery struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	EventID    uint      `gorm:"not null;uniqueIndex:idx_outbox_deliveries_event_subscriber,priority:1" json:"event_id"`
+	Subscriber string    `gorm:"size:100;not null;uniqueIndex:idx_outbox_deliveries_event_subscriber,priority:2" json:"subscriber"`
+}
+
+// TableName 指定表名
+func (OutboxDelivery) TableName() string {
+	return "outbox_deliveries"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProductPrice 产品价目表中某一币种的标价（Amount 为最小货币单位），优先于按汇率换算的价格；
+// 基础货币的价格保存在 Product.Price 中，不在价目表中重复
+type ProductPrice struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ProductID uint     `gorm:"uniqueIndex:idx_product_prices_product_currency;not null"`
+	Currency  string   `gorm:"uniqueIndex:idx_product_prices_product_currency;size:3;not null"`
+	Amount    int64    `gorm:"column:amount_minor;not null"`
+	Product   *Product `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (ProductPrice) TableName() string {
+	return "product_prices"
+}
+
+// Price 以金额表示的标价
+func (p *ProductPrice) Price() Money {
+	return Money{Amount: p.Amount, Currency: p.Currency}
+}
+
+// MarshalJSON 以与其他接口相同的金额格式输出标价
+func (p ProductPrice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID        uint      `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		ProductID uint      `json:"product_id"`
+		Currency  string    `json:"currency"`
+		Price     Money     `json:"price"`
+	}{p.ID, p.CreatedAt, p.UpdatedAt, p.ProductID, p.Currency, p.Price()})
+}
@@ -3,15 +3,58 @@ package models
 // Product 产品模型
 type Product struct {
 	BaseModel
-	Name        string  `gorm:"not null;size:200" json:"name" binding:"required"`
-	Description string  `gorm:"type:text" json:"description"`
-	Price       float64 `gorm:"not null;type:decimal(10,2)" json:"price" binding:"required,gt=0"`
-	Stock       int     `gorm:"default:0" json:"stock"`
-	Category    string  `gorm:"size:100" json:"category"`
-	IsAvailable bool    `gorm:"default:true" json:"is_available"`
+	SKU          *string          `gorm:"column:sku;size:64;uniqueIndex" json:"sku" binding:"omitempty,max=64"`
+	Name         string           `gorm:"not null;size:200" json:"name" binding:"required"`
+	Description  string           `gorm:"type:text" json:"description"`
+	Price        Money            `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	LocalPrice   *Money           `gorm:"-" json:"local_price,omitempty"`
+	Stock        int              `gorm:"default:0" json:"stock"`
+	Reserved     int              `gorm:"default:0" json:"reserved"`
+	CategoryID   *uint            `gorm:"index" json:"category_id"`
+	Category     string           `gorm:"size:100" json:"category"`
+	IsAvailable  bool             `gorm:"default:true" json:"is_available"`
+	CategoryRef  *Category        `gorm:"foreignKey:CategoryID;constraint:OnDelete:RESTRICT" json:"-"`
+	VariantTerms string           `gorm:"type:text" json:"-"`
+	Variants     []ProductVariant `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+	Images       []ProductImage   `gorm:"foreignKey:ProductID" json:"images,omitempty"`
 }
 
 // TableName 指定表名
 func (Product) TableName() string {
 	return "products"
 }
+
+// ProductSearchHit 全文搜索命中的产品及其相关度和高亮片段
+type ProductSearchHit struct {
+	Product   Product          `json:"product"`
+	Score     float64          `json:"score"`
+	Highlight ProductHighlight `json:"highlight"`
+}
+
+// ProductHighlight 高亮片段，命中的词用 <mark></mark> 包裹，其余内容已做 HTML 转义
+type ProductHighlight struct {
+	Name    string `json:"name,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// ProductQuerySchema 产品列表可过滤、可排序的字段
+var ProductQuerySchema = QuerySchema{
+	Fields: map[string]QueryField{
+		"id":           {Column: "id", Type: FieldInt, Filterable: true, Sortable: true},
+		"sku":          {Column: "sku", Type: FieldString, Filterable: true, Sortable: true},
+		"name":         {Column: "name", Type: FieldString, Filterable: true, Sortable: true},
+		"price":        {Column: "price_minor", Type: FieldMoney, Filterable: true, Sortable: true},
+		"stock":        {Column: "stock", Type: FieldInt, Filterable: true, Sortable: true},
+		"category":     {Column: "category", Type: FieldString, Filterable: true, Sortable: true},
+		"category_id":  {Column: "category_id", Type: FieldInt, Filterable: true},
+		"is_available": {Column: "is_available", Type: FieldBool, Filterable: true},
+		"created_at":   {Column: "created_at", Type: FieldTime, Filterable: true, Sortable: true},
+		"updated_at":   {Column: "updated_at", Type: FieldTime, Filterable: true, Sortable: true},
+	},
+	DefaultSort: "id",
+}
+
+// AvailableStock 可售库存，即总库存减去已预留数量
+func (p *Product) AvailableStock() int {
+	return p.Stock - p.Reserved
+}
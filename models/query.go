@@ -0,0 +1,302 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidQuery 过滤或排序参数不合法
+var ErrInvalidQuery = errors.New("invalid query")
+
+// 查询参数限制
+const (
+	maxInValues  = 100
+	maxSortField = 5
+)
+
+// FieldType 可过滤字段的值类型，决定参数如何解析及支持哪些操作符
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldFloat
+	FieldBool
+	FieldTime
+	// FieldMoney 以基础货币十进制金额传入（如 19.99），按最小货币单位与列比较
+	FieldMoney
+)
+
+// 过滤操作符
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpIn   = "in"
+	OpNin  = "nin"
+	OpLike = "like"
+)
+
+// fieldOperators 各类型字段支持的操作符
+var fieldOperators = map[FieldType][]string{
+	FieldString: {OpEq, OpNe, OpIn, OpNin, OpLike},
+	FieldInt:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin},
+	FieldFloat:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin},
+	FieldBool:   {OpEq, OpNe},
+	FieldTime:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+	FieldMoney:  {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNin},
+}
+
+// QueryField 白名单中的字段，参数名与数据库列名分开，避免直接拼接用户输入
+type QueryField struct {
+	Column     string
+	Type       FieldType
+	Filterable bool
+	Sortable   bool
+}
+
+// QuerySchema 模型的可过滤、可排序字段白名单
+type QuerySchema struct {
+	Fields map[string]QueryField
+	// DefaultSort 未指定 sort 参数时使用的排序，格式同 sort 参数，如 "-created_at"
+	DefaultSort string
+}
+
+// Filter 解析后的过滤条件
+type Filter struct {
+	Field    string
+	Column   string
+	Operator string
+	Value    interface{}
+}
+
+// SortField 解析后的排序字段
+type SortField struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// ListQuery 列表查询的过滤和排序条件
+type ListQuery struct {
+	Filters []Filter
+	Sorts   []SortField
+}
+
+// filterParamPattern 匹配 field 或 field[op] 形式的参数名
+var filterParamPattern = regexp.MustCompile(`^([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+// ParseListQuery 按白名单将查询参数解析为过滤和排序条件，例如
+// ?price[gte]=10&category[in]=a,b&sort=-price,name，价格按基础货币解析。
+// 不在白名单中的普通参数（如 page）会被忽略，带操作符的未知字段返回错误
+func ParseListQuery(values url.Values, schema QuerySchema) (*ListQuery, error) {
+	q := &ListQuery{}
+
+	// 按参数名排序，保证生成的 SQL 稳定
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vals := values[key]
+		if key == "sort" {
+			continue
+		}
+		m := filterParamPattern.FindStringSubmatch(key)
+		if m == nil {
+			if strings.Contains(key, "[") {
+				return nil, fmt.Errorf("%w: unsupported parameter %q", ErrInvalidQuery, key)
+			}
+			continue
+		}
+		name, op := m[1], m[2]
+		field, ok := schema.Fields[name]
+		if !ok || !field.Filterable {
+			if op != "" {
+				return nil, fmt.Errorf("%w: field %q is not filterable", ErrInvalidQuery, name)
+			}
+			continue
+		}
+		if op == "" {
+			op = OpEq
+		}
+		if !supportsOperator(field.Type, op) {
+			return nil, fmt.Errorf("%w: operator %q is not supported for %q", ErrInvalidQuery, op, name)
+		}
+		for _, raw := range vals {
+			value, err := parseFilterValue(field.Type, op, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s[%s]: %v", ErrInvalidQuery, name, op, err)
+			}
+			q.Filters = append(q.Filters, Filter{Field: name, Column: field.Column, Operator: op, Value: value})
+		}
+	}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = schema.DefaultSort
+	}
+	sorts, err := parseSort(sortParam, schema)
+	if err != nil {
+		return nil, err
+	}
+	q.Sorts = sorts
+
+	return q, nil
+}
+
+// ApplyFilters 将过滤条件追加到查询
+func (q *ListQuery) ApplyFilters(db *gorm.DB) *gorm.DB {
+	if q == nil {
+		return db
+	}
+	for _, f := range q.Filters {
+		db = db.Where(f.expression())
+	}
+	return db
+}
+
+// ApplySort 将排序条件追加到查询，没有排序条件时按 id 升序，保证分页顺序稳定
+func (q *ListQuery) ApplySort(db *gorm.DB) *gorm.DB {
+	if q == nil || len(q.Sorts) == 0 {
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: "id"}})
+	}
+	for _, s := range q.Sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: s.Column}, Desc: s.Desc})
+	}
+	return db
+}
+
+// expression 将过滤条件转换为 GORM 子句，列名来自白名单，值始终作为参数绑定
+func (f Filter) expression() clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: f.Column}
+	switch f.Operator {
+	case OpNe:
+		return clause.Neq{Column: column, Value: f.Value}
+	case OpGt:
+		return clause.Gt{Column: column, Value: f.Value}
+	case OpGte:
+		return clause.Gte{Column: column, Value: f.Value}
+	case OpLt:
+		return clause.Lt{Column: column, Value: f.Value}
+	case OpLte:
+		return clause.Lte{Column: column, Value: f.Value}
+	case OpIn:
+		return clause.IN{Column: column, Values: f.Value.([]interface{})}
+	case OpNin:
+		return clause.Not(clause.IN{Column: column, Values: f.Value.([]interface{})})
+	case OpLike:
+		return clause.Expr{SQL: "? LIKE ? ESCAPE '!'", Vars: []interface{}{column, f.Value}}
+	default:
+		return clause.Eq{Column: column, Value: f.Value}
+	}
+}
+
+// parseSort 解析 "-price,name" 形式的排序参数，并追加 id 作为最后的排序键
+func parseSort(param string, schema QuerySchema) ([]SortField, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var sorts []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+		field, ok := schema.Fields[name]
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("%w: field %q is not sortable", ErrInvalidQuery, name)
+		}
+		if seen[field.Column] {
+			continue
+		}
+		seen[field.Column] = true
+		sorts = append(sorts, SortField{Field: name, Column: field.Column, Desc: desc})
+	}
+	if len(sorts) > maxSortField {
+		return nil, fmt.Errorf("%w: at most %d sort fields are allowed", ErrInvalidQuery, maxSortField)
+	}
+	if len(sorts) > 0 && !seen["id"] {
+		sorts = append(sorts, SortField{Field: "id", Column: "id", Desc: sorts[len(sorts)-1].Desc})
+	}
+	return sorts, nil
+}
+
+// parseFilterValue 按字段类型解析参数值，in/nin 的值以逗号分隔
+func parseFilterValue(fieldType FieldType, op, raw string) (interface{}, error) {
+	if op == OpIn || op == OpNin {
+		parts := strings.Split(raw, ",")
+		if len(parts) > maxInValues {
+			return nil, fmt.Errorf("at most %d values are allowed", maxInValues)
+		}
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			value, err := parseScalar(fieldType, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	if op == OpLike {
+		return "%" + EscapeLike(raw) + "%", nil
+	}
+	return parseScalar(fieldType, raw)
+}
+
+func parseScalar(fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case FieldInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case FieldFloat:
+		return strconv.ParseFloat(raw, 64)
+	case FieldMoney:
+		money, err := ParseMoney(raw, DefaultCurrency)
+		if err != nil {
+			return nil, err
+		}
+		return money.Amount, nil
+	case FieldBool:
+		return strconv.ParseBool(raw)
+	case FieldTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", raw)
+	default:
+		return raw, nil
+	}
+}
+
+func supportsOperator(fieldType FieldType, op string) bool {
+	for _, supported := range fieldOperators[fieldType] {
+		if supported == op {
+			return true
+		}
+	}
+	return false
+}
+
+// EscapeLike 转义 LIKE 通配符，配合 ESCAPE '!' 使用
+func EscapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestParseListQuery(t *testing.T) {
+	values, _ := url.ParseQuery("price[gte]=10&category[in]=a,b&name[like]=50%25&page=2&sort=-price,name")
+	q, err := ParseListQuery(values, ProductQuerySchema)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Filter{
+		{Field: "category", Column: "category", Operator: OpIn, Value: []interface{}{"a", "b"}},
+		{Field: "name", Column: "name", Operator: OpLike, Value: "%50!%%"},
+		{Field: "price", Column: "price_minor", Operator: OpGte, Value: int64(1000)},
+	}, q.Filters)
+	assert.Equal(t, []SortField{
+		{Field: "price", Column: "price_minor", Desc: true},
+		{Field: "name", Column: "name"},
+		{Field: "id", Column: "id"},
+	}, q.Sorts)
+
+	invalid := []string{
+		"password[eq]=x",
+		"price[like]=1",
+		"price[gte]=abc",
+		"is_available=maybe",
+		"sort=description",
+		"price[gte]x=1",
+	}
+	for _, raw := range invalid {
+		values, _ := url.ParseQuery(raw)
+		_, err := ParseListQuery(values, ProductQuerySchema)
+		assert.ErrorIs(t, err, ErrInvalidQuery, raw)
+	}
+}
+
+func TestListQuery_Apply(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&Product{})
+	db.Create(&[]Product{
+		{Name: "a", Price: NewMoney(500, "USD"), Category: "books", IsAvailable: true},
+		{Name: "b", Price: NewMoney(2000, "USD"), Category: "books", IsAvailable: true},
+		{Name: "c", Price: NewMoney(2000, "USD"), Category: "games", IsAvailable: true},
+		{Name: "d", Price: NewMoney(3000, "USD"), Category: "music", IsAvailable: true},
+	})
+
+	values, _ := url.ParseQuery("price[gte]=10&category[in]=books,games&sort=-price,-name")
+	q, err := ParseListQuery(values, ProductQuerySchema)
+	assert.NoError(t, err)
+
+	var products []Product
+	assert.NoError(t, q.ApplySort(q.ApplyFilters(db.Model(&Product{}))).Find(&products).Error)
+	var names []string
+	for _, p := range products {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"c", "b"}, names)
+}
@@ -0,0 +1,69 @@
+package models
+
+import "strings"
+
+// 内置权限
+const (
+	PermissionAll            = "*"
+	PermissionUsersWrite     = "users:write"
+	PermissionProductsWrite  = "products:write"
+	PermissionRolesManage    = "roles:manage"
+	PermissionOrdersManage   = "orders:manage"
+	PermissionJobsManage     = "jobs:manage"
+	PermissionWebhooksManage = "webhooks:manage"
+)
+
+// 内置角色
+const (
+	RoleAdmin = "admin"
+)
+
+// DefaultPermissions 启动时确保存在的权限
+var DefaultPermissions = []string{
+	PermissionAll,
+	PermissionUsersWrite,
+	PermissionProductsWrite,
+	PermissionRolesManage,
+	PermissionOrdersManage,
+	PermissionJobsManage,
+	PermissionWebhooksManage,
+}
+
+// Permission 权限，名称格式为 "资源:操作"，如 products:write
+type Permission struct {
+	BaseModel
+	Name        string `gorm:"uniqueIndex;not null;size:100" json:"name" binding:"required"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// TableName 指定表名
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role 角色
+type Role struct {
+	BaseModel
+	Name        string       `gorm:"uniqueIndex;not null;size:50" json:"name" binding:"required"`
+	Description string       `gorm:"size:255" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+}
+
+// TableName 指定表名
+func (Role) TableName() string {
+	return "roles"
+}
+
+// PermissionGranted 判断已授予的权限中是否包含 required，支持 "*" 和 "资源:*" 通配
+func PermissionGranted(granted []string, required string) bool {
+	resource := required
+	if i := strings.Index(required, ":"); i >= 0 {
+		resource = required[:i]
+	}
+	for _, p := range granted {
+		if p == PermissionAll || p == required || p == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
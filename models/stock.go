@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// 库存变动类型
+const (
+	StockMovementAdjust  = "adjust"
+	StockMovementReserve = "reserve"
+	StockMovementCommit  = "commit"
+	StockMovementRelease = "release"
+)
+
+// 预留状态
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+)
+
+// StockMovement 库存流水，每次库存或预留数量变化都会追加一条记录；
+// 变体的流水中 StockAfter 和 ReservedAfter 为变体自身的数量
+type StockMovement struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+	ProductID     uint      `gorm:"index;not null" json:"product_id"`
+	VariantID     *uint     `gorm:"index" json:"variant_id,omitempty"`
+	Type          string    `gorm:"size:20;not null" json:"type"`
+	Quantity      int       `gorm:"not null" json:"quantity"`
+	StockAfter    int       `gorm:"not null" json:"stock_after"`
+	ReservedAfter int       `gorm:"not null" json:"reserved_after"`
+	Reason        string    `gorm:"size:255" json:"reason"`
+	Reference     string    `gorm:"index;size:100" json:"reference"`
+	ActorID       *uint     `json:"actor_id"`
+}
+
+// TableName 指定表名
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+// StockReservation 库存预留，提交时扣减库存，释放时归还可用库存
+type StockReservation struct {
+	BaseModel
+	ProductID uint   `gorm:"index;not null" json:"product_id"`
+	VariantID *uint  `gorm:"index" json:"variant_id,omitempty"`
+	Quantity  int    `gorm:"not null" json:"quantity"`
+	Reference string `gorm:"index;not null;size:100" json:"reference"`
+	Status    string `gorm:"size:20;not null;default:active" json:"status"`
+}
+
+// TableName 指定表名
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
+
+// StockChange 描述一次库存变动的原因、关联单据和操作人
+type StockChange struct {
+	Reason    string
+	Reference string
+	ActorID   *uint
+}
@@ -9,6 +9,7 @@ type User struct {
 	FullName string `gorm:"size:100" json:"full_name"`
 	Age      int    `gorm:"default:0" json:"age"`
 	IsActive bool   `gorm:"default:true" json:"is_active"`
+	Roles    []Role `gorm:"many2many:user_roles" json:"-"`
 }
 
 // TableName 指定表名
@@ -16,20 +17,39 @@ func (User) TableName() string {
 	return "users"
 }
 
+// UserQuerySchema 用户列表可过滤、可排序的字段，不包含密码
+var UserQuerySchema = QuerySchema{
+	Fields: map[string]QueryField{
+		"id":         {Column: "id", Type: FieldInt, Filterable: true, Sortable: true},
+		"username":   {Column: "username", Type: FieldString, Filterable: true, Sortable: true},
+		"email":      {Column: "email", Type: FieldString, Filterable: true, Sortable: true},
+		"full_name":  {Column: "full_name", Type: FieldString, Filterable: true, Sortable: true},
+		"age":        {Column: "age", Type: FieldInt, Filterable: true, Sortable: true},
+		"is_active":  {Column: "is_active", Type: FieldBool, Filterable: true},
+		"created_at": {Column: "created_at", Type: FieldTime, Filterable: true, Sortable: true},
+	},
+	DefaultSort: "id",
+}
+
 // UserResponse 用户响应结构（不包含密码）
 type UserResponse struct {
-	ID        uint   `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	FullName  string `json:"full_name"`
-	Age       int    `json:"age"`
-	IsActive  bool   `json:"is_active"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID        uint     `json:"id"`
+	Username  string   `json:"username"`
+	Email     string   `json:"email"`
+	FullName  string   `json:"full_name"`
+	Age       int      `json:"age"`
+	IsActive  bool     `json:"is_active"`
+	Roles     []string `json:"roles,omitempty"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
 }
 
 // ToResponse 转换为响应结构
 func (u *User) ToResponse() UserResponse {
+	var roles []string
+	for _, role := range u.Roles {
+		roles = append(roles, role.Name)
+	}
 	return UserResponse{
 		ID:        u.ID,
 		Username:  u.Username,
@@ -37,6 +57,7 @@ func (u *User) ToResponse() UserResponse {
 		FullName:  u.FullName,
 		Age:       u.Age,
 		IsActive:  u.IsActive,
+		Roles:     roles,
 		CreatedAt: u.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt: u.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAttribute 变体属性不符合分类的属性定义
+var ErrInvalidAttribute = errors.New("invalid attribute")
+
+// 属性值类型
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+	AttributeEnum    = "enum"
+)
+
+// attributeCodePattern 属性编码：小写字母开头，只含小写字母、数字和下划线
+var attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// StringList 以 JSON 数组存储的字符串列表
+type StringList []string
+
+// Value 实现 driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+// Scan 实现 sql.Scanner
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+// Attributes 以 JSON 对象存储的属性值，键为属性编码
+type Attributes map[string]interface{}
+
+// Value 实现 driver.Valuer
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]interface{}(a))
+	return string(b), err
+}
+
+// Scan 实现 sql.Scanner
+func (a *Attributes) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+// Key 属性的规范化表示，键按字母排序，用于判断两个变体的属性组合是否相同
+func (a Attributes) Key() string {
+	b, _ := json.Marshal(map[string]interface{}(a))
+	return string(b)
+}
+
+func scanJSON(value interface{}, dest interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", value)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// AttributeDefinition 分类下的属性定义，子分类继承祖先分类的定义
+type AttributeDefinition struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	CategoryID uint       `gorm:"uniqueIndex:idx_attribute_definitions_category_code;not null" json:"category_id"`
+	Code       string     `gorm:"uniqueIndex:idx_attribute_definitions_category_code;size:50;not null" json:"code" binding:"required,max=50"`
+	Name       string     `gorm:"size:100;not null" json:"name" binding:"required,max=100"`
+	Type       string     `gorm:"size:20;not null" json:"type" binding:"required"`
+	Options    StringList `gorm:"type:text" json:"options,omitempty"`
+	Required   bool       `gorm:"default:false" json:"required"`
+	SortOrder  int        `gorm:"default:0" json:"sort_order"`
+	Category   *Category  `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (AttributeDefinition) TableName() string {
+	return "attribute_definitions"
+}
+
+// Validate 校验属性定义本身
+func (d *AttributeDefinition) Validate() error {
+	if !attributeCodePattern.MatchString(d.Code) {
+		return fmt.Errorf("%w: code %q must start with a letter and contain only lowercase letters, digits and underscores", ErrInvalidAttribute, d.Code)
+	}
+	switch d.Type {
+	case AttributeString, AttributeNumber, AttributeBoolean:
+		d.Options = nil
+	case AttributeEnum:
+		if len(d.Options) == 0 {
+			return fmt.Errorf("%w: enum attribute %q requires options", ErrInvalidAttribute, d.Code)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported type %q", ErrInvalidAttribute, d.Type)
+	}
+	return nil
+}
+
+// ValidateValue 校验属性值是否符合定义的类型，数字为 JSON 解码后的 float64
+func (d *AttributeDefinition) ValidateValue(value interface{}) error {
+	ok := false
+	switch d.Type {
+	case AttributeString:
+		_, ok = value.(string)
+	case AttributeNumber:
+		_, ok = value.(float64)
+	case AttributeBoolean:
+		_, ok = value.(bool)
+	case AttributeEnum:
+		if s, isString := value.(string); isString {
+			for _, option := range d.Options {
+				if option == s {
+					ok = true
+					break
+				}
+			}
+		}
+	}
+	if !ok {
+		if d.Type == AttributeEnum {
+			return fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, d.Code, strings.Join(d.Options, ", "))
+		}
+		return fmt.Errorf("%w: %s must be a %s", ErrInvalidAttribute, d.Code, d.Type)
+	}
+	return nil
+}
+
+// ValidateAttributes 按属性定义校验变体属性：不允许未定义的属性，必填属性不能缺失
+func ValidateAttributes(attributes Attributes, definitions []AttributeDefinition) error {
+	defined := make(map[string]*AttributeDefinition, len(definitions))
+	for i := range definitions {
+		defined[definitions[i].Code] = &definitions[i]
+	}
+
+	codes := make([]string, 0, len(attributes))
+	for code := range attributes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		definition, ok := defined[code]
+		if !ok {
+			return fmt.Errorf("%w: %s is not defined for this category", ErrInvalidAttribute, code)
+		}
+		if err := definition.ValidateValue(attributes[code]); err != nil {
+			return err
+		}
+	}
+	for _, definition := range definitions {
+		if _, ok := attributes[definition.Code]; definition.Required && !ok {
+			return fmt.Errorf("%w: %s is required", ErrInvalidAttribute, definition.Code)
+		}
+	}
+	return nil
+}
+
+// ProductVariant 产品变体（如尺码、颜色），有独立的 SKU、价格、库存和条码。
+// 产品有变体时库存按变体管理，产品的 Stock 和 Reserved 为所有变体的合计
+type ProductVariant struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ProductID   uint       `gorm:"index;not null" json:"product_id"`
+	SKU         string     `gorm:"column:sku;uniqueIndex;size:64;not null" json:"sku"`
+	Barcode     string     `gorm:"index;size:64" json:"barcode"`
+	Price       *Money     `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	LocalPrice  *Money     `gorm:"-" json:"local_price,omitempty"`
+	Stock       int        `gorm:"default:0" json:"stock"`
+	Reserved    int        `gorm:"default:0" json:"reserved"`
+	Attributes  Attributes `gorm:"type:text" json:"attributes"`
+	IsAvailable bool       `gorm:"default:true" json:"is_available"`
+	SortOrder   int        `gorm:"default:0" json:"sort_order"`
+}
+
+// TableName 指定表名
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+// AvailableStock 变体的可售库存
+func (v *ProductVariant) AvailableStock() int {
+	return v.Stock - v.Reserved
+}
+
+// EffectivePrice 变体价格，未设置覆盖价格时使用产品价格
+func (v *ProductVariant) EffectivePrice(product *Product) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// SearchTerms 变体中可供全文搜索的内容：SKU、条码和字符串属性值
+func (v *ProductVariant) SearchTerms() []string {
+	terms := []string{v.SKU}
+	if v.Barcode != "" {
+		terms = append(terms, v.Barcode)
+	}
+	codes := make([]string, 0, len(v.Attributes))
+	for code := range v.Attributes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if s, ok := v.Attributes[code].(string); ok && s != "" {
+			terms = append(terms, s)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Webhook 外部系统订阅的回调地址。匹配 Events 的领域事件以签名的 POST 请求投递到 URL，
+// 连续失败次数达到上限时自动停用，需要管理员修复后重新启用
+type Webhook struct {
+	BaseModel
+	URL         string     `gorm:"size:500;not null" json:"url"`
+	Description string     `gorm:"size:255" json:"description"`
+	Events      StringList `gorm:"type:text" json:"events"`
+	// Secret 签名密钥，只在创建时返回
+	Secret              string     `gorm:"size:100;not null" json:"-"`
+	Active              bool       `gorm:"not null;index" json:"active"`
+	ConsecutiveFailures int        `gorm:"not null" json:"consecutive_failures"`
+	LastDeliveryAt      *time.Time `json:"last_delivery_at,omitempty"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `gorm:"size:255" json:"disabled_reason,omitempty"`
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes 判断 webhook 是否订阅了该事件类型
+func (w *Webhook) Subscribes(eventType string) bool {
+	return len(w.Events) > 0 && MatchEventType(w.Events, eventType)
+}
+
+// WebhookDelivery 一次投递尝试的记录。Payload 是发送的请求体，重放时原样重新发送
+type WebhookDelivery struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+	WebhookID    uint      `gorm:"not null;index" json:"webhook_id"`
+	EventKey     string    `gorm:"size:36;not null;index" json:"event_key"`
+	EventType    string    `gorm:"size:100;not null" json:"event_type"`
+	Payload      RawJSON   `gorm:"type:text" json:"payload"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	Success      bool      `gorm:"not null" json:"success"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	ResponseBody string    `gorm:"type:text" json:"response_body,omitempty"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	ReplayOf     *uint     `json:"replay_of,omitempty"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,257 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/jobs"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"gorm.io/gorm"
+)
+
+// Handler 订阅者处理函数。事件至少投递一次，处理函数失败、超时或进程崩溃后会再次收到同一事件，
+// 应以 event.Key 作为幂等键去重
+type Handler func(ctx context.Context, event *models.OutboxEvent) error
+
+// Config dispatcher 配置，零值字段使用默认值
+type Config struct {
+	// PollInterval 没有待投递事件时的轮询间隔，默认 500ms
+	PollInterval time.Duration
+	// BatchSize 每次领取的事件数量，默认 100
+	BatchSize int
+	// Lease 领取事件后独占的时间，也是单个订阅者处理一个事件的超时时间，默认 1m
+	Lease time.Duration
+	// MaxAttempts 投递失败的最大次数，超过后事件进入 failed 状态，默认 10
+	MaxAttempts int
+	// BackoffBase、BackoffMax 失败重试的初始间隔和最大间隔，默认 1s 和 10m
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Retention 已投递事件的保留时间，为 0 时不清理
+	Retention time.Duration
+}
+
+type subscription struct {
+	name     string
+	patterns []string
+	handler  Handler
+}
+
+// matches 判断事件类型是否匹配订阅
+func (s *subscription) matches(eventType string) bool {
+	return models.MatchEventType(s.patterns, eventType)
+}
+
+// Dispatcher 按写入顺序将 outbox 中的领域事件投递给进程内的订阅者。
+// 每个订阅者的投递结果单独记录，重试时只投递给之前失败的订阅者
+type Dispatcher struct {
+	repo *repository.OutboxRepository
+	cfg  Config
+	now  func() time.Time
+
+	subsMu        sync.RWMutex
+	subscriptions []*subscription
+
+	mu            sync.Mutex
+	cancel        context.CancelFunc
+	handlerCancel context.CancelFunc
+	done          chan struct{}
+	started       bool
+}
+
+// NewDispatcher 创建 dispatcher，订阅需要在 Start 之前完成
+func NewDispatcher(db *gorm.DB, cfg Config) *Dispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 500 * time.Millisecond
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = time.Second
+	}
+	if cfg.BackoffMax <= 0 {
+		cfg.BackoffMax = 10 * time.Minute
+	}
+	return &Dispatcher{
+		repo: repository.NewOutboxRepository(db),
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// Subscribe 注册订阅者，name 在所有订阅者中唯一，用于记录投递结果；
+// eventTypes 为空时订阅所有事件
+func (d *Dispatcher) Subscribe(name string, handler Handler, eventTypes ...string) {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+	for _, s := range d.subscriptions {
+		if s.name == name {
+			panic(fmt.Sprintf("outbox: duplicate subscriber %q", name))
+		}
+	}
+	d.subscriptions = append(d.subscriptions, &subscription{name: name, patterns: eventTypes, handler: handler})
+}
+
+// Start 启动后台投递协程
+func (d *Dispatcher) Start(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started {
+		return errors.New("dispatcher already started")
+	}
+	d.started = true
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	handlerCtx, handlerCancel := context.WithCancel(context.Background())
+	d.cancel, d.handlerCancel = cancel, handlerCancel
+	d.done = make(chan struct{})
+	go d.loop(runCtx, handlerCtx)
+	return nil
+}
+
+// Stop 停止领取新事件并等待正在投递的事件处理完成；ctx 到期时取消订阅者的 ctx。
+// 未投递完的事件在租约到期后由其他实例或下次启动时重新投递
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.started {
+		return nil
+	}
+	d.started = false
+	d.cancel()
+	defer d.handlerCancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		d.handlerCancel()
+		<-d.done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) loop(ctx, handlerCtx context.Context) {
+	defer close(d.done)
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	cleanup := time.Time{}
+
+	for {
+		n, err := d.dispatchPending(ctx, handlerCtx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to dispatch outbox events: %v", err)
+		}
+		if d.cfg.Retention > 0 && d.now().Sub(cleanup) > time.Hour {
+			cleanup = d.now()
+			if _, err := d.repo.DeleteDispatched(cleanup.Add(-d.cfg.Retention)); err != nil {
+				log.Printf("Failed to delete dispatched outbox events: %v", err)
+			}
+		}
+		// 领取满一批时可能还有更多事件，立即继续
+		if n == d.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPending 领取一批到期的事件并投递，返回领取的事件数量。
+// 通常由后台协程调用，也可以在测试或命令行工具中直接调用
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	return d.dispatchPending(ctx, ctx)
+}
+
+// dispatchPending ctx 取消后不再投递下一个事件，handlerCtx 是订阅者处理函数的父 ctx
+func (d *Dispatcher) dispatchPending(ctx, handlerCtx context.Context) (int, error) {
+	events, err := d.repo.Claim(d.cfg.BatchSize, d.cfg.Lease, d.now())
+	if err != nil {
+		return 0, err
+	}
+	for i := range events {
+		if err := ctx.Err(); err != nil {
+			// 未处理的事件在租约到期后重新领取
+			return len(events), err
+		}
+		if err := d.dispatch(handlerCtx, &events[i]); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+// dispatch 将事件投递给所有尚未成功的匹配订阅者
+func (d *Dispatcher) dispatch(ctx context.Context, event *models.OutboxEvent) error {
+	delivered, err := d.repo.Delivered(event.ID)
+	if err != nil {
+		return err
+	}
+
+	d.subsMu.RLock()
+	subscriptions := d.subscriptions
+	d.subsMu.RUnlock()
+
+	var failures []string
+	for _, s := range subscriptions {
+		if delivered[s.name] || !s.matches(event.Type) {
+			continue
+		}
+		handlerCtx, cancel := context.WithTimeout(ctx, d.cfg.Lease)
+		err := call(handlerCtx, s, event)
+		cancel()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		if err := d.repo.MarkDelivered(event.ID, s.name); err != nil {
+			return err
+		}
+	}
+
+	now := d.now()
+	if len(failures) == 0 {
+		return d.repo.Complete(event, now)
+	}
+	if err := ctx.Err(); err != nil {
+		// 停止期限已到被中断，不计入失败次数，租约到期后重新投递
+		return err
+	}
+
+	message := strings.Join(failures, "; ")
+	attempt := event.Attempts + 1
+	if attempt >= d.cfg.MaxAttempts {
+		log.Printf("Outbox event %d (%s) failed after %d attempts: %s", event.ID, event.Type, attempt, message)
+		return d.repo.Fail(event, message, time.Time{})
+	}
+	delay := jobs.Backoff(attempt, d.cfg.BackoffBase, d.cfg.BackoffMax)
+	log.Printf("Outbox event %d (%s) delivery failed, retrying in %s: %s", event.ID, event.Type, delay, message)
+	return d.repo.Fail(event, message, now.Add(delay))
+}
+
+// call 执行订阅者的处理函数，将 panic 转换为错误
+func call(ctx context.Context, s *subscription, event *models.OutboxEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Outbox subscriber %s panicked on event %d: %v\n%s", s.name, event.ID, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.handler(ctx, event)
+}
@@ -0,0 +1,191 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.OutboxEvent{}, &models.OutboxDelivery{}))
+	return db
+}
+
+func newTestDispatcher(db *gorm.DB, maxAttempts int) *Dispatcher {
+	return NewDispatcher(db, Config{
+		PollInterval: 10 * time.Millisecond,
+		MaxAttempts:  maxAttempts,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   time.Millisecond,
+	})
+}
+
+func createUser(t *testing.T, db *gorm.DB, name string) *models.User {
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "x"}
+	require.NoError(t, repository.NewUserRepository(db).Create(user))
+	return user
+}
+
+// recorder 记录订阅者收到的事件
+type recorder struct {
+	mu     sync.Mutex
+	events []models.OutboxEvent
+}
+
+func (r *recorder) handle(ctx context.Context, event *models.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *recorder) keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, len(r.events))
+	for i, event := range r.events {
+		keys[i] = event.Key
+	}
+	return keys
+}
+
+// dispatchDue 等待重试时间到达后投递
+func dispatchDue(t *testing.T, d *Dispatcher) {
+	time.Sleep(5 * time.Millisecond)
+	_, err := d.DispatchPending(context.Background())
+	require.NoError(t, err)
+}
+
+func TestDispatcher_DeliversToMatchingSubscribers(t *testing.T) {
+	db := setupTestDB(t)
+	d := newTestDispatcher(db, 0)
+
+	all, users, products := &recorder{}, &recorder{}, &recorder{}
+	d.Subscribe("all", all.handle)
+	d.Subscribe("users", users.handle, "user.*")
+	d.Subscribe("products", products.handle, models.EventProductCreated)
+	assert.Panics(t, func() { d.Subscribe("all", all.handle) })
+
+	user := createUser(t, db, "alice")
+	require.NoError(t, repository.NewUserRepository(db).Delete(user.ID))
+
+	n, err := d.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, all.keys(), 2)
+	assert.Equal(t, all.keys(), users.keys())
+	assert.Empty(t, products.keys())
+	assert.Equal(t, models.EventUserCreated, users.events[0].Type)
+	assert.Equal(t, user.ID, users.events[0].AggregateID)
+
+	var events []models.OutboxEvent
+	require.NoError(t, db.Find(&events).Error)
+	for _, event := range events {
+		assert.Equal(t, models.OutboxStatusDispatched, event.Status)
+		assert.NotNil(t, event.DispatchedAt)
+	}
+
+	// 已投递的事件不再投递
+	n, err = d.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDispatcher_RetriesOnlyFailedSubscribers(t *testing.T) {
+	db := setupTestDB(t)
+	d := newTestDispatcher(db, 0)
+
+	stable, flaky := &recorder{}, &recorder{}
+	calls := 0
+	d.Subscribe("stable", stable.handle)
+	d.Subscribe("flaky", func(ctx context.Context, event *models.OutboxEvent) error {
+		calls++
+		if calls == 1 {
+			return errors.New("temporary failure")
+		}
+		if calls == 2 {
+			panic("boom")
+		}
+		return flaky.handle(ctx, event)
+	})
+	createUser(t, db, "bob")
+
+	dispatchDue(t, d)
+	dispatchDue(t, d)
+	dispatchDue(t, d)
+
+	// 成功的订阅者只收到一次，失败的订阅者重试时收到同一个幂等键
+	assert.Len(t, stable.keys(), 1)
+	assert.Equal(t, stable.keys(), flaky.keys())
+	assert.Equal(t, 3, calls)
+
+	var event models.OutboxEvent
+	require.NoError(t, db.First(&event).Error)
+	assert.Equal(t, models.OutboxStatusDispatched, event.Status)
+	assert.Equal(t, 2, event.Attempts)
+	assert.Equal(t, "flaky: panic: boom", event.LastError)
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	d := newTestDispatcher(db, 2)
+
+	calls := 0
+	d.Subscribe("broken", func(ctx context.Context, event *models.OutboxEvent) error {
+		calls++
+		return fmt.Errorf("attempt %d failed", calls)
+	})
+	createUser(t, db, "carol")
+
+	for i := 0; i < 4; i++ {
+		dispatchDue(t, d)
+	}
+
+	assert.Equal(t, 2, calls)
+	var event models.OutboxEvent
+	require.NoError(t, db.First(&event).Error)
+	assert.Equal(t, models.OutboxStatusFailed, event.Status)
+	assert.Equal(t, 2, event.Attempts)
+	assert.Equal(t, "broken: attempt 2 failed", event.LastError)
+}
+
+func TestDispatcher_StartStop(t *testing.T) {
+	db := setupTestDB(t)
+	d := newTestDispatcher(db, 0)
+	ctx := context.Background()
+
+	received := &recorder{}
+	d.Subscribe("all", received.handle)
+	require.NoError(t, d.Start(ctx))
+	assert.Error(t, d.Start(ctx))
+
+	createUser(t, db, "dave")
+	createUser(t, db, "erin")
+	require.Eventually(t, func() bool {
+		return len(received.keys()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, d.Stop(ctx))
+	require.NoError(t, d.Stop(ctx))
+
+	// 停止后写入的事件保持 pending，重新启动后投递
+	createUser(t, db, "frank")
+	time.Sleep(30 * time.Millisecond)
+	assert.Len(t, received.keys(), 2)
+	var pending int64
+	db.Model(&models.OutboxEvent{}).Where("status = ?", models.OutboxStatusPending).Count(&pending)
+	assert.Equal(t, int64(1), pending)
+}
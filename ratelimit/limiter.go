@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 支持的限流算法
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// casRetries 令牌桶写入冲突时的最大重试次数
+const casRetries = 5
+
+// ErrContention 并发写入冲突次数过多
+var ErrContention = errors.New("ratelimit: too much contention")
+
+// Rule 限流规则：每个 Window 内允许 Limit 个请求，令牌桶允许突发 Burst 个请求
+type Rule struct {
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter 限流器
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Store 限流状态存储，所有操作都必须是原子的
+type Store interface {
+	// Get 读取 key 的值，不存在时 exists 为 false
+	Get(ctx context.Context, key string) (value string, exists bool, err error)
+	// CompareAndSet 当 key 的当前值与 old 一致（exists 为 false 表示 key 不存在）时写入 value
+	CompareAndSet(ctx context.Context, key string, old string, exists bool, value string, ttl time.Duration) (bool, error)
+	// Incr 将计数器加一并返回新值，计数器首次创建时设置过期时间
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// New 根据算法名称创建限流器
+func New(algorithm string, store Store, rule Rule) (Limiter, error) {
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		return nil, fmt.Errorf("ratelimit: invalid rule %+v", rule)
+	}
+	switch algorithm {
+	case AlgorithmTokenBucket:
+		return NewTokenBucket(store, rule), nil
+	case AlgorithmSlidingWindow:
+		return NewSlidingWindow(store, rule), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown algorithm %q", algorithm)
+	}
+}
+
+// TokenBucket 令牌桶限流器，使用 GCRA 实现，每个 key 只需保存一个时间戳
+type TokenBucket struct {
+	store    Store
+	rule     Rule
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewTokenBucket 创建令牌桶限流器，Burst 为 0 时等于 Limit
+func NewTokenBucket(store Store, rule Rule) *TokenBucket {
+	if rule.Burst <= 0 {
+		rule.Burst = rule.Limit
+	}
+	return &TokenBucket{
+		store:    store,
+		rule:     rule,
+		interval: rule.Window / time.Duration(rule.Limit),
+		now:      time.Now,
+	}
+}
+
+// Allow 尝试从桶中取出一个令牌
+func (l *TokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	key = "tb:" + key
+	burstOffset := l.interval * time.Duration(l.rule.Burst)
+
+	for i := 0; i < casRetries; i++ {
+		now := l.now()
+		old, exists, err := l.store.Get(ctx, key)
+		if err != nil {
+			return Result{}, err
+		}
+
+		// tat 为理论到达时间，桶满时等于 now
+		tat := now
+		if exists {
+			nanos, err := strconv.ParseInt(old, 10, 64)
+			if err == nil && nanos > now.UnixNano() {
+				tat = time.Unix(0, nanos)
+			}
+		}
+
+		newTat := tat.Add(l.interval)
+		allowAt := newTat.Add(-burstOffset)
+		if now.Before(allowAt) {
+			return Result{
+				Allowed:    false,
+				Limit:      l.rule.Burst,
+				Remaining:  0,
+				ResetAfter: tat.Sub(now),
+				RetryAfter: allowAt.Sub(now),
+			}, nil
+		}
+
+		ok, err := l.store.CompareAndSet(ctx, key, old, exists, strconv.FormatInt(newTat.UnixNano(), 10), newTat.Sub(now))
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			continue
+		}
+
+		remaining := int(now.Sub(allowAt) / l.interval)
+		return Result{
+			Allowed:    true,
+			Limit:      l.rule.Burst,
+			Remaining:  remaining,
+			ResetAfter: newTat.Sub(now),
+		}, nil
+	}
+	return Result{}, ErrContention
+}
+
+// SlidingWindow 滑动窗口限流器，按前后两个固定窗口的计数加权估算
+type SlidingWindow struct {
+	store Store
+	rule  Rule
+	now   func() time.Time
+}
+
+// NewSlidingWindow 创建滑动窗口限流器
+func NewSlidingWindow(store Store, rule Rule) *SlidingWindow {
+	return &SlidingWindow{store: store, rule: rule, now: time.Now}
+}
+
+// Allow 记录一次请求并判断是否超出限制
+func (l *SlidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	now := l.now()
+	window := l.rule.Window
+	current := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - current*int64(window))
+	resetAfter := window - elapsed
+
+	var previous int64
+	value, exists, err := l.store.Get(ctx, fmt.Sprintf("sw:%s:%d", key, current-1))
+	if err != nil {
+		return Result{}, err
+	}
+	if exists {
+		previous, _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	count, err := l.store.Incr(ctx, fmt.Sprintf("sw:%s:%d", key, current), 2*window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	weight := float64(window-elapsed) / float64(window)
+	estimated := int(float64(previous)*weight) + int(count)
+	remaining := l.rule.Limit - estimated
+	if remaining >= 0 {
+		return Result{
+			Allowed:    true,
+			Limit:      l.rule.Limit,
+			Remaining:  remaining,
+			ResetAfter: resetAfter,
+		}, nil
+	}
+
+	// 超限后需要等待当前窗口结束，或上一窗口的权重衰减到足够低
+	retryAfter := resetAfter
+	if previous > 0 {
+		over := float64(estimated - l.rule.Limit + 1)
+		wait := time.Duration(over / float64(previous) * float64(window))
+		if wait < retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	return Result{
+		Allowed:    false,
+		Limit:      l.rule.Limit,
+		Remaining:  0,
+		ResetAfter: resetAfter,
+		RetryAfter: retryAfter,
+	}, nil
+}
@@ -0,0 +1,277 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis 实现限流所需 Redis 命令子集的本地 RESP 服务
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	versions map[string]int
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	f := &fakeRedis{
+		listener: ln,
+		values:   make(map[string]string),
+		expires:  make(map[string]time.Time),
+		versions: make(map[string]int),
+	}
+	go f.serve()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	watched := map[string]int{}
+	var queued [][]string
+	inMulti := false
+
+	for {
+		reply, err := readReply(rd)
+		if err != nil {
+			return
+		}
+		items := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = item.(string)
+		}
+		cmd := strings.ToUpper(args[0])
+
+		switch {
+		case cmd == "MULTI":
+			inMulti, queued = true, nil
+			io.WriteString(conn, "+OK\r\n")
+		case cmd == "EXEC":
+			f.mu.Lock()
+			conflict := false
+			for key, version := range watched {
+				if f.versions[key] != version {
+					conflict = true
+				}
+			}
+			var out strings.Builder
+			if conflict {
+				out.WriteString("*-1\r\n")
+			} else {
+				fmt.Fprintf(&out, "*%d\r\n", len(queued))
+				for _, q := range queued {
+					out.WriteString(f.exec(q))
+				}
+			}
+			f.mu.Unlock()
+			inMulti, queued, watched = false, nil, map[string]int{}
+			io.WriteString(conn, out.String())
+		case cmd == "DISCARD":
+			if !inMulti {
+				io.WriteString(conn, "-ERR DISCARD without MULTI\r\n")
+				continue
+			}
+			inMulti, queued, watched = false, nil, map[string]int{}
+			io.WriteString(conn, "+OK\r\n")
+		case inMulti:
+			queued = append(queued, args)
+			io.WriteString(conn, "+QUEUED\r\n")
+		case cmd == "WATCH":
+			f.mu.Lock()
+			for _, key := range args[1:] {
+				watched[key] = f.versions[key]
+			}
+			f.mu.Unlock()
+			io.WriteString(conn, "+OK\r\n")
+		case cmd == "UNWATCH":
+			watched = map[string]int{}
+			io.WriteString(conn, "+OK\r\n")
+		default:
+			f.mu.Lock()
+			out := f.exec(args)
+			f.mu.Unlock()
+			io.WriteString(conn, out)
+		}
+	}
+}
+
+// exec 执行单条命令，调用方需持有锁
+func (f *fakeRedis) exec(args []string) string {
+	key := ""
+	if len(args) > 1 {
+		key = args[1]
+		if exp, ok := f.expires[key]; ok && !time.Now().Before(exp) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		value, ok := f.values[key]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		nx := false
+		var ttl time.Duration
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			}
+		}
+		if _, exists := f.values[key]; nx && exists {
+			return "$-1\r\n"
+		}
+		f.values[key] = args[2]
+		f.versions[key]++
+		if ttl > 0 {
+			f.expires[key] = time.Now().Add(ttl)
+		}
+		return "+OK\r\n"
+	case "INCR":
+		n, _ := strconv.ParseInt(f.values[key], 10, 64)
+		n++
+		f.values[key] = strconv.FormatInt(n, 10)
+		f.versions[key]++
+		return fmt.Sprintf(":%d\r\n", n)
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func TestTokenBucket_MemoryStore(t *testing.T) {
+	store := NewMemoryStore(0)
+	limiter := NewTokenBucket(store, Rule{Limit: 3, Window: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "client")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "client")
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= 20*time.Second)
+
+	// 不同 key 互不影响
+	result, _ = limiter.Allow(ctx, "other")
+	assert.True(t, result.Allowed)
+}
+
+func TestSlidingWindow_MemoryStore(t *testing.T) {
+	store := NewMemoryStore(0)
+	limiter := NewSlidingWindow(store, Rule{Limit: 2, Window: time.Minute})
+	now := time.Unix(1700000000, 0).Truncate(time.Minute)
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, _ := limiter.Allow(ctx, "client")
+		assert.True(t, result.Allowed)
+	}
+	result, _ := limiter.Allow(ctx, "client")
+	assert.False(t, result.Allowed)
+
+	// 下一窗口开始不久，上一窗口的计数仍按较高权重计入
+	now = now.Add(70 * time.Second)
+	result, _ = limiter.Allow(ctx, "client")
+	assert.False(t, result.Allowed)
+
+	// 上一窗口权重衰减到 1/4 后放行
+	now = now.Add(35 * time.Second)
+	result, _ = limiter.Allow(ctx, "client")
+	assert.True(t, result.Allowed)
+}
+
+func TestTokenBucket_RedisStore(t *testing.T) {
+	fake := newFakeRedis(t)
+	store := NewRedisStore(RedisOptions{Addr: fake.listener.Addr().String(), PoolSize: 4})
+	defer store.Close()
+	assert.NoError(t, store.Ping(context.Background()))
+
+	limiter := NewTokenBucket(store, Rule{Limit: 5, Window: time.Minute})
+
+	// 并发请求下总放行数不能超过桶容量
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := limiter.Allow(context.Background(), "client")
+			if err == nil && result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, allowed, 5)
+	assert.Greater(t, allowed, 0)
+}
+
+func TestSlidingWindow_RedisStore(t *testing.T) {
+	fake := newFakeRedis(t)
+	store := NewRedisStore(RedisOptions{Addr: fake.listener.Addr().String()})
+	defer store.Close()
+
+	limiter := NewSlidingWindow(store, Rule{Limit: 2, Window: time.Minute})
+	ctx := context.Background()
+
+	r1, err := limiter.Allow(ctx, "client")
+	assert.NoError(t, err)
+	assert.True(t, r1.Allowed)
+	r2, _ := limiter.Allow(ctx, "client")
+	assert.True(t, r2.Allowed)
+	r3, _ := limiter.Allow(ctx, "client")
+	assert.False(t, r3.Allowed)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	found := false
+	for key := range fake.values {
+		if strings.HasPrefix(key, "ratelimit:sw:client:") {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// MemoryStore 进程内存储，适用于单实例部署和测试
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// NewMemoryStore 创建内存存储，并按 cleanupInterval 定期清理过期 key
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		stop:    make(chan struct{}),
+	}
+	if cleanupInterval > 0 {
+		go s.cleanup(cleanupInterval)
+	}
+	return s
+}
+
+// Get 读取 key 的值
+func (s *MemoryStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.load(key, time.Now())
+	return entry.value, ok, nil
+}
+
+// CompareAndSet 比较并写入
+func (s *MemoryStore) CompareAndSet(_ context.Context, key string, old string, exists bool, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.load(key, now)
+	if ok != exists || (ok && entry.value != old) {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Incr 计数器加一
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.load(key, now)
+	if !ok {
+		entry = memoryEntry{value: "0", expiresAt: now.Add(ttl)}
+	}
+	count, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	s.entries[key] = entry
+	return count, nil
+}
+
+// Close 停止后台清理
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// load 读取未过期的条目，调用方需持有锁
+func (s *MemoryStore) load(key string, now time.Time) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for key, entry := range s.entries {
+				if !now.Before(entry.expiresAt) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// errNil Redis 返回的空值（nil bulk string / nil array）
+var errNil = errors.New("redis: nil")
+
+// RedisOptions Redis 连接配置
+type RedisOptions struct {
+	Addr        string
+	Password    string
+	DB          int
+	Prefix      string
+	PoolSize    int
+	DialTimeout time.Duration
+}
+
+// RedisStore 基于 Redis 协议（RESP）的存储，适用于多实例共享限流状态
+type RedisStore struct {
+	opts  RedisOptions
+	conns chan *redisConn
+	mu    sync.Mutex
+	open  int
+}
+
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedisStore 创建 Redis 存储，连接按需建立
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 3 * time.Second
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "ratelimit:"
+	}
+	return &RedisStore{
+		opts:  opts,
+		conns: make(chan *redisConn, opts.PoolSize),
+	}
+}
+
+// Ping 检查 Redis 是否可用
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.with(ctx, func(c *redisConn) error {
+		_, err := c.do("PING")
+		return err
+	})
+}
+
+// Get 读取 key 的值
+func (s *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
+	var value string
+	var exists bool
+	err := s.with(ctx, func(c *redisConn) error {
+		reply, err := c.do("GET", s.opts.Prefix+key)
+		if err == errNil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, exists = reply.(string), true
+		return nil
+	})
+	return value, exists, err
+}
+
+// CompareAndSet 使用 SET NX 或 WATCH/MULTI/EXEC 实现比较并写入
+func (s *RedisStore) CompareAndSet(ctx context.Context, key string, old string, exists bool, value string, ttl time.Duration) (bool, error) {
+	key = s.opts.Prefix + key
+	ttlMillis := strconv.FormatInt(ttlMilliseconds(ttl), 10)
+
+	var ok bool
+	err := s.with(ctx, func(c *redisConn) error {
+		if !exists {
+			_, err := c.do("SET", key, value, "PX", ttlMillis, "NX")
+			if err == errNil {
+				return nil
+			}
+			ok = err == nil
+			return err
+		}
+
+		if _, err := c.do("WATCH", key); err != nil {
+			return err
+		}
+		current, err := c.do("GET", key)
+		if err != nil && err != errNil {
+			return err
+		}
+		if err == errNil || current.(string) != old {
+			_, err := c.do("UNWATCH")
+			return err
+		}
+
+		if _, err := c.do("MULTI"); err != nil {
+			return err
+		}
+		if _, err := c.do("SET", key, value, "PX", ttlMillis); err != nil {
+			return err
+		}
+		_, err = c.do("EXEC")
+		if err == errNil {
+			// 事务期间 key 被修改
+			return nil
+		}
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+// Incr 计数器加一，首次创建时通过 SET NX 设置过期时间
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	key = s.opts.Prefix + key
+	var count int64
+	err := s.with(ctx, func(c *redisConn) error {
+		_, err := c.do("SET", key, "0", "PX", strconv.FormatInt(ttlMilliseconds(ttl), 10), "NX")
+		if err != nil && err != errNil {
+			return err
+		}
+		reply, err := c.do("INCR", key)
+		if err != nil {
+			return err
+		}
+		count = reply.(int64)
+		return nil
+	})
+	return count, err
+}
+
+// Close 关闭所有空闲连接
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case c := <-s.conns:
+			c.conn.Close()
+			s.release()
+		default:
+			return nil
+		}
+	}
+}
+
+// with 从连接池取出连接执行 fn，出错的连接会被丢弃
+func (s *RedisStore) with(ctx context.Context, fn func(c *redisConn) error) error {
+	c, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	} else {
+		c.conn.SetDeadline(time.Now().Add(s.opts.DialTimeout))
+	}
+
+	if err := fn(c); err != nil {
+		var redisErr redisError
+		if errors.As(err, &redisErr) {
+			// 服务端返回的错误不影响连接本身，但可能残留 WATCH/MULTI 状态
+			if _, resetErr := c.do("DISCARD"); resetErr != nil {
+				c.do("UNWATCH")
+			}
+			s.put(c)
+		} else {
+			c.conn.Close()
+			s.release()
+		}
+		return err
+	}
+	s.put(c)
+	return nil
+}
+
+func (s *RedisStore) acquire(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-s.conns:
+		return c, nil
+	default:
+	}
+
+	s.mu.Lock()
+	if s.open >= s.opts.PoolSize {
+		s.mu.Unlock()
+		select {
+		case c := <-s.conns:
+			return c, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.open++
+	s.mu.Unlock()
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		s.release()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *RedisStore) dial(ctx context.Context) (*redisConn, error) {
+	dialer := net.Dialer{Timeout: s.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", s.opts.Addr, err)
+	}
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(s.opts.DialTimeout))
+
+	if s.opts.Password != "" {
+		if _, err := c.do("AUTH", s.opts.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if s.opts.DB != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(s.opts.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *RedisStore) put(c *redisConn) {
+	select {
+	case s.conns <- c:
+	default:
+		c.conn.Close()
+		s.release()
+	}
+}
+
+func (s *RedisStore) release() {
+	s.mu.Lock()
+	s.open--
+	s.mu.Unlock()
+}
+
+// redisError 服务端返回的错误回复
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// do 发送命令并读取一个回复
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(c.rd)
+}
+
+// readReply 解析一个 RESP 回复
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	prefix, body := line[0], line[1:len(line)-2]
+
+	switch prefix {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := readReply(rd)
+			if err != nil && err != errNil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", prefix)
+	}
+}
+
+func ttlMilliseconds(ttl time.Duration) int64 {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	return ms
+}
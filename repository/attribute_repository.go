@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// ErrAttributeCodeTaken 分类下已有相同编码的属性
+var ErrAttributeCodeTaken = errors.New("attribute code already exists in this category")
+
+// ancestorsSQL 用递归 CTE 查询分类自身及全部祖先分类的 ID 和层级，自身层级为 0
+const ancestorsSQL = `WITH RECURSIVE category_path (id, parent_id, depth) AS (
+	SELECT id, parent_id, 0 FROM categories WHERE id = ?
+	UNION ALL
+	SELECT categories.id, categories.parent_id, category_path.depth + 1
+	FROM categories JOIN category_path ON categories.id = category_path.parent_id
+) SELECT id FROM category_path ORDER BY depth`
+
+type AttributeRepository struct {
+	db *gorm.DB
+}
+
+func NewAttributeRepository(db *gorm.DB) *AttributeRepository {
+	return &AttributeRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *AttributeRepository) WithContext(ctx context.Context) *AttributeRepository {
+	return &AttributeRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建属性定义
+func (r *AttributeRepository) Create(definition *models.AttributeDefinition) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := prepareAttribute(tx, definition); err != nil {
+			return err
+		}
+		return tx.Create(definition).Error
+	})
+}
+
+// FindByID 查找分类下的属性定义
+func (r *AttributeRepository) FindByID(categoryID, id uint) (*models.AttributeDefinition, error) {
+	var definition models.AttributeDefinition
+	err := r.db.Where("category_id = ?", categoryID).First(&definition, id).Error
+	return &definition, err
+}
+
+// FindByCategory 查找分类自身定义的属性
+func (r *AttributeRepository) FindByCategory(categoryID uint) ([]models.AttributeDefinition, error) {
+	var definitions []models.AttributeDefinition
+	err := r.db.Where("category_id = ?", categoryID).
+		Order("sort_order").Order("id").
+		Find(&definitions).Error
+	return definitions, err
+}
+
+// FindEffective 查找分类生效的属性定义，包括从祖先分类继承的定义
+func (r *AttributeRepository) FindEffective(categoryID uint) ([]models.AttributeDefinition, error) {
+	return effectiveAttributes(r.db, categoryID)
+}
+
+// Update 更新属性定义；已有变体的属性值不会被重新校验，下次修改变体时按新定义校验
+func (r *AttributeRepository) Update(definition *models.AttributeDefinition) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := prepareAttribute(tx, definition); err != nil {
+			return err
+		}
+		return tx.Save(definition).Error
+	})
+}
+
+// Delete 删除分类下的属性定义
+func (r *AttributeRepository) Delete(categoryID, id uint) error {
+	result := r.db.Where("category_id = ?", categoryID).Delete(&models.AttributeDefinition{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// prepareAttribute 校验属性定义，同一分类下编码不能重复
+func prepareAttribute(tx *gorm.DB, definition *models.AttributeDefinition) error {
+	if err := definition.Validate(); err != nil {
+		return err
+	}
+	if err := tx.First(&models.Category{}, definition.CategoryID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+
+	var count int64
+	err := tx.Model(&models.AttributeDefinition{}).
+		Where("category_id = ? AND code = ? AND id <> ?", definition.CategoryID, definition.Code, definition.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAttributeCodeTaken
+	}
+	return nil
+}
+
+// effectiveAttributes 合并分类及其祖先的属性定义，编码相同时离分类最近的定义生效
+func effectiveAttributes(db *gorm.DB, categoryID uint) ([]models.AttributeDefinition, error) {
+	var categoryIDs []uint
+	if err := db.Raw(ancestorsSQL, categoryID).Scan(&categoryIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+
+	var definitions []models.AttributeDefinition
+	err := db.Where("category_id IN ?", categoryIDs).
+		Order("sort_order").Order("id").
+		Find(&definitions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	depth := make(map[uint]int, len(categoryIDs))
+	for i, id := range categoryIDs {
+		depth[id] = i
+	}
+	nearest := make(map[string]int, len(definitions))
+	for i, definition := range definitions {
+		if j, ok := nearest[definition.Code]; !ok || depth[definition.CategoryID] < depth[definitions[j].CategoryID] {
+			nearest[definition.Code] = i
+		}
+	}
+
+	effective := make([]models.AttributeDefinition, 0, len(nearest))
+	for i, definition := range definitions {
+		if nearest[definition.Code] == i {
+			effective = append(effective, definition)
+		}
+	}
+	return effective, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+type CartRepository struct {
+	db     *gorm.DB
+	orders *OrderRepository
+}
+
+func NewCartRepository(db *gorm.DB) *CartRepository {
+	return &CartRepository{db: db, orders: NewOrderRepository(db)}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *CartRepository) WithContext(ctx context.Context) *CartRepository {
+	db := r.db.WithContext(ctx)
+	return &CartRepository{db: db, orders: r.orders.WithTx(db)}
+}
+
+// FindByID 根据ID查找购物车，并预加载商品对应的产品
+func (r *CartRepository) FindByID(id uint) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.withItems(r.db).First(&cart, id).Error
+	return &cart, err
+}
+
+// FindByUser 查找用户的购物车
+func (r *CartRepository) FindByUser(userID uint) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.withItems(r.db).Where("user_id = ?", userID).First(&cart).Error
+	return &cart, err
+}
+
+// FindByTokenHash 根据匿名购物车令牌摘要查找购物车
+func (r *CartRepository) FindByTokenHash(hash string) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.withItems(r.db).Where("token_hash = ?", hash).First(&cart).Error
+	return &cart, err
+}
+
+// FindOrCreateByUser 查找用户的购物车，不存在时创建
+func (r *CartRepository) FindOrCreateByUser(userID uint) (*models.Cart, error) {
+	var cart models.Cart
+	if err := r.db.Where("user_id = ?", userID).Attrs(models.Cart{UserID: &userID}).FirstOrCreate(&cart).Error; err != nil {
+		return nil, err
+	}
+	return r.FindByID(cart.ID)
+}
+
+// CreateAnonymous 创建匿名购物车
+func (r *CartRepository) CreateAnonymous(tokenHash string) (*models.Cart, error) {
+	cart := &models.Cart{TokenHash: &tokenHash}
+	return cart, r.db.Create(cart).Error
+}
+
+// AddItem 向购物车添加商品，已存在时累加数量。
+// 产品有变体时必须指定变体，否则返回 ErrVariantRequired
+func (r *CartRepository) AddItem(cartID, productID uint, variantID *uint, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var item models.CartItem
+		err := tx.Scopes(cartItemScope(cartID, productID, variantID)).First(&item).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		return setCartItem(tx, cartID, productID, variantID, item.Quantity+quantity)
+	})
+}
+
+// SetItemQuantity 设置购物车商品数量，数量为 0 时移除该商品
+func (r *CartRepository) SetItemQuantity(cartID, productID uint, variantID *uint, quantity int) error {
+	if quantity < 0 {
+		return ErrInvalidQuantity
+	}
+	if quantity == 0 {
+		return r.RemoveItem(cartID, productID, variantID)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return setCartItem(tx, cartID, productID, variantID, quantity)
+	})
+}
+
+// RemoveItem 从购物车移除商品，variantID 为 nil 时移除未指定变体的一项
+func (r *CartRepository) RemoveItem(cartID, productID uint, variantID *uint) error {
+	return r.db.Scopes(cartItemScope(cartID, productID, variantID)).Delete(&models.CartItem{}).Error
+}
+
+// Clear 清空购物车
+func (r *CartRepository) Clear(cartID uint) error {
+	return r.db.Where("cart_id = ?", cartID).Delete(&models.CartItem{}).Error
+}
+
+// MergeAnonymous 将匿名购物车合并到用户购物车并删除匿名购物车。
+// 相同产品（和变体）数量相加，超过可用库存的部分被截断，已下架或已删除的产品和变体被丢弃
+func (r *CartRepository) MergeAnonymous(userID uint, tokenHash string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var anonymous models.Cart
+		err := tx.Preload("Items").Where("token_hash = ? AND user_id IS NULL", tokenHash).First(&anonymous).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var cart models.Cart
+		if err := tx.Preload("Items").Where("user_id = ?", userID).Attrs(models.Cart{UserID: &userID}).FirstOrCreate(&cart).Error; err != nil {
+			return err
+		}
+		existing := make(map[orderItemKey]int, len(cart.Items))
+		for _, item := range cart.Items {
+			existing[cartItemKey(item)] = item.Quantity
+		}
+
+		for _, item := range anonymous.Items {
+			var product models.Product
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					continue
+				}
+				return err
+			}
+			available, isAvailable := product.AvailableStock(), product.IsAvailable
+			if item.VariantID != nil {
+				var variant models.ProductVariant
+				if err := tx.Where("product_id = ?", product.ID).First(&variant, *item.VariantID).Error; err != nil {
+					if err == gorm.ErrRecordNotFound {
+						continue
+					}
+					return err
+				}
+				available, isAvailable = variant.AvailableStock(), isAvailable && variant.IsAvailable
+			}
+			quantity := existing[cartItemKey(item)] + item.Quantity
+			if quantity > available {
+				quantity = available
+			}
+			if !isAvailable || quantity <= 0 {
+				continue
+			}
+			if err := upsertCartItem(tx, cart.ID, item.ProductID, item.VariantID, quantity); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("cart_id = ?", anonymous.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&anonymous).Error
+	})
+}
+
+// Checkout 将购物车转为订单并清空购物车，两者在同一事务中完成
+func (r *CartRepository) Checkout(cartID, userID uint, note string) (*models.Order, error) {
+	order := &models.Order{UserID: userID, Note: note}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var items []models.CartItem
+		if err := tx.Where("cart_id = ?", cartID).Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			order.Items = append(order.Items, models.OrderItem{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity})
+		}
+
+		if err := r.orders.WithTx(tx).Create(order); err != nil {
+			return err
+		}
+		return tx.Where("cart_id = ?", cartID).Delete(&models.CartItem{}).Error
+	})
+	return order, err
+}
+
+// withItems 预加载购物车商品及其产品和变体
+func (r *CartRepository) withItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Items.Product").Preload("Items.Variant")
+}
+
+// cartItemScope 按产品和变体定位购物车商品，variantID 为 nil 时匹配未指定变体的一项
+func cartItemScope(cartID, productID uint, variantID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("cart_id = ? AND product_id = ?", cartID, productID)
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
+}
+
+// cartItemKey 购物车商品与订单项一样按产品和变体区分
+func cartItemKey(item models.CartItem) orderItemKey {
+	key := orderItemKey{productID: item.ProductID}
+	if item.VariantID != nil {
+		key.variantID = *item.VariantID
+	}
+	return key
+}
+
+// setCartItem 校验产品（及变体）可售且库存充足后写入购物车商品数量，
+// 产品有变体时必须指定变体
+func setCartItem(tx *gorm.DB, cartID, productID uint, variantID *uint, quantity int) error {
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return err
+	}
+	if !product.IsAvailable {
+		return ErrProductUnavailable
+	}
+	variant, err := stockTarget(tx, &product, variantID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("variant %d: %w", *variantID, err)
+		}
+		return err
+	}
+	available := product.AvailableStock()
+	if variant != nil {
+		if !variant.IsAvailable {
+			return fmt.Errorf("variant %s: %w", variant.SKU, ErrProductUnavailable)
+		}
+		available = variant.AvailableStock()
+	}
+	if quantity > available {
+		return ErrInsufficientStock
+	}
+	return upsertCartItem(tx, cartID, productID, variantID, quantity)
+}
+
+// upsertCartItem 创建或更新购物车商品
+func upsertCartItem(tx *gorm.DB, cartID, productID uint, variantID *uint, quantity int) error {
+	var item models.CartItem
+	err := tx.Scopes(cartItemScope(cartID, productID, variantID)).First(&item).Error
+	if err == gorm.ErrRecordNotFound {
+		return tx.Create(&models.CartItem{CartID: cartID, ProductID: productID, VariantID: variantID, Quantity: quantity}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&item).Update("quantity", quantity).Error
+}
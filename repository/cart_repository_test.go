@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCartRepository_MergeAndCheckout(t *testing.T) {
+	db, product := setupOrderTestDB(t)
+	db.AutoMigrate(&models.Cart{}, &models.CartItem{})
+	repo := NewCartRepository(db)
+	products := NewProductRepository(db)
+
+	hidden := &models.Product{Name: "Hidden", Price: models.NewMoney(500, "USD"), Stock: 10, IsAvailable: true}
+	assert.NoError(t, products.Create(hidden))
+	db.Model(hidden).Update("is_available", false)
+
+	// 匿名购物车校验上架状态和库存
+	anonymous, err := repo.CreateAnonymous("token-hash")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.AddItem(anonymous.ID, product.ID, nil, 3))
+	assert.ErrorIs(t, repo.AddItem(anonymous.ID, product.ID, nil, 8), ErrInsufficientStock)
+	assert.ErrorIs(t, repo.AddItem(anonymous.ID, hidden.ID, nil, 1), ErrProductUnavailable)
+
+	userCart, err := repo.FindOrCreateByUser(7)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.AddItem(userCart.ID, product.ID, nil, 2))
+
+	// 登录后合并，相同产品数量相加，匿名购物车被删除
+	assert.NoError(t, repo.MergeAnonymous(7, "token-hash"))
+	_, err = repo.FindByTokenHash("token-hash")
+	assert.Error(t, err)
+
+	cart, err := repo.FindByUser(7)
+	assert.NoError(t, err)
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, 5, cart.Items[0].Quantity)
+
+	// 总价按产品当前价格计算
+	product.Price = models.NewMoney(1000, "USD")
+	assert.NoError(t, products.Update(product))
+	cart, _ = repo.FindByUser(7)
+	summary := cart.Summary()
+	assert.Equal(t, models.NewMoney(5000, "USD"), summary.Total)
+	assert.Equal(t, 5, summary.ItemCount)
+
+	order, err := repo.Checkout(cart.ID, 7, "")
+	assert.NoError(t, err)
+	assert.Equal(t, models.NewMoney(5000, "USD"), order.Total)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+
+	cart, _ = repo.FindByUser(7)
+	assert.Empty(t, cart.Items)
+
+	// 空购物车不能结算
+	_, err = repo.Checkout(cart.ID, 7, "")
+	assert.ErrorIs(t, err, ErrEmptyOrder)
+}
+
+func TestCartRepository_Variants(t *testing.T) {
+	db, _ := setupOrderTestDB(t)
+	db.AutoMigrate(&models.Category{}, &models.AttributeDefinition{}, &models.Cart{}, &models.CartItem{})
+	repo := NewCartRepository(db)
+	products := NewProductRepository(db)
+	variants := NewVariantRepository(db)
+
+	shirts := &models.Category{Name: "Shirts"}
+	require.NoError(t, NewCategoryRepository(db).Create(shirts))
+	require.NoError(t, NewAttributeRepository(db).Create(&models.AttributeDefinition{
+		CategoryID: shirts.ID, Code: "size", Name: "Size", Type: models.AttributeEnum, Options: models.StringList{"S", "L"},
+	}))
+	shirt := &models.Product{Name: "T-Shirt", Price: models.NewMoney(2000, "USD"), CategoryID: &shirts.ID, IsAvailable: true}
+	require.NoError(t, products.Create(shirt))
+	price := models.NewMoney(2500, "USD")
+	large := &models.ProductVariant{ProductID: shirt.ID, SKU: "TS-L", Price: &price, Stock: 3, IsAvailable: true,
+		Attributes: models.Attributes{"size": "L"}}
+	require.NoError(t, variants.Create(large))
+	small := &models.ProductVariant{ProductID: shirt.ID, SKU: "TS-S", Stock: 1, IsAvailable: true,
+		Attributes: models.Attributes{"size": "S"}}
+	require.NoError(t, variants.Create(small))
+
+	cart, err := repo.FindOrCreateByUser(7)
+	require.NoError(t, err)
+
+	// 有变体的产品必须指定变体，库存按变体校验
+	assert.ErrorIs(t, repo.AddItem(cart.ID, shirt.ID, nil, 1), ErrVariantRequired)
+	assert.ErrorIs(t, repo.AddItem(cart.ID, shirt.ID, &small.ID, 2), ErrInsufficientStock)
+	missing := uint(999)
+	assert.ErrorIs(t, repo.AddItem(cart.ID, shirt.ID, &missing, 1), gorm.ErrRecordNotFound)
+
+	// 同一产品的不同变体各占一项
+	require.NoError(t, repo.AddItem(cart.ID, shirt.ID, &large.ID, 1))
+	require.NoError(t, repo.AddItem(cart.ID, shirt.ID, &large.ID, 1))
+	require.NoError(t, repo.AddItem(cart.ID, shirt.ID, &small.ID, 1))
+	assert.ErrorIs(t, repo.SetItemQuantity(cart.ID, shirt.ID, &large.ID, 4), ErrInsufficientStock)
+
+	cart, err = repo.FindByUser(7)
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 2)
+	summary := cart.Summary()
+	assert.Equal(t, "TS-L", summary.Items[0].SKU)
+	assert.Equal(t, 2, summary.Items[0].Quantity)
+	assert.Equal(t, models.NewMoney(2500, "USD"), summary.Items[0].UnitPrice)
+	assert.Equal(t, models.NewMoney(2000, "USD"), summary.Items[1].UnitPrice)
+	assert.Equal(t, models.NewMoney(7000, "USD"), summary.Total)
+
+	// 结算时订单项带上变体，预留变体库存
+	order, err := repo.Checkout(cart.ID, 7, "")
+	require.NoError(t, err)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, &large.ID, order.Items[0].VariantID)
+	assert.Equal(t, "TS-L", order.Items[0].SKU)
+	assert.Equal(t, models.NewMoney(7000, "USD"), order.Total)
+
+	found, err := variants.FindByID(shirt.ID, large.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found.Reserved)
+	cart, _ = repo.FindByUser(7)
+	assert.Empty(t, cart.Items)
+
+	// 移除时按变体定位
+	require.NoError(t, repo.AddItem(cart.ID, shirt.ID, &large.ID, 1))
+	require.NoError(t, repo.RemoveItem(cart.ID, shirt.ID, nil))
+	cart, _ = repo.FindByUser(7)
+	assert.Len(t, cart.Items, 1)
+	require.NoError(t, repo.RemoveItem(cart.ID, shirt.ID, &large.ID))
+	cart, _ = repo.FindByUser(7)
+	assert.Empty(t, cart.Items)
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCategoryNotFound 分类或父分类不存在
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategorySlugTaken slug 已被其他分类使用
+	ErrCategorySlugTaken = errors.New("category slug already exists")
+	// ErrInvalidCategorySlug slug 格式不合法
+	ErrInvalidCategorySlug = errors.New("category slug may only contain lowercase letters, digits and hyphens")
+	// ErrCategoryCycle 父分类不能是自身或自身的子孙分类
+	ErrCategoryCycle = errors.New("category cannot be moved under itself or its descendants")
+	// ErrCategoryInUse 分类下仍有子分类或产品
+	ErrCategoryInUse = errors.New("category still has subcategories or products")
+)
+
+// descendantsSQL 用递归 CTE 查询分类自身及全部子孙分类的 ID，三种方言通用（MySQL 需要 8.0+）
+const descendantsSQL = `WITH RECURSIVE category_tree (id) AS (
+	SELECT id FROM categories WHERE id = ?
+	UNION ALL
+	SELECT categories.id FROM categories JOIN category_tree ON categories.parent_id = category_tree.id
+) SELECT id FROM category_tree`
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *CategoryRepository) WithContext(ctx context.Context) *CategoryRepository {
+	return &CategoryRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建分类，未指定 slug 时由名称生成
+func (r *CategoryRepository) Create(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		category.ID = 0
+		if err := prepareCategory(tx, category); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
+}
+
+// FindByID 根据ID查找分类
+func (r *CategoryRepository) FindByID(id uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, id).Error
+	return &category, err
+}
+
+// FindBySlug 根据 slug 查找分类
+func (r *CategoryRepository) FindBySlug(slug string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Where("slug = ?", slug).First(&category).Error
+	return &category, err
+}
+
+// FindByName 根据名称查找分类，用于兼容按分类名称访问的旧接口
+func (r *CategoryRepository) FindByName(name string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Where("name = ?", name).Order("id").First(&category).Error
+	return &category, err
+}
+
+// FindAll 按排序值和名称返回所有分类
+func (r *CategoryRepository) FindAll() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("sort_order").Order("name").Order("id").Find(&categories).Error
+	return categories, err
+}
+
+// FindTree 返回分类树；rootID 非 nil 时只返回以该分类为根的子树
+func (r *CategoryRepository) FindTree(rootID *uint) ([]*models.Category, error) {
+	query := r.db.Order("sort_order").Order("name").Order("id")
+	if rootID != nil {
+		ids, err := r.DescendantIDs(*rootID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, gorm.ErrRecordNotFound
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	var categories []models.Category
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return models.BuildCategoryTree(categories), nil
+}
+
+// DescendantIDs 返回分类自身及全部子孙分类的 ID，分类不存在时返回空列表
+func (r *CategoryRepository) DescendantIDs(id uint) ([]uint, error) {
+	return descendantIDs(r.db, id)
+}
+
+// Update 更新分类，并同步产品上冗余的分类名称
+func (r *CategoryRepository) Update(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := prepareCategory(tx, category); err != nil {
+			return err
+		}
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Product{}).
+			Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error
+	})
+}
+
+// Delete 删除分类，仍有子分类或未删除的产品时返回 ErrCategoryInUse；
+// 已软删除的产品会解除与该分类的关联
+func (r *CategoryRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var children, products int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+			return err
+		}
+		if children > 0 || products > 0 {
+			return ErrCategoryInUse
+		}
+
+		err := tx.Unscoped().Model(&models.Product{}).Where("category_id = ?", id).
+			Update("category_id", nil).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// prepareCategory 校验父分类和 slug，未指定 slug 时生成唯一的 slug
+func prepareCategory(tx *gorm.DB, category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+
+	if category.ParentID != nil {
+		var parent models.Category
+		if err := tx.First(&parent, *category.ParentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("%w: parent %d", ErrCategoryNotFound, *category.ParentID)
+			}
+			return err
+		}
+		if category.ID != 0 {
+			ids, err := descendantIDs(tx, category.ID)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if id == parent.ID {
+					return ErrCategoryCycle
+				}
+			}
+		}
+	}
+
+	if category.Slug == "" {
+		slug, err := uniqueSlug(tx, models.Slugify(category.Name))
+		if err != nil {
+			return err
+		}
+		category.Slug = slug
+		return nil
+	}
+
+	if !models.IsValidSlug(category.Slug) {
+		return ErrInvalidCategorySlug
+	}
+	var count int64
+	err := tx.Model(&models.Category{}).Where("slug = ? AND id <> ?", category.Slug, category.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategorySlugTaken
+	}
+	return nil
+}
+
+// uniqueSlug 在 base 已被占用时追加数字后缀
+func uniqueSlug(tx *gorm.DB, base string) (string, error) {
+	if base == "" {
+		base = "category"
+	}
+	for i := 1; ; i++ {
+		slug := base
+		if i > 1 {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		var count int64
+		if err := tx.Model(&models.Category{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+	}
+}
+
+func descendantIDs(db *gorm.DB, id uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(descendantsSQL, id).Scan(&ids).Error
+	return ids, err
+}
+
+// syncProductCategory 同步产品的分类：指定 CategoryID 时以分类名称覆盖 Category；
+// 只提供分类名称时按名称或 slug 查找分类，不存在则创建为顶级分类，兼容自由文本分类的旧客户端
+func syncProductCategory(tx *gorm.DB, product *models.Product) error {
+	if product.CategoryID != nil {
+		var category models.Category
+		if err := tx.First(&category, *product.CategoryID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		product.Category = category.Name
+		return nil
+	}
+
+	name := strings.TrimSpace(product.Category)
+	if name == "" {
+		product.Category = ""
+		return nil
+	}
+
+	var category models.Category
+	err := tx.Where("name = ?", name).Order("id").First(&category).Error
+	if err == gorm.ErrRecordNotFound && models.Slugify(name) != "" {
+		err = tx.Where("slug = ?", models.Slugify(name)).First(&category).Error
+	}
+	if err == gorm.ErrRecordNotFound {
+		category = models.Category{Name: name}
+		if err = prepareCategory(tx, &category); err == nil {
+			err = tx.Create(&category).Error
+		}
+	}
+	if err != nil {
+		return err
+	}
+	product.CategoryID = &category.ID
+	product.Category = category.Name
+	return nil
+}
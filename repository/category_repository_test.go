@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryRepository_Tree(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Category{}, &models.Product{}, &models.StockMovement{})
+	repo := NewCategoryRepository(db)
+
+	electronics := &models.Category{Name: "Electronics"}
+	require.NoError(t, repo.Create(electronics))
+	assert.Equal(t, "electronics", electronics.Slug)
+
+	phones := &models.Category{Name: "Phones & Tablets", ParentID: &electronics.ID, SortOrder: 2}
+	require.NoError(t, repo.Create(phones))
+	assert.Equal(t, "phones-tablets", phones.Slug)
+	audio := &models.Category{Name: "Audio", ParentID: &electronics.ID, SortOrder: 1}
+	require.NoError(t, repo.Create(audio))
+	headphones := &models.Category{Name: "Headphones", ParentID: &audio.ID}
+	require.NoError(t, repo.Create(headphones))
+
+	assert.ErrorIs(t, repo.Create(&models.Category{Name: "Other", Slug: "audio"}), ErrCategorySlugTaken)
+	assert.ErrorIs(t, repo.Create(&models.Category{Name: "Other", Slug: "Not Valid"}), ErrInvalidCategorySlug)
+
+	tree, err := repo.FindTree(nil)
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "Audio", tree[0].Children[0].Name)
+	assert.Equal(t, "Headphones", tree[0].Children[0].Children[0].Name)
+
+	ids, err := repo.DescendantIDs(audio.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{audio.ID, headphones.ID}, ids)
+
+	// 不能移动到自身的子孙分类下
+	audio.ParentID = &headphones.ID
+	assert.ErrorIs(t, repo.Update(audio), ErrCategoryCycle)
+}
+
+func TestCategoryRepository_ProductSync(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Category{}, &models.Product{}, &models.StockMovement{})
+	repo := NewCategoryRepository(db)
+	products := NewProductRepository(db)
+
+	// 只提供分类名称时自动关联或创建分类
+	first := &models.Product{Name: "Guitar", Price: models.NewMoney(10000, "USD"), Category: "Music Gear"}
+	require.NoError(t, products.Create(first))
+	require.NotNil(t, first.CategoryID)
+	second := &models.Product{Name: "Drum", Price: models.NewMoney(20000, "USD"), Category: "music gear"}
+	require.NoError(t, products.Create(second))
+	assert.Equal(t, *first.CategoryID, *second.CategoryID)
+	assert.Equal(t, "Music Gear", second.Category)
+
+	missing := uint(999)
+	assert.ErrorIs(t, products.Create(&models.Product{Name: "x", Price: models.NewMoney(100, "USD"), CategoryID: &missing}), ErrCategoryNotFound)
+
+	// 重命名同步到产品
+	category, err := repo.FindByID(*first.CategoryID)
+	require.NoError(t, err)
+	category.Name = "Instruments"
+	require.NoError(t, repo.Update(category))
+	found, _ := products.FindByID(first.ID)
+	assert.Equal(t, "Instruments", found.Category)
+
+	var pagination models.Pagination
+	list, err := products.FindByCategory([]uint{category.ID}, nil, &pagination)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	// 仍有产品时不能删除，产品软删除后可以删除
+	assert.ErrorIs(t, repo.Delete(category.ID), ErrCategoryInUse)
+	require.NoError(t, products.Delete(first.ID))
+	require.NoError(t, products.Delete(second.ID))
+	require.NoError(t, repo.Delete(category.ID))
+}
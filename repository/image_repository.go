@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// MaxImagesPerProduct 每个产品最多的图片数量
+const MaxImagesPerProduct = 20
+
+var (
+	// ErrTooManyImages 产品图片数量已达上限
+	ErrTooManyImages = errors.New("product has too many images")
+	// ErrInvalidImageOrder 排序列表必须恰好包含产品的所有图片
+	ErrInvalidImageOrder = errors.New("image order must list every image of the product exactly once")
+)
+
+type ImageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) *ImageRepository {
+	return &ImageRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *ImageRepository) WithContext(ctx context.Context) *ImageRepository {
+	return &ImageRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 保存图片元数据，排在产品现有图片之后；产品的第一张图片自动成为主图
+func (r *ImageRepository) Create(image *models.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProduct(tx, image.ProductID); err != nil {
+			return err
+		}
+
+		var stats struct {
+			Count    int64
+			MaxOrder *int
+		}
+		err := tx.Model(&models.ProductImage{}).Select("COUNT(*) AS count, MAX(sort_order) AS max_order").
+			Where("product_id = ?", image.ProductID).Scan(&stats).Error
+		if err != nil {
+			return err
+		}
+		if stats.Count >= MaxImagesPerProduct {
+			return ErrTooManyImages
+		}
+
+		image.ID = 0
+		image.SortOrder = 0
+		if stats.MaxOrder != nil {
+			image.SortOrder = *stats.MaxOrder + 1
+		}
+		if stats.Count == 0 {
+			image.IsPrimary = true
+		}
+		if image.IsPrimary {
+			if err := clearPrimary(tx, image.ProductID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(image).Error
+	})
+}
+
+// FindByProduct 查找产品的所有图片，按排序顺序返回
+func (r *ImageRepository) FindByProduct(productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.Where("product_id = ?", productID).Order("sort_order").Order("id").Find(&images).Error
+	return images, err
+}
+
+// FindByID 查找属于产品的图片
+func (r *ImageRepository) FindByID(productID, id uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	err := r.db.Where("product_id = ?", productID).First(&image, id).Error
+	return &image, err
+}
+
+// Update 更新图片的替代文本；IsPrimary 为 true 时设为主图，产品的其他图片取消主图
+func (r *ImageRepository) Update(image *models.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if image.IsPrimary {
+			if err := clearPrimary(tx, image.ProductID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(image).Select("alt_text", "is_primary").Updates(image).Error
+	})
+}
+
+// Reorder 按 ids 的顺序重新排列产品的图片
+func (r *ImageRepository) Reorder(productID uint, ids []uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+		var existing []uint
+		if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(ids) != len(existing) {
+			return ErrInvalidImageOrder
+		}
+		owned := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			owned[id] = true
+		}
+		for i, id := range ids {
+			if !owned[id] {
+				return ErrInvalidImageOrder
+			}
+			delete(owned, id)
+			if err := tx.Model(&models.ProductImage{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("product_id = ?", productID).Order("sort_order").Order("id").Find(&images).Error
+	})
+	return images, err
+}
+
+// Delete 删除图片元数据并返回被删除的图片，由调用方删除存储中的文件；
+// 删除主图时排在最前的图片成为新的主图
+func (r *ImageRepository) Delete(productID, id uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", productID).First(&image, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+
+		var next models.ProductImage
+		err := tx.Where("product_id = ?", productID).Order("sort_order").Order("id").First(&next).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+	return &image, err
+}
+
+// clearPrimary 取消产品所有图片的主图标记
+func clearPrimary(tx *gorm.DB, productID uint) error {
+	return tx.Model(&models.ProductImage{}).
+		Where("product_id = ? AND is_primary = ?", productID, true).
+		Update("is_primary", false).Error
+}
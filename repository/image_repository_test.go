@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageRepository_PrimaryOrderAndDelete(t *testing.T) {
+	db, product := setupOrderTestDB(t)
+	db.AutoMigrate(&models.ProductImage{})
+	repo := NewImageRepository(db)
+
+	newImage := func(key string, primary bool) *models.ProductImage {
+		image := &models.ProductImage{ProductID: product.ID, Key: key, URL: "/uploads/" + key, ContentType: "image/png", Size: 1, IsPrimary: primary,
+			Thumbnails: models.ImageThumbnails{"small": {Key: key + "_small", Width: 1, Height: 1}}}
+		require.NoError(t, repo.Create(image))
+		return image
+	}
+
+	// 第一张图片自动成为主图，后续图片排在最后
+	first := newImage("a.png", false)
+	second := newImage("b.png", false)
+	third := newImage("c.png", true)
+	assert.True(t, first.IsPrimary)
+	assert.Equal(t, []int{0, 1, 2}, []int{first.SortOrder, second.SortOrder, third.SortOrder})
+
+	images, err := repo.FindByProduct(product.ID)
+	require.NoError(t, err)
+	primaries := 0
+	for _, image := range images {
+		if image.IsPrimary {
+			primaries++
+			assert.Equal(t, third.ID, image.ID)
+		}
+	}
+	assert.Equal(t, 1, primaries)
+	assert.Equal(t, "b.png_small", images[1].Thumbnails["small"].Key)
+
+	// 设置主图时取消原主图
+	second.IsPrimary = true
+	second.AltText = "side view"
+	require.NoError(t, repo.Update(second))
+	reloaded, _ := repo.FindByID(product.ID, third.ID)
+	assert.False(t, reloaded.IsPrimary)
+
+	// 排序必须恰好包含所有图片
+	_, err = repo.Reorder(product.ID, []uint{third.ID, first.ID})
+	assert.ErrorIs(t, err, ErrInvalidImageOrder)
+	_, err = repo.Reorder(product.ID, []uint{third.ID, first.ID, first.ID})
+	assert.ErrorIs(t, err, ErrInvalidImageOrder)
+	images, err = repo.Reorder(product.ID, []uint{third.ID, second.ID, first.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{third.ID, second.ID, first.ID}, []uint{images[0].ID, images[1].ID, images[2].ID})
+
+	// 删除主图后排在最前的图片成为主图
+	deleted, err := repo.Delete(product.ID, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.png", "b.png_small"}, deleted.Keys())
+	reloaded, _ = repo.FindByID(product.ID, third.ID)
+	assert.True(t, reloaded.IsPrimary)
+
+	_, err = repo.Delete(product.ID+1, first.ID)
+	assert.Error(t, err)
+	_, err = repo.FindByID(product.ID+1, first.ID)
+	assert.Error(t, err)
+}
+
+func TestImageRepository_Limit(t *testing.T) {
+	db, product := setupOrderTestDB(t)
+	db.AutoMigrate(&models.ProductImage{})
+	repo := NewImageRepository(db)
+
+	for i := 0; i < MaxImagesPerProduct; i++ {
+		require.NoError(t, repo.Create(&models.ProductImage{ProductID: product.ID, Key: "k", URL: "u", ContentType: "image/png"}))
+	}
+	err := repo.Create(&models.ProductImage{ProductID: product.ID, Key: "k", URL: "u", ContentType: "image/png"})
+	assert.ErrorIs(t, err, ErrTooManyImages)
+}
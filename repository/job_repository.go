@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrJobNotRetryable 只有 dead 或 cancelled 的任务可以重试
+	ErrJobNotRetryable = errors.New("only dead or cancelled jobs can be retried")
+	// ErrJobNotCancellable 只有 pending 的任务可以取消
+	ErrJobNotCancellable = errors.New("only pending jobs can be cancelled")
+	// ErrJobLockLost 任务已不属于当前 worker（超时后被重新排队或被取消）
+	ErrJobLockLost = errors.New("job is no longer locked by this worker")
+)
+
+// JobFilter 任务列表过滤条件，空字段不过滤
+type JobFilter struct {
+	Queue  string
+	Type   string
+	Status string
+}
+
+// JobRepository 后台任务队列。任务的领取使用条件更新而不是 SELECT ... FOR UPDATE SKIP LOCKED，
+// 在 SQLite、MySQL 和 PostgreSQL 上行为一致。时间统一使用 UTC，SQLite 按文本比较时间也能得到正确顺序
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// WithTx 返回在指定事务中执行的仓库，用于让任务和业务数据一起提交
+func (r *JobRepository) WithTx(tx *gorm.DB) *JobRepository {
+	return &JobRepository{db: tx}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *JobRepository) WithContext(ctx context.Context) *JobRepository {
+	return &JobRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建任务
+func (r *JobRepository) Create(job *models.Job) error {
+	if job.Status == "" {
+		job.Status = models.JobStatusPending
+	}
+	job.RunAt = job.RunAt.UTC()
+	return r.db.Create(job).Error
+}
+
+// FindByID 根据ID查找任务
+func (r *JobRepository) FindByID(id uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.First(&job, id).Error
+	return &job, err
+}
+
+// FindAll 查找任务（分页），按ID倒序
+func (r *JobRepository) FindAll(filter JobFilter, pagination *models.Pagination) ([]models.Job, error) {
+	var jobs []models.Job
+
+	query := r.db.Model(&models.Job{})
+	if filter.Queue != "" {
+		query = query.Where("queue = ?", filter.Queue)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	// 获取总数
+	query.Count(&pagination.Total)
+
+	// 分页查询
+	offset := pagination.GetOffset()
+	limit := pagination.GetLimit()
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+
+	return jobs, err
+}
+
+// Stats 按队列和状态统计任务数量
+func (r *JobRepository) Stats() ([]models.JobStats, error) {
+	var stats []models.JobStats
+	err := r.db.Model(&models.Job{}).
+		Select("queue, status, COUNT(*) AS count").
+		Group("queue, status").
+		Order("queue, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// Claim 领取队列中最多 limit 个已到执行时间的任务，将其标记为 running 并增加尝试次数。
+// 多个 worker 同时领取同一任务时只有一个能成功
+func (r *JobRepository) Claim(queue, workerID string, limit int, now time.Time) ([]models.Job, error) {
+	now = now.UTC()
+	var candidates []models.Job
+	err := r.db.Where("queue = ? AND status = ? AND run_at <= ?", queue, models.JobStatusPending, now).
+		Order("run_at, id").
+		Limit(limit).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := candidates[:0]
+	for _, job := range candidates {
+		result := r.db.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":    models.JobStatusRunning,
+				"attempts":  gorm.Expr("attempts + 1"),
+				"locked_by": workerID,
+				"locked_at": now,
+			})
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedBy = workerID
+		job.LockedAt = &now
+		claimed = append(claimed, job)
+	}
+	return claimed, nil
+}
+
+// Complete 将当前 worker 持有的任务标记为成功
+func (r *JobRepository) Complete(job *models.Job, now time.Time) error {
+	return r.finish(job, map[string]interface{}{
+		"status":      models.JobStatusSucceeded,
+		"finished_at": now.UTC(),
+	})
+}
+
+// Reschedule 任务执行失败，记录错误并在 runAt 重新排队
+func (r *JobRepository) Reschedule(job *models.Job, message string, runAt time.Time) error {
+	return r.finish(job, map[string]interface{}{
+		"status":     models.JobStatusPending,
+		"last_error": message,
+		"run_at":     runAt.UTC(),
+	})
+}
+
+// Bury 任务执行失败且不再重试，进入死信状态
+func (r *JobRepository) Bury(job *models.Job, message string, now time.Time) error {
+	return r.finish(job, map[string]interface{}{
+		"status":      models.JobStatusDead,
+		"last_error":  message,
+		"finished_at": now.UTC(),
+	})
+}
+
+// finish 释放当前 worker 对任务的锁并更新状态
+func (r *JobRepository) finish(job *models.Job, updates map[string]interface{}) error {
+	updates["locked_by"] = ""
+	updates["locked_at"] = nil
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.JobStatusRunning, job.LockedBy).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLockLost
+	}
+	return nil
+}
+
+// RequeueStale 将 lockedBefore 之前领取、仍处于 running 的任务视为 worker 已丢失：
+// 尝试次数用完的进入死信状态，其余立即重新排队。返回处理的任务数量
+func (r *JobRepository) RequeueStale(lockedBefore, now time.Time) (int64, error) {
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&models.Job{}).Where("status = ? AND locked_at < ?", models.JobStatusRunning, lockedBefore.UTC())
+		message := "job timed out or its worker stopped responding"
+
+		result := stale.Session(&gorm.Session{}).Where("attempts >= max_attempts").Updates(map[string]interface{}{
+			"status":      models.JobStatusDead,
+			"last_error":  message,
+			"finished_at": now.UTC(),
+			"locked_by":   "",
+			"locked_at":   nil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected += result.RowsAffected
+
+		result = stale.Session(&gorm.Session{}).Updates(map[string]interface{}{
+			"status":     models.JobStatusPending,
+			"last_error": message,
+			"run_at":     now.UTC(),
+			"locked_by":  "",
+			"locked_at":  nil,
+		})
+		affected += result.RowsAffected
+		return result.Error
+	})
+	return affected, err
+}
+
+// Retry 将 dead 或 cancelled 的任务重置为立即执行，尝试次数清零
+func (r *JobRepository) Retry(id uint, now time.Time) (*models.Job, error) {
+	return r.transition(id, []string{models.JobStatusDead, models.JobStatusCancelled}, ErrJobNotRetryable, map[string]interface{}{
+		"status":      models.JobStatusPending,
+		"attempts":    0,
+		"run_at":      now.UTC(),
+		"finished_at": nil,
+	})
+}
+
+// Cancel 取消尚未开始执行的任务（包括计划在将来执行的任务）
+func (r *JobRepository) Cancel(id uint, now time.Time) (*models.Job, error) {
+	return r.transition(id, []string{models.JobStatusPending}, ErrJobNotCancellable, map[string]interface{}{
+		"status":      models.JobStatusCancelled,
+		"finished_at": now.UTC(),
+	})
+}
+
+// transition 在任务处于 from 中的某个状态时更新任务，否则返回 invalid
+func (r *JobRepository) transition(id uint, from []string, invalid error, updates map[string]interface{}) (*models.Job, error) {
+	var job models.Job
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Job{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.First(&job, id).Error; err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return invalid
+		}
+		return nil
+	})
+	return &job, err
+}
+
+// DeleteFinished 删除 before 之前已完成（succeeded 或 cancelled）的任务，死信任务保留以便排查
+func (r *JobRepository) DeleteFinished(before time.Time) (int64, error) {
+	result := r.db.
+		Where("status IN ? AND finished_at < ?", []string{models.JobStatusSucceeded, models.JobStatusCancelled}, before.UTC()).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupJobTestRepo(t *testing.T) *JobRepository {
+	db := setupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Job{}))
+	return NewJobRepository(db)
+}
+
+func newTestJob(t *testing.T, repo *JobRepository, queue string, runAt time.Time) *models.Job {
+	job := &models.Job{Queue: queue, Type: "test", Payload: models.RawJSON(`{"n":1}`), RunAt: runAt, MaxAttempts: 2}
+	require.NoError(t, repo.Create(job))
+	return job
+}
+
+func TestJobRepository_ClaimOnlyDueJobsOnce(t *testing.T) {
+	repo := setupJobTestRepo(t)
+	now := time.Now()
+	first := newTestJob(t, repo, "default", now.Add(-time.Minute))
+	second := newTestJob(t, repo, "default", now.Add(-time.Second))
+	newTestJob(t, repo, "default", now.Add(time.Hour)) // 计划在将来执行
+	newTestJob(t, repo, "other", now.Add(-time.Minute))
+
+	claimed, err := repo.Claim("default", "w1", 10, now)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, first.ID, claimed[0].ID)
+	assert.Equal(t, second.ID, claimed[1].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "w1", claimed[0].LockedBy)
+	assert.JSONEq(t, `{"n":1}`, string(claimed[0].Payload))
+
+	// 已领取的任务不会被其他 worker 再次领取
+	claimed, err = repo.Claim("default", "w2", 10, now)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// limit 限制单次领取数量
+	claimed, err = repo.Claim("default", "w2", 1, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1)
+}
+
+func TestJobRepository_FinishRequiresLock(t *testing.T) {
+	repo := setupJobTestRepo(t)
+	now := time.Now()
+	newTestJob(t, repo, "default", now)
+
+	claimed, _ := repo.Claim("default", "w1", 1, now)
+	require.Len(t, claimed, 1)
+	job := claimed[0]
+
+	require.NoError(t, repo.Reschedule(&job, "boom", now.Add(time.Minute)))
+	reloaded, _ := repo.FindByID(job.ID)
+	assert.Equal(t, models.JobStatusPending, reloaded.Status)
+	assert.Equal(t, "boom", reloaded.LastError)
+	assert.Empty(t, reloaded.LockedBy)
+	assert.Nil(t, reloaded.LockedAt)
+
+	// 锁已释放，再次完成会失败
+	assert.ErrorIs(t, repo.Complete(&job, now), ErrJobLockLost)
+
+	claimed, _ = repo.Claim("default", "w1", 1, now.Add(time.Minute))
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 2, claimed[0].Attempts)
+	require.NoError(t, repo.Bury(&claimed[0], "boom again", now))
+	reloaded, _ = repo.FindByID(job.ID)
+	assert.Equal(t, models.JobStatusDead, reloaded.Status)
+	assert.NotNil(t, reloaded.FinishedAt)
+}
+
+func TestJobRepository_RequeueStale(t *testing.T) {
+	repo := setupJobTestRepo(t)
+	now := time.Now()
+	retried := newTestJob(t, repo, "default", now.Add(-time.Hour))
+	exhausted := newTestJob(t, repo, "default", now.Add(-time.Hour))
+	repo.db.Model(exhausted).Update("attempts", 1)
+
+	claimed, _ := repo.Claim("default", "w1", 10, now.Add(-10*time.Minute))
+	require.Len(t, claimed, 2)
+
+	n, err := repo.RequeueStale(now.Add(-5*time.Minute), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	reloaded, _ := repo.FindByID(retried.ID)
+	assert.Equal(t, models.JobStatusPending, reloaded.Status)
+	assert.Equal(t, 1, reloaded.Attempts)
+	reloaded, _ = repo.FindByID(exhausted.ID)
+	assert.Equal(t, models.JobStatusDead, reloaded.Status)
+
+	// 未超时的任务不受影响
+	claimed, _ = repo.Claim("default", "w1", 10, now)
+	require.Len(t, claimed, 1)
+	n, _ = repo.RequeueStale(now.Add(-5*time.Minute), now)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestJobRepository_RetryCancelAndCleanup(t *testing.T) {
+	repo := setupJobTestRepo(t)
+	now := time.Now()
+	scheduled := newTestJob(t, repo, "default", now.Add(time.Hour))
+
+	_, err := repo.Retry(scheduled.ID, now)
+	assert.ErrorIs(t, err, ErrJobNotRetryable)
+
+	cancelled, err := repo.Cancel(scheduled.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusCancelled, cancelled.Status)
+	_, err = repo.Cancel(scheduled.ID, now)
+	assert.ErrorIs(t, err, ErrJobNotCancellable)
+	_, err = repo.Cancel(9999, now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	retried, err := repo.Retry(scheduled.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusPending, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+	assert.Nil(t, retried.FinishedAt)
+	assert.WithinDuration(t, now, retried.RunAt, time.Second)
+
+	claimed, _ := repo.Claim("default", "w1", 1, now)
+	require.Len(t, claimed, 1)
+	require.NoError(t, repo.Complete(&claimed[0], now.Add(-48*time.Hour)))
+	newTestJob(t, repo, "other", now)
+
+	stats, err := repo.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, []models.JobStats{
+		{Queue: "default", Status: models.JobStatusSucceeded, Count: 1},
+		{Queue: "other", Status: models.JobStatusPending, Count: 1},
+	}, stats)
+
+	n, err := repo.DeleteFinished(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	var pagination models.Pagination
+	jobs, err := repo.FindAll(JobFilter{Status: models.JobStatusPending}, &pagination)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, int64(1), pagination.Total)
+}
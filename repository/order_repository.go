@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrEmptyOrder 订单中没有商品
+	ErrEmptyOrder = errors.New("order has no items")
+	// ErrProductUnavailable 产品已下架
+	ErrProductUnavailable = errors.New("product is not available")
+	// ErrInvalidOrderTransition 订单状态不允许流转到目标状态
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+)
+
+type OrderRepository struct {
+	db       *gorm.DB
+	products *ProductRepository
+}
+
+func NewOrderRepository(db *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: db, products: NewProductRepository(db)}
+}
+
+// WithTx 返回在指定事务中执行的仓库
+func (r *OrderRepository) WithTx(tx *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: tx, products: r.products.WithTx(tx)}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *OrderRepository) WithContext(ctx context.Context) *OrderRepository {
+	return r.WithTx(r.db.WithContext(ctx))
+}
+
+// Create 创建订单：按订单币种快照产品价格并在同一事务中预留库存。
+// order.Items 只需填写 ProductID、VariantID 和 Quantity，相同产品和变体会合并为一项；
+// order.Total.Currency 为订单币种，未指定时使用基础货币
+func (r *OrderRepository) Create(order *models.Order) error {
+	if len(order.Items) == 0 {
+		return ErrEmptyOrder
+	}
+	currency := order.Total.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	ctx := r.db.Statement.Context
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		items, err := mergeOrderItems(order.Items)
+		if err != nil {
+			return err
+		}
+
+		total := models.NewMoney(0, currency)
+		for i := range items {
+			item := &items[i]
+			var product models.Product
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return fmt.Errorf("product %d: %w", item.ProductID, err)
+				}
+				return err
+			}
+			if !product.IsAvailable {
+				return fmt.Errorf("product %d: %w", product.ID, ErrProductUnavailable)
+			}
+			item.ProductName = product.Name
+			var variant *models.ProductVariant
+			if item.VariantID != nil {
+				variant = &models.ProductVariant{}
+				if err := tx.Where("product_id = ?", product.ID).First(variant, *item.VariantID).Error; err != nil {
+					if err == gorm.ErrRecordNotFound {
+						return fmt.Errorf("variant %d: %w", *item.VariantID, err)
+					}
+					return err
+				}
+				if !variant.IsAvailable {
+					return fmt.Errorf("variant %s: %w", variant.SKU, ErrProductUnavailable)
+				}
+				item.SKU = variant.SKU
+			}
+			if item.UnitPrice, err = resolvePrice(ctx, tx, &product, variant, currency); err != nil {
+				return fmt.Errorf("product %d: %w", product.ID, err)
+			}
+			if item.Subtotal, err = item.UnitPrice.Multiply(item.Quantity); err != nil {
+				return err
+			}
+			if total, err = total.Add(item.Subtotal); err != nil {
+				return err
+			}
+		}
+
+		order.Items = items
+		order.Status = models.OrderStatusPending
+		order.Total = total
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		products := r.products.WithTx(tx)
+		change := models.StockChange{Reason: "order placed", Reference: order.StockReference(), ActorID: &order.UserID}
+		for _, item := range order.Items {
+			var err error
+			if item.VariantID != nil {
+				_, err = products.ReserveVariant(item.ProductID, *item.VariantID, item.Quantity, change)
+			} else {
+				_, err = products.Reserve(item.ProductID, item.Quantity, change)
+			}
+			if err != nil {
+				return fmt.Errorf("product %d: %w", item.ProductID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// FindByID 根据ID查找订单
+func (r *OrderRepository) FindByID(id uint) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Items").First(&order, id).Error
+	return &order, err
+}
+
+// FindByIDForUser 查找属于指定用户的订单
+func (r *OrderRepository) FindByIDForUser(id, userID uint) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Items").Where("user_id = ?", userID).First(&order, id).Error
+	return &order, err
+}
+
+// FindAll 查找订单（分页），userID 为 0 时不限用户，status 为空时不限状态
+func (r *OrderRepository) FindAll(userID uint, status string, pagination *models.Pagination) ([]models.Order, error) {
+	var orders []models.Order
+
+	query := r.db.Model(&models.Order{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// 获取总数
+	query.Count(&pagination.Total)
+
+	// 分页查询
+	offset := pagination.GetOffset()
+	limit := pagination.GetLimit()
+	err := query.Preload("Items").Order("id DESC").Offset(offset).Limit(limit).Find(&orders).Error
+
+	return orders, err
+}
+
+// UpdateStatus 按状态机流转订单状态，并在同一事务中处理库存：
+// 支付时提交预留扣减库存，取消时释放预留，退款时归还库存
+func (r *OrderRepository) UpdateStatus(id uint, status string, actorID *uint) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, id).Error; err != nil {
+			return err
+		}
+		if !order.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidOrderTransition, order.Status, status)
+		}
+
+		products := r.products.WithTx(tx)
+		change := models.StockChange{Reason: "order " + status, Reference: order.StockReference(), ActorID: actorID}
+		switch status {
+		case models.OrderStatusPaid:
+			if _, err := products.CommitReservation(change); err != nil {
+				return err
+			}
+		case models.OrderStatusCancelled:
+			if _, err := products.ReleaseReservation(change); err != nil {
+				return err
+			}
+		case models.OrderStatusRefunded:
+			for _, item := range order.Items {
+				var err error
+				if item.VariantID != nil {
+					_, err = products.AdjustVariantStock(item.ProductID, *item.VariantID, item.Quantity, change)
+				} else {
+					_, err = products.AdjustStock(item.ProductID, item.Quantity, change)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": status}
+		switch status {
+		case models.OrderStatusPaid:
+			updates["paid_at"] = now
+		case models.OrderStatusShipped:
+			updates["shipped_at"] = now
+		case models.OrderStatusCancelled:
+			updates["cancelled_at"] = now
+		case models.OrderStatusRefunded:
+			updates["refunded_at"] = now
+		}
+		result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, order.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidOrderTransition
+		}
+		return tx.Preload("Items").First(&order, order.ID).Error
+	})
+	return &order, err
+}
+
+// Delete 删除订单（软删除）
+func (r *OrderRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Order{}, id).Error
+}
+
+// orderItemKey 订单项按产品和变体合并
+type orderItemKey struct {
+	productID uint
+	variantID uint
+}
+
+// mergeOrderItems 校验数量并合并相同产品和变体的订单项
+func mergeOrderItems(items []models.OrderItem) ([]models.OrderItem, error) {
+	merged := make([]models.OrderItem, 0, len(items))
+	index := make(map[orderItemKey]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		key := orderItemKey{productID: item.ProductID}
+		if item.VariantID != nil {
+			key.variantID = *item.VariantID
+		}
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, models.OrderItem{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity})
+	}
+	return merged, nil
+}
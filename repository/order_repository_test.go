@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupOrderTestDB(t *testing.T) (*gorm.DB, *models.Product) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Product{}, &models.ProductVariant{}, &models.StockMovement{}, &models.StockReservation{}, &models.Order{}, &models.OrderItem{})
+
+	product := &models.Product{Name: "Mouse", Price: models.NewMoney(1999, "USD"), Stock: 10, IsAvailable: true}
+	assert.NoError(t, NewProductRepository(db).Create(product))
+	return db, product
+}
+
+func TestOrderRepository_CreateSnapshotsPriceAndReservesStock(t *testing.T) {
+	db, product := setupOrderTestDB(t)
+	repo := NewOrderRepository(db)
+	products := NewProductRepository(db)
+
+	order := &models.Order{UserID: 1, Items: []models.OrderItem{
+		{ProductID: product.ID, Quantity: 2},
+		{ProductID: product.ID, Quantity: 1},
+	}}
+	assert.NoError(t, repo.Create(order))
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	assert.Len(t, order.Items, 1)
+	assert.Equal(t, models.NewMoney(5997, "USD"), order.Total)
+
+	// 价格变化不影响已下单的快照
+	product.Price = models.NewMoney(2999, "USD")
+	assert.NoError(t, products.Update(product))
+	found, err := repo.FindByIDForUser(order.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.NewMoney(1999, "USD"), found.Items[0].UnitPrice)
+
+	// 其他用户看不到该订单
+	_, err = repo.FindByIDForUser(order.ID, 2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	stocked, _ := products.FindByID(product.ID)
+	assert.Equal(t, 10, stocked.Stock)
+	assert.Equal(t, 3, stocked.Reserved)
+
+	// 库存不足时整个订单回滚
+	err = repo.Create(&models.Order{UserID: 1, Items: []models.OrderItem{{ProductID: product.ID, Quantity: 8}}})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	var count int64
+	db.Model(&models.Order{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestOrderRepository_StatusTransitions(t *testing.T) {
+	db, product := setupOrderTestDB(t)
+	repo := NewOrderRepository(db)
+	products := NewProductRepository(db)
+
+	order := &models.Order{UserID: 1, Items: []models.OrderItem{{ProductID: product.ID, Quantity: 4}}}
+	assert.NoError(t, repo.Create(order))
+
+	_, err := repo.UpdateStatus(order.ID, models.OrderStatusShipped, nil)
+	assert.ErrorIs(t, err, ErrInvalidOrderTransition)
+
+	paid, err := repo.UpdateStatus(order.ID, models.OrderStatusPaid, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, paid.PaidAt)
+	stocked, _ := products.FindByID(product.ID)
+	assert.Equal(t, 6, stocked.Stock)
+	assert.Equal(t, 0, stocked.Reserved)
+
+	_, err = repo.UpdateStatus(order.ID, models.OrderStatusCancelled, nil)
+	assert.ErrorIs(t, err, ErrInvalidOrderTransition)
+
+	_, err = repo.UpdateStatus(order.ID, models.OrderStatusRefunded, nil)
+	assert.NoError(t, err)
+	stocked, _ = products.FindByID(product.ID)
+	assert.Equal(t, 10, stocked.Stock)
+
+	// 取消待支付订单释放预留
+	pending := &models.Order{UserID: 1, Items: []models.OrderItem{{ProductID: product.ID, Quantity: 5}}}
+	assert.NoError(t, repo.Create(pending))
+	_, err = repo.UpdateStatus(pending.ID, models.OrderStatusCancelled, nil)
+	assert.NoError(t, err)
+	stocked, _ = products.FindByID(product.ID)
+	assert.Equal(t, 10, stocked.AvailableStock())
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordEvent 在事务 tx 中写入领域事件，事件随业务数据一起提交或回滚
+func recordEvent(tx *gorm.DB, eventType, aggregateType string, aggregateID uint, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	key, err := newEventKey()
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{
+		Key:           key,
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: time.Now().UTC(),
+	}).Error
+}
+
+// newEventKey 生成 UUID v4 格式的事件幂等键
+func newEventKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// userEventPayload 用户事件内容，不包含密码摘要
+func userEventPayload(user *models.User) models.User {
+	payload := *user
+	payload.Password = ""
+	return payload
+}
+
+// OutboxRepository 领域事件的投递状态。与任务队列相同，领取使用条件更新，时间统一使用 UTC
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// FindByID 根据ID查找事件
+func (r *OutboxRepository) FindByID(id uint) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := r.db.First(&event, id).Error
+	return &event, err
+}
+
+// Claim 按写入顺序领取最多 limit 个待投递的事件，在 lease 时间内其他 dispatcher 不会领取同一事件
+func (r *OutboxRepository) Claim(limit int, lease time.Duration, now time.Time) ([]models.OutboxEvent, error) {
+	now = now.UTC()
+	var candidates []models.OutboxEvent
+	err := r.db.
+		Where("status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)", models.OutboxStatusPending, now, now).
+		Order("id").
+		Limit(limit).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntil := now.Add(lease)
+	claimed := candidates[:0]
+	for _, event := range candidates {
+		result := r.db.Model(&models.OutboxEvent{}).
+			Where("id = ? AND status = ? AND (locked_until IS NULL OR locked_until < ?)", event.ID, models.OutboxStatusPending, now).
+			Update("locked_until", lockedUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		event.LockedUntil = &lockedUntil
+		claimed = append(claimed, event)
+	}
+	return claimed, nil
+}
+
+// Delivered 返回事件已成功投递的订阅者
+func (r *OutboxRepository) Delivered(eventID uint) (map[string]bool, error) {
+	var subscribers []string
+	err := r.db.Model(&models.OutboxDelivery{}).Where("event_id = ?", eventID).Pluck("subscriber", &subscribers).Error
+	delivered := make(map[string]bool, len(subscribers))
+	for _, s := range subscribers {
+		delivered[s] = true
+	}
+	return delivered, err
+}
+
+// MarkDelivered 记录事件已投递给订阅者，重复记录时忽略
+func (r *OutboxRepository) MarkDelivered(eventID uint, subscriber string) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.OutboxDelivery{EventID: eventID, Subscriber: subscriber}).Error
+}
+
+// Complete 事件已投递给所有订阅者
+func (r *OutboxRepository) Complete(event *models.OutboxEvent, now time.Time) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+		"status":        models.OutboxStatusDispatched,
+		"dispatched_at": now.UTC(),
+		"locked_until":  nil,
+	}).Error
+}
+
+// Fail 记录投递失败。retryAt 为零值时事件进入 failed 状态，不再自动投递
+func (r *OutboxRepository) Fail(event *models.OutboxEvent, message string, retryAt time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   message,
+		"locked_until": nil,
+	}
+	if retryAt.IsZero() {
+		updates["status"] = models.OutboxStatusFailed
+	} else {
+		updates["next_attempt_at"] = retryAt.UTC()
+	}
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", event.ID).Updates(updates).Error
+}
+
+// DeleteDispatched 删除 before 之前已投递的事件及其投递记录，失败的事件保留以便排查
+func (r *OutboxRepository) DeleteDispatched(before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.OutboxEvent{}).
+			Select("id").
+			Where("status = ? AND dispatched_at < ?", models.OutboxStatusDispatched, before.UTC())
+		if err := tx.Where("event_id IN (?)", expired).Delete(&models.OutboxDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("status = ? AND dispatched_at < ?", models.OutboxStatusDispatched, before.UTC()).
+			Delete(&models.OutboxEvent{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupOutboxTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB()
+	require.NoError(t, db.AutoMigrate(&models.Product{}, &models.ProductVariant{}, &models.ProductPrice{},
+		&models.StockMovement{}, &models.StockReservation{}, &models.OutboxDelivery{}))
+	return db
+}
+
+func outboxEvents(t *testing.T, db *gorm.DB) []models.OutboxEvent {
+	var events []models.OutboxEvent
+	require.NoError(t, db.Order("id").Find(&events).Error)
+	return events
+}
+
+func eventTypes(events []models.OutboxEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestOutbox_UserEvents(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	repo := NewUserRepository(db)
+
+	user := &models.User{Username: "outbox", Email: "outbox@example.com", Password: "secret-hash"}
+	require.NoError(t, repo.Create(user))
+	user.Email = "changed@example.com"
+	require.NoError(t, repo.Update(user))
+	require.NoError(t, repo.Delete(user.ID))
+	// 删除不存在的用户不写入事件
+	require.NoError(t, repo.Delete(user.ID))
+
+	events := outboxEvents(t, db)
+	require.Equal(t, []string{models.EventUserCreated, models.EventUserUpdated, models.EventUserDeleted}, eventTypes(events))
+	for _, event := range events {
+		assert.Equal(t, models.AggregateUser, event.AggregateType)
+		assert.Equal(t, user.ID, event.AggregateID)
+		assert.Equal(t, models.OutboxStatusPending, event.Status)
+		assert.Len(t, event.Key, 36)
+		assert.NotContains(t, string(event.Payload), "secret-hash")
+	}
+	assert.NotEqual(t, events[0].Key, events[1].Key)
+	assert.Contains(t, string(events[1].Payload), "changed@example.com")
+	assert.JSONEq(t, `{"id":1}`, string(events[2].Payload))
+}
+
+func TestOutbox_ProductEvents(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	repo := NewProductRepository(db)
+
+	product := &models.Product{Name: "Keyboard", Price: models.NewMoney(9900, "USD"), Stock: 5}
+	require.NoError(t, repo.Create(product))
+	product.Name = "Mechanical Keyboard"
+	require.NoError(t, repo.Update(product))
+	product.Price = models.NewMoney(12900, "USD")
+	require.NoError(t, repo.Update(product))
+	_, err := repo.AdjustStock(product.ID, -2, models.StockChange{Reason: "damaged"})
+	require.NoError(t, err)
+	prices := NewPriceRepository(db)
+	_, err = prices.Set(product.ID, models.NewMoney(11900, "EUR"))
+	require.NoError(t, err)
+	// 标价不变时不写入事件
+	_, err = prices.Set(product.ID, models.NewMoney(11900, "EUR"))
+	require.NoError(t, err)
+	require.NoError(t, prices.Delete(product.ID, "EUR"))
+	require.NoError(t, repo.Delete(product.ID))
+
+	events := outboxEvents(t, db)
+	require.Equal(t, []string{
+		models.EventProductCreated,
+		models.EventProductStockChanged, // 初始库存
+		models.EventProductUpdated,
+		models.EventProductUpdated,
+		models.EventProductPriceChanged,
+		models.EventProductStockChanged,
+		models.EventProductPriceChanged,
+		models.EventProductPriceChanged,
+		models.EventProductDeleted,
+	}, eventTypes(events))
+	for _, event := range events {
+		assert.Equal(t, models.AggregateProduct, event.AggregateType)
+		assert.Equal(t, product.ID, event.AggregateID)
+	}
+	assert.Contains(t, string(events[2].Payload), "Mechanical Keyboard")
+	assert.Contains(t, string(events[4].Payload), `"old_price":{"amount":"99.00"`)
+	assert.Contains(t, string(events[4].Payload), `"price":{"amount":"129.00"`)
+	assert.Contains(t, string(events[5].Payload), `"quantity":-2`)
+	assert.Contains(t, string(events[6].Payload), `"old_price":null`)
+	assert.Contains(t, string(events[7].Payload), `"price":null`)
+}
+
+func TestOutbox_RolledBackTransactionWritesNoEvent(t *testing.T) {
+	db := setupOutboxTestDB(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		user := &models.User{Username: "rollback", Email: "rollback@example.com", Password: "x"}
+		if err := NewUserRepository(tx).Create(user); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	require.Error(t, err)
+	assert.Empty(t, outboxEvents(t, db))
+
+	// 插入失败（用户名重复）时事件也随之回滚
+	repo := NewUserRepository(db)
+	require.NoError(t, repo.Create(&models.User{Username: "dup", Email: "a@example.com", Password: "x"}))
+	assert.Error(t, repo.Create(&models.User{Username: "dup", Email: "b@example.com", Password: "x"}))
+	assert.Len(t, outboxEvents(t, db), 1)
+}
+
+func TestOutboxRepository_ClaimLeaseAndFail(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	users := NewUserRepository(db)
+	repo := NewOutboxRepository(db)
+	require.NoError(t, users.Create(&models.User{Username: "a", Email: "a@example.com", Password: "x"}))
+	require.NoError(t, users.Create(&models.User{Username: "b", Email: "b@example.com", Password: "x"}))
+
+	now := time.Now()
+	claimed, err := repo.Claim(10, time.Minute, now)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Less(t, claimed[0].ID, claimed[1].ID)
+
+	// 租约期内不能再次领取，到期后可以
+	claimed, err = repo.Claim(10, time.Minute, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+	claimed, err = repo.Claim(10, time.Minute, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	first, second := claimed[0], claimed[1]
+	require.NoError(t, repo.MarkDelivered(first.ID, "search"))
+	require.NoError(t, repo.MarkDelivered(first.ID, "search"))
+	delivered, err := repo.Delivered(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"search": true}, delivered)
+
+	later := now.Add(2 * time.Minute)
+	require.NoError(t, repo.Fail(&first, "mail: down", later.Add(time.Minute)))
+	require.NoError(t, repo.Fail(&second, "gave up", time.Time{}))
+
+	// 重试时间未到时不领取，失败的事件不再领取
+	claimed, err = repo.Claim(10, time.Minute, later)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+	claimed, err = repo.Claim(10, time.Minute, later.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, first.ID, claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "mail: down", claimed[0].LastError)
+
+	failed, err := repo.FindByID(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxStatusFailed, failed.Status)
+
+	require.NoError(t, repo.Complete(&claimed[0], later))
+	deleted, err := repo.DeleteDispatched(later.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	delivered, err = repo.Delivered(first.ID)
+	require.NoError(t, err)
+	assert.Empty(t, delivered)
+	_, err = repo.FindByID(second.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fangyanlin/gin-gorm-app/exchange"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceRepository(db *gorm.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *PriceRepository) WithContext(ctx context.Context) *PriceRepository {
+	return &PriceRepository{db: r.db.WithContext(ctx)}
+}
+
+// FindByProduct 查找产品价目表中的所有标价
+func (r *PriceRepository) FindByProduct(productID uint) ([]models.ProductPrice, error) {
+	var prices []models.ProductPrice
+	err := r.db.Where("product_id = ?", productID).Order("currency").Find(&prices).Error
+	return prices, err
+}
+
+// Set 设置产品在某一币种下的标价，已存在时覆盖，标价变化时同一事务中写入 product.price_changed 事件。
+// 基础货币的价格请直接修改产品
+func (r *PriceRepository) Set(productID uint, price models.Money) (*models.ProductPrice, error) {
+	currency, err := models.NormalizeCurrency(price.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == models.DefaultCurrency {
+		return nil, fmt.Errorf("%w: %s is the base currency, update the product price instead", ErrInvalidPrice, currency)
+	}
+	if price.Amount <= 0 {
+		return nil, fmt.Errorf("%w: price must be positive", ErrInvalidPrice)
+	}
+
+	entry := models.ProductPrice{ProductID: productID, Currency: currency, Amount: price.Amount}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.Product{}, productID).Error; err != nil {
+			return err
+		}
+		var previous []models.ProductPrice
+		if err := tx.Where("product_id = ? AND currency = ?", productID, currency).Limit(1).Find(&previous).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"amount_minor", "updated_at"}),
+		}).Create(&entry).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ? AND currency = ?", productID, currency).First(&entry).Error; err != nil {
+			return err
+		}
+
+		current := entry.Price()
+		change := models.PriceChange{ProductID: productID, Currency: currency, Price: &current}
+		if len(previous) > 0 {
+			if previous[0].Amount == entry.Amount {
+				return nil
+			}
+			old := previous[0].Price()
+			change.OldPrice = &old
+		}
+		return recordEvent(tx, models.EventProductPriceChanged, models.AggregateProduct, productID, change)
+	})
+	return &entry, err
+}
+
+// Delete 删除产品在某一币种下的标价，之后该币种按汇率换算；同一事务中写入 product.price_changed 事件
+func (r *PriceRepository) Delete(productID uint, currency string) error {
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var entry models.ProductPrice
+		if err := tx.Where("product_id = ? AND currency = ?", productID, currency).First(&entry).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entry).Error; err != nil {
+			return err
+		}
+		old := entry.Price()
+		return recordEvent(tx, models.EventProductPriceChanged, models.AggregateProduct, productID, models.PriceChange{
+			ProductID: productID,
+			Currency:  currency,
+			OldPrice:  &old,
+		})
+	})
+}
+
+// Resolve 解析产品（或变体）在指定币种下的价格
+func (r *PriceRepository) Resolve(ctx context.Context, product *models.Product, variant *models.ProductVariant, currency string) (models.Money, error) {
+	return resolvePrice(ctx, r.db, product, variant, currency)
+}
+
+// Localize 为产品列表及已加载的变体填充指定币种下的价格 LocalPrice，价目表一次查询批量加载
+func (r *PriceRepository) Localize(ctx context.Context, products []models.Product, currency string) error {
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	listed := map[uint]models.Money{}
+	if currency != models.DefaultCurrency {
+		ids := make([]uint, len(products))
+		for i := range products {
+			ids[i] = products[i].ID
+		}
+		var entries []models.ProductPrice
+		if err := r.db.Where("product_id IN ? AND currency = ?", ids, currency).Find(&entries).Error; err != nil {
+			return err
+		}
+		for i := range entries {
+			listed[entries[i].ProductID] = entries[i].Price()
+		}
+	}
+
+	provider := exchange.Default()
+	for i := range products {
+		price, ok := listed[products[i].ID]
+		if !ok {
+			if price, err = exchange.Convert(ctx, provider, products[i].Price, currency); err != nil {
+				return err
+			}
+		}
+		products[i].LocalPrice = &price
+
+		for j := range products[i].Variants {
+			variant := &products[i].Variants[j]
+			variantPrice := price
+			if variant.Price != nil {
+				if variantPrice, err = exchange.Convert(ctx, provider, *variant.Price, currency); err != nil {
+					return err
+				}
+			}
+			variant.LocalPrice = &variantPrice
+		}
+	}
+	return nil
+}
+
+// resolvePrice 按以下顺序解析价格：变体覆盖价格按汇率换算；基础货币直接使用产品价格；
+// 其他币种优先使用价目表中的标价，没有标价时按汇率换算
+func resolvePrice(ctx context.Context, db *gorm.DB, product *models.Product, variant *models.ProductVariant, currency string) (models.Money, error) {
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+	provider := exchange.Default()
+	if variant != nil && variant.Price != nil {
+		return exchange.Convert(ctx, provider, *variant.Price, currency)
+	}
+	if currency == product.Price.Currency {
+		return product.Price, nil
+	}
+
+	var entry models.ProductPrice
+	err = db.Where("product_id = ? AND currency = ?", product.ID, currency).First(&entry).Error
+	if err == nil {
+		return entry.Price(), nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return models.Money{}, err
+	}
+	return exchange.Convert(ctx, provider, product.Price, currency)
+}
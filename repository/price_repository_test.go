@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/exchange"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceRepository_ResolveAndOrderCurrency(t *testing.T) {
+	db, product := setupOrderTestDB(t)
+	db.AutoMigrate(&models.ProductPrice{})
+	repo := NewPriceRepository(db)
+	ctx := context.Background()
+
+	exchange.SetDefault(exchange.NewStatic("USD", map[string]*big.Rat{"EUR": big.NewRat(9, 10), "GBP": big.NewRat(4, 5)}))
+	defer exchange.SetDefault(exchange.NewStatic(models.DefaultCurrency, nil))
+
+	// 基础货币的价格只能在产品上修改，标价必须为正数
+	_, err := repo.Set(product.ID, models.NewMoney(100, "USD"))
+	assert.ErrorIs(t, err, ErrInvalidPrice)
+	_, err = repo.Set(product.ID, models.NewMoney(0, "EUR"))
+	assert.ErrorIs(t, err, ErrInvalidPrice)
+	assert.ErrorIs(t, NewProductRepository(db).Create(&models.Product{Name: "x", Price: models.NewMoney(100, "EUR")}), ErrInvalidPrice)
+
+	_, err = repo.Set(product.ID, models.NewMoney(1500, "EUR"))
+	require.NoError(t, err)
+	entry, err := repo.Set(product.ID, models.NewMoney(1750, "eur"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1750), entry.Amount)
+	prices, _ := repo.FindByProduct(product.ID)
+	assert.Len(t, prices, 1)
+
+	// 价目表中的标价优先，其他币种按汇率换算（19.99 * 0.8 = 15.992）
+	price, err := repo.Resolve(ctx, product, nil, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(1750, "EUR"), price)
+	price, err = repo.Resolve(ctx, product, nil, "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(1599, "GBP"), price)
+	_, err = repo.Resolve(ctx, product, nil, "JPY")
+	assert.ErrorIs(t, err, exchange.ErrRateNotFound)
+
+	products := []models.Product{*product}
+	require.NoError(t, repo.Localize(ctx, products, "EUR"))
+	assert.Equal(t, models.NewMoney(1750, "EUR"), *products[0].LocalPrice)
+
+	// 订单按所选币种快照价格
+	order := &models.Order{UserID: 1, Total: models.Money{Currency: "EUR"}, Items: []models.OrderItem{{ProductID: product.ID, Quantity: 2}}}
+	require.NoError(t, NewOrderRepository(db).Create(order))
+	assert.Equal(t, models.NewMoney(1750, "EUR"), order.Items[0].UnitPrice)
+	assert.Equal(t, models.NewMoney(3500, "EUR"), order.Total)
+
+	err = NewOrderRepository(db).Create(&models.Order{UserID: 1, Total: models.Money{Currency: "JPY"},
+		Items: []models.OrderItem{{ProductID: product.ID, Quantity: 1}}})
+	assert.ErrorIs(t, err, exchange.ErrRateNotFound)
+
+	require.NoError(t, repo.Delete(product.ID, "EUR"))
+	price, _ = repo.Resolve(ctx, product, nil, "EUR")
+	assert.Equal(t, models.NewMoney(1799, "EUR"), price)
+}
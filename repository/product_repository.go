@@ -1,21 +1,76 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/search"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInvalidPrice 价格必须为正数且使用基础货币，其他币种通过价目表设置
+var ErrInvalidPrice = errors.New("invalid price")
+
 type ProductRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search search.Engine
 }
 
 func NewProductRepository(db *gorm.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+	return &ProductRepository{db: db, search: search.New(db)}
+}
+
+// WithTx 返回在指定事务中执行的仓库，供其他仓库在同一事务内操作库存
+func (r *ProductRepository) WithTx(tx *gorm.DB) *ProductRepository {
+	return &ProductRepository{db: tx, search: search.New(tx)}
 }
 
-// Create 创建产品
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *ProductRepository) WithContext(ctx context.Context) *ProductRepository {
+	return r.WithTx(r.db.WithContext(ctx))
+}
+
+// Create 创建产品，同一事务中写入 product.created 事件
 func (r *ProductRepository) Create(product *models.Product) error {
-	return r.db.Create(product).Error
+	if err := validatePrice(&product.Price, false); err != nil {
+		return err
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product.Reserved = 0
+		if err := prepareProductSKU(tx, product); err != nil {
+			return err
+		}
+		if err := syncProductCategory(tx, product); err != nil {
+			return err
+		}
+		// is_available 有默认值 true，插入时零值会被替换为默认值，需要单独写入
+		available := product.IsAvailable
+		// 变体通过变体接口单独创建
+		if err := tx.Omit(clause.Associations).Create(product).Error; err != nil {
+			return err
+		}
+		if !available {
+			if err := tx.Model(product).UpdateColumn("is_available", false).Error; err != nil {
+				return err
+			}
+			product.IsAvailable = false
+		}
+		if err := recordEvent(tx, models.EventProductCreated, models.AggregateProduct, product.ID, product); err != nil {
+			return err
+		}
+		// 初始库存记入库存流水
+		if product.Stock == 0 {
+			return nil
+		}
+		_, err := recordMovement(tx, product, nil, models.StockMovementAdjust, product.Stock, models.StockChange{
+			Reason: "initial stock",
+		})
+		return err
+	})
 }
 
 // FindByID 根据ID查找产品
@@ -25,26 +80,72 @@ func (r *ProductRepository) FindByID(id uint) (*models.Product, error) {
 	return &product, err
 }
 
-// FindAll 查找所有产品（分页）
-func (r *ProductRepository) FindAll(pagination *models.Pagination) ([]models.Product, error) {
+// FindBySKU 根据 SKU 查找产品
+func (r *ProductRepository) FindBySKU(sku string) (*models.Product, error) {
+	var product models.Product
+	err := r.db.Where("sku = ?", sku).First(&product).Error
+	return &product, err
+}
+
+// FindByIDWithDetails 根据ID查找产品并加载变体和图片
+func (r *ProductRepository) FindByIDWithDetails(id uint) (*models.Product, error) {
+	var product models.Product
+	ordered := func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order").Order("id")
+	}
+	err := r.db.Preload("Variants", ordered).Preload("Images", ordered).First(&product, id).Error
+	return &product, err
+}
+
+// FindAll 查找所有产品（分页），按 q 过滤和排序
+func (r *ProductRepository) FindAll(q *models.ListQuery, pagination *models.Pagination) ([]models.Product, error) {
 	var products []models.Product
 
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	query := q.ApplyFilters(r.db.Model(&models.Product{}))
 
 	// 获取总数
-	r.db.Model(&models.Product{}).Count(&pagination.Total)
+	query.Count(&pagination.Total)
 
 	// 分页查询
-	err := r.db.Offset(offset).Limit(limit).Find(&products).Error
+	offset := pagination.GetOffset()
+	limit := pagination.GetLimit()
+	err := q.ApplySort(query).Offset(offset).Limit(limit).Find(&products).Error
+
 	return products, err
 }
 
-// FindByCategory 根据分类查找产品
-func (r *ProductRepository) FindByCategory(category string, pagination *models.Pagination) ([]models.Product, error) {
+// FindAllCursor 按游标分页查找产品，page.WithTotal 为 false 时不查询总数
+func (r *ProductRepository) FindAllCursor(q *models.ListQuery, page *models.CursorPage) ([]models.Product, error) {
 	var products []models.Product
+	err := models.FindCursorPage(r.db.Model(&models.Product{}), q, models.ProductQuerySchema, page, &products)
+	return products, err
+}
 
-	query := r.db.Model(&models.Product{}).Where("category = ?", category)
+// Each 按 q 过滤和排序逐个读取产品并调用 fn，不一次性加载到内存，用于导出；fn 返回错误时停止
+func (r *ProductRepository) Each(ctx context.Context, q *models.ListQuery, fn func(*models.Product) error) error {
+	rows, err := q.ApplySort(q.ApplyFilters(r.db.WithContext(ctx).Model(&models.Product{}))).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product models.Product
+		if err := r.db.ScanRows(rows, &product); err != nil {
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindByCategory 查找属于指定分类（可包含子孙分类）的产品
+func (r *ProductRepository) FindByCategory(categoryIDs []uint, q *models.ListQuery, pagination *models.Pagination) ([]models.Product, error) {
+	var products []models.Product
+
+	query := q.ApplyFilters(r.db.Model(&models.Product{}).Where("category_id IN ?", categoryIDs))
 
 	// 获取总数
 	query.Count(&pagination.Total)
@@ -52,44 +153,119 @@ func (r *ProductRepository) FindByCategory(category string, pagination *models.P
 	// 分页查询
 	offset := pagination.GetOffset()
 	limit := pagination.GetLimit()
-	err := query.Offset(offset).Limit(limit).Find(&products).Error
+	err := q.ApplySort(query).Offset(offset).Limit(limit).Find(&products).Error
 
 	return products, err
 }
 
-// Update 更新产品
+// Update 更新产品，同一事务中写入 product.updated 事件
 func (r *ProductRepository) Update(product *models.Product) error {
-	return r.db.Save(product).Error
+	if err := validatePrice(&product.Price, false); err != nil {
+		return err
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := prepareProductSKU(tx, product); err != nil {
+			return err
+		}
+		if err := syncProductCategory(tx, product); err != nil {
+			return err
+		}
+		var previous models.Product
+		if err := tx.Select("id", "price_minor", "price_currency").Where("id = ?", product.ID).Limit(1).Find(&previous).Error; err != nil {
+			return err
+		}
+		// 库存和预留数量只能通过 AdjustStock 等库存操作修改
+		if err := tx.Omit(clause.Associations, "stock", "reserved", "variant_terms").Save(product).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, models.EventProductUpdated, models.AggregateProduct, product.ID, product); err != nil {
+			return err
+		}
+		// 价格变化时还写入 product.price_changed 事件
+		if previous.ID == 0 || previous.Price == product.Price {
+			return nil
+		}
+		price := product.Price
+		return recordEvent(tx, models.EventProductPriceChanged, models.AggregateProduct, product.ID, models.PriceChange{
+			ProductID: product.ID,
+			Currency:  price.Currency,
+			OldPrice:  &previous.Price,
+			Price:     &price,
+		})
+	})
 }
 
-// Delete 删除产品（软删除）
+// Delete 删除产品（软删除），产品存在时同一事务中写入 product.deleted 事件
 func (r *ProductRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Product{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Product{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return recordEvent(tx, models.EventProductDeleted, models.AggregateProduct, id, map[string]uint{"id": id})
+	})
 }
 
-// UpdateStock 更新库存
-func (r *ProductRepository) UpdateStock(id uint, quantity int) error {
-	return r.db.Model(&models.Product{}).Where("id = ?", id).
-		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+// Search 全文搜索产品，结果按相关度排序，q 的排序条件作为次要排序；
+// facets 非 nil 时在相同过滤范围内计算分面
+func (r *ProductRepository) Search(keyword string, q *models.ListQuery, facets *models.FacetRequest, pagination *models.Pagination) ([]models.ProductSearchHit, *models.ProductFacets, error) {
+	result, err := r.search.Search(r.db.Statement.Context, search.Request{
+		Keyword: keyword,
+		Query:   q,
+		Offset:  pagination.GetOffset(),
+		Limit:   pagination.GetLimit(),
+		Facets:  facets,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	pagination.Total = result.Total
+	return result.Hits, result.Facets, nil
 }
 
-// Search 搜索产品
-func (r *ProductRepository) Search(keyword string, pagination *models.Pagination) ([]models.Product, error) {
-	var products []models.Product
-
-	query := r.db.Model(&models.Product{})
-	if keyword != "" {
-		query = query.Where("name LIKE ? OR description LIKE ? OR category LIKE ?",
-			"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+// prepareProductSKU 规范化产品 SKU（空字符串视为未设置），并校验没有被其他产品或变体使用
+func prepareProductSKU(tx *gorm.DB, product *models.Product) error {
+	if product.SKU == nil {
+		return nil
 	}
+	sku := strings.TrimSpace(*product.SKU)
+	if sku == "" {
+		product.SKU = nil
+		return nil
+	}
+	product.SKU = &sku
+	return checkSKUAvailable(tx, sku, product.ID, 0)
+}
 
-	// 获取总数
-	query.Count(&pagination.Total)
-
-	// 分页查询
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	err := query.Offset(offset).Limit(limit).Find(&products).Error
+// checkSKUAvailable 校验 SKU 没有被其他产品或变体使用，productID、variantID 为当前记录自身（新建时为 0）
+func checkSKUAvailable(tx *gorm.DB, sku string, productID, variantID uint) error {
+	var count int64
+	err := tx.Model(&models.Product{}).Where("sku = ? AND id <> ?", sku, productID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = tx.Model(&models.ProductVariant{}).Where("sku = ? AND id <> ?", sku, variantID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		return ErrSKUTaken
+	}
+	return nil
+}
 
-	return products, err
+// validatePrice 校验价格使用基础货币，未指定币种时补全为基础货币；allowZero 为 false 时价格必须为正数
+func validatePrice(price *models.Money, allowZero bool) error {
+	if price.Currency == "" {
+		price.Currency = models.DefaultCurrency
+	}
+	if price.Currency != models.DefaultCurrency {
+		return fmt.Errorf("%w: price must be in %s, use the price list for other currencies", ErrInvalidPrice, models.DefaultCurrency)
+	}
+	if price.Amount < 0 || price.Amount == 0 && !allowZero {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidPrice)
+	}
+	return nil
 }
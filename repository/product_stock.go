@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInsufficientStock 可用库存不足
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidQuantity 数量必须为正数
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+	// ErrReservationNotFound 没有处于预留状态的记录
+	ErrReservationNotFound = errors.New("active reservation not found")
+	// ErrVariantRequired 产品有变体，库存操作必须指定变体
+	ErrVariantRequired = errors.New("product has variants, a variant must be specified")
+)
+
+// AdjustStock 按增量调整库存并记录流水，调整后的库存不能低于已预留数量；
+// 产品有变体时返回 ErrVariantRequired
+func (r *ProductRepository) AdjustStock(id uint, delta int, change models.StockChange) (*models.StockMovement, error) {
+	return r.adjustStock(id, nil, delta, change)
+}
+
+// AdjustVariantStock 调整变体库存，产品库存合计同步变化
+func (r *ProductRepository) AdjustVariantStock(productID, variantID uint, delta int, change models.StockChange) (*models.StockMovement, error) {
+	return r.adjustStock(productID, &variantID, delta, change)
+}
+
+// Reserve 从可用库存中预留指定数量，预留期间其他请求无法售出这部分库存；
+// 产品有变体时返回 ErrVariantRequired
+func (r *ProductRepository) Reserve(id uint, quantity int, change models.StockChange) (*models.StockReservation, error) {
+	return r.reserve(id, nil, quantity, change)
+}
+
+// ReserveVariant 从变体的可用库存中预留指定数量
+func (r *ProductRepository) ReserveVariant(productID, variantID uint, quantity int, change models.StockChange) (*models.StockReservation, error) {
+	return r.reserve(productID, &variantID, quantity, change)
+}
+
+// UpdateWithStock 更新产品并把库存设置为 stock，两者在同一事务中完成。
+// 变化量按加行锁读取的当前库存计算并记入流水，库存未变化时返回的流水为 nil
+func (r *ProductRepository) UpdateWithStock(product *models.Product, stock int, change models.StockChange) (*models.StockMovement, error) {
+	var movement *models.StockMovement
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockProduct(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		product.Stock = current.Stock
+		product.Reserved = current.Reserved
+
+		repo := r.WithTx(tx)
+		if err := repo.Update(product); err != nil {
+			return err
+		}
+		if delta := stock - current.Stock; delta != 0 {
+			if movement, err = repo.AdjustStock(product.ID, delta, change); err != nil {
+				return err
+			}
+			product.Stock = movement.StockAfter
+			product.Reserved = movement.ReservedAfter
+		}
+		return nil
+	})
+	return movement, err
+}
+
+func (r *ProductRepository) adjustStock(id uint, variantID *uint, delta int, change models.StockChange) (*models.StockMovement, error) {
+	var movement *models.StockMovement
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, id)
+		if err != nil {
+			return err
+		}
+		variant, err := stockTarget(tx, product, variantID)
+		if err != nil {
+			return err
+		}
+
+		if err := applyStockChange(tx, product, variant, delta, 0, "stock + ? >= reserved", delta); err != nil {
+			return err
+		}
+
+		movement, err = recordMovement(tx, product, variant, models.StockMovementAdjust, delta, change)
+		return err
+	})
+	return movement, err
+}
+
+func (r *ProductRepository) reserve(id uint, variantID *uint, quantity int, change models.StockChange) (*models.StockReservation, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	var reservation *models.StockReservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, id)
+		if err != nil {
+			return err
+		}
+		variant, err := stockTarget(tx, product, variantID)
+		if err != nil {
+			return err
+		}
+
+		// 条件更新保证即使在不支持行锁的数据库上也不会超卖
+		if err := applyStockChange(tx, product, variant, 0, quantity, "stock - reserved >= ?", quantity); err != nil {
+			return err
+		}
+
+		reservation = &models.StockReservation{
+			ProductID: id,
+			VariantID: variantID,
+			Quantity:  quantity,
+			Reference: change.Reference,
+			Status:    models.ReservationActive,
+		}
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+
+		_, err = recordMovement(tx, product, variant, models.StockMovementReserve, quantity, change)
+		return err
+	})
+	return reservation, err
+}
+
+// CommitReservation 提交关联单据下的所有预留，从库存中实际扣减
+func (r *ProductRepository) CommitReservation(change models.StockChange) ([]models.StockReservation, error) {
+	return r.settleReservations(change, models.ReservationCommitted)
+}
+
+// ReleaseReservation 释放关联单据下的所有预留，归还可用库存
+func (r *ProductRepository) ReleaseReservation(change models.StockChange) ([]models.StockReservation, error) {
+	return r.settleReservations(change, models.ReservationReleased)
+}
+
+// FindReservations 查找关联单据下的预留记录
+func (r *ProductRepository) FindReservations(reference string) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	err := r.db.Where("reference = ?", reference).Order("product_id").Order("id").Find(&reservations).Error
+	return reservations, err
+}
+
+// FindStockMovements 查找产品的库存流水（分页，最新的在前），variantID 非 nil 时只查该变体
+func (r *ProductRepository) FindStockMovements(productID uint, variantID *uint, pagination *models.Pagination) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+
+	query := r.db.Model(&models.StockMovement{}).Where("product_id = ?", productID)
+	if variantID != nil {
+		query = query.Where("variant_id = ?", *variantID)
+	}
+
+	// 获取总数
+	query.Count(&pagination.Total)
+
+	// 分页查询
+	offset := pagination.GetOffset()
+	limit := pagination.GetLimit()
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&movements).Error
+
+	return movements, err
+}
+
+// settleReservations 将处于预留状态的记录转为提交或释放
+func (r *ProductRepository) settleReservations(change models.StockChange, status string) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 按产品 ID 顺序加锁，避免并发事务互相等待
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ? AND status = ?", change.Reference, models.ReservationActive).
+			Order("product_id").
+			Find(&reservations).Error
+		if err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			return ErrReservationNotFound
+		}
+
+		for i := range reservations {
+			reservation := &reservations[i]
+			result := tx.Model(&models.StockReservation{}).
+				Where("id = ? AND status = ?", reservation.ID, models.ReservationActive).
+				Update("status", status)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrReservationNotFound
+			}
+			reservation.Status = status
+
+			product, err := lockProduct(tx, reservation.ProductID)
+			if err != nil {
+				return err
+			}
+			var variant *models.ProductVariant
+			if reservation.VariantID != nil {
+				if variant, err = lockVariant(tx, product.ID, *reservation.VariantID); err != nil {
+					return err
+				}
+			}
+
+			stockDelta := 0
+			movementType := models.StockMovementRelease
+			if status == models.ReservationCommitted {
+				stockDelta = -reservation.Quantity
+				movementType = models.StockMovementCommit
+			}
+			if err := applyStockChange(tx, product, variant, stockDelta, -reservation.Quantity, ""); err != nil {
+				return err
+			}
+
+			if _, err := recordMovement(tx, product, variant, movementType, reservation.Quantity, change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return reservations, err
+}
+
+// lockProduct 在事务中读取产品并加行锁（SQLite 不支持行锁，由写事务串行化保证）
+func lockProduct(tx *gorm.DB, id uint) (*models.Product, error) {
+	var product models.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error
+	return &product, err
+}
+
+// lockVariant 在事务中读取属于产品的变体并加行锁
+func lockVariant(tx *gorm.DB, productID, variantID uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ?", productID).
+		First(&variant, variantID).Error
+	return &variant, err
+}
+
+// stockTarget 确定库存操作的对象：指定变体时返回加锁的变体；
+// 未指定时操作产品本身，此时产品不能有变体
+func stockTarget(tx *gorm.DB, product *models.Product, variantID *uint) (*models.ProductVariant, error) {
+	if variantID != nil {
+		return lockVariant(tx, product.ID, *variantID)
+	}
+	var count int64
+	if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrVariantRequired
+	}
+	return nil, nil
+}
+
+// applyStockChange 修改库存和预留数量。有变体时以条件更新修改变体，并同步产品上的合计；
+// 否则直接修改产品。cond 不满足时返回 ErrInsufficientStock
+func applyStockChange(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, stockDelta, reservedDelta int, cond string, args ...interface{}) error {
+	updates := map[string]interface{}{}
+	if stockDelta != 0 {
+		updates["stock"] = gorm.Expr("stock + ?", stockDelta)
+	}
+	if reservedDelta != 0 {
+		updates["reserved"] = gorm.Expr("reserved + ?", reservedDelta)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	query := tx.Model(&models.Product{}).Where("id = ?", product.ID)
+	if variant != nil {
+		query = tx.Model(&models.ProductVariant{}).Where("id = ?", variant.ID)
+	}
+	if cond != "" {
+		query = query.Where(cond, args...)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	if variant != nil {
+		if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		variant.Stock += stockDelta
+		variant.Reserved += reservedDelta
+	}
+	product.Stock += stockDelta
+	product.Reserved += reservedDelta
+	return nil
+}
+
+// recordMovement 追加一条库存流水并写入 product.stock_changed 事件，变体的流水记录变体自身的库存和预留数量
+func recordMovement(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, movementType string, quantity int, change models.StockChange) (*models.StockMovement, error) {
+	movement := &models.StockMovement{
+		ProductID:     product.ID,
+		Type:          movementType,
+		Quantity:      quantity,
+		StockAfter:    product.Stock,
+		ReservedAfter: product.Reserved,
+		Reason:        change.Reason,
+		Reference:     change.Reference,
+		ActorID:       change.ActorID,
+	}
+	if variant != nil {
+		movement.VariantID = &variant.ID
+		movement.StockAfter = variant.Stock
+		movement.ReservedAfter = variant.Reserved
+	}
+	if err := tx.Create(movement).Error; err != nil {
+		return movement, err
+	}
+	return movement, recordEvent(tx, models.EventProductStockChanged, models.AggregateProduct, product.ID, movement)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductRepository_ReserveCommitRelease(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Product{}, &models.ProductVariant{}, &models.StockMovement{}, &models.StockReservation{})
+	repo := NewProductRepository(db)
+
+	product := &models.Product{Name: "Keyboard", Price: models.NewMoney(9900, "USD"), Stock: 5}
+	assert.NoError(t, repo.Create(product))
+
+	_, err := repo.Reserve(product.ID, 3, models.StockChange{Reference: "order-1"})
+	assert.NoError(t, err)
+
+	// 可用库存只剩 2，不能超卖
+	_, err = repo.Reserve(product.ID, 3, models.StockChange{Reference: "order-2"})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	// 库存不能调整到低于已预留数量
+	_, err = repo.AdjustStock(product.ID, -3, models.StockChange{Reason: "damaged"})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	_, err = repo.CommitReservation(models.StockChange{Reference: "order-1"})
+	assert.NoError(t, err)
+
+	found, _ := repo.FindByID(product.ID)
+	assert.Equal(t, 2, found.Stock)
+	assert.Equal(t, 0, found.Reserved)
+
+	// 已提交的预留不能再释放
+	_, err = repo.ReleaseReservation(models.StockChange{Reference: "order-1"})
+	assert.ErrorIs(t, err, ErrReservationNotFound)
+
+	_, err = repo.Reserve(product.ID, 2, models.StockChange{Reference: "order-3"})
+	assert.NoError(t, err)
+	_, err = repo.ReleaseReservation(models.StockChange{Reference: "order-3"})
+	assert.NoError(t, err)
+
+	found, _ = repo.FindByID(product.ID)
+	assert.Equal(t, 2, found.Stock)
+	assert.Equal(t, 2, found.AvailableStock())
+
+	var pagination models.Pagination
+	movements, err := repo.FindStockMovements(product.ID, nil, &pagination)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), pagination.Total)
+	assert.Equal(t, models.StockMovementRelease, movements[0].Type)
+	assert.Equal(t, models.StockMovementAdjust, movements[len(movements)-1].Type)
+
+	// 普通更新不会覆盖库存
+	found.Stock = 100
+	found.Name = "Mechanical Keyboard"
+	assert.NoError(t, repo.Update(found))
+	found, _ = repo.FindByID(product.ID)
+	assert.Equal(t, "Mechanical Keyboard", found.Name)
+	assert.Equal(t, 2, found.Stock)
+}
+
+func TestProductRepository_UpdateWithStock(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Product{}, &models.ProductVariant{}, &models.StockMovement{}, &models.StockReservation{})
+	repo := NewProductRepository(db)
+
+	product := &models.Product{Name: "Keyboard", Price: models.NewMoney(9900, "USD"), Stock: 5}
+	assert.NoError(t, repo.Create(product))
+	stale, _ := repo.FindByID(product.ID)
+
+	// 变化量按当前库存计算，读取之后发生的调整不会被覆盖
+	_, err := repo.AdjustStock(product.ID, 2, models.StockChange{Reason: "restock"})
+	assert.NoError(t, err)
+	stale.Name = "Mechanical Keyboard"
+	movement, err := repo.UpdateWithStock(stale, 10, models.StockChange{Reason: "product update"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, movement.Quantity)
+	assert.Equal(t, 10, stale.Stock)
+
+	// 库存未变化时不记流水
+	movement, err = repo.UpdateWithStock(stale, 10, models.StockChange{Reason: "product update"})
+	assert.NoError(t, err)
+	assert.Nil(t, movement)
+
+	// 库存调整失败时产品更新一并回滚
+	_, err = repo.Reserve(product.ID, 4, models.StockChange{Reference: "order-1"})
+	assert.NoError(t, err)
+	stale.Name = "Rolled Back"
+	_, err = repo.UpdateWithStock(stale, 1, models.StockChange{Reason: "product update"})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	found, _ := repo.FindByID(product.ID)
+	assert.Equal(t, "Mechanical Keyboard", found.Name)
+	assert.Equal(t, 10, found.Stock)
+	var pagination models.Pagination
+	_, err = repo.FindStockMovements(product.ID, nil, &pagination)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), pagination.Total)
+}
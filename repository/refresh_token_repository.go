@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenReused 刷新令牌已被使用或吊销后再次出现
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *RefreshTokenRepository) WithContext(ctx context.Context) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 保存刷新令牌
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash 根据令牌摘要查找
+func (r *RefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// Rotate 将旧令牌标记为已使用并保存新令牌。
+// 如果旧令牌已被并发请求使用，返回 ErrRefreshTokenReused
+func (r *RefreshTokenRepository) Rotate(old *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", old.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		old.UsedAt = &now
+
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeFamily 吊销同一 family 下所有未吊销的令牌
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
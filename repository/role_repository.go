@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *RoleRepository) WithContext(ctx context.Context) *RoleRepository {
+	return &RoleRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建角色
+func (r *RoleRepository) Create(role *models.Role) error {
+	return r.db.Omit("Permissions").Create(role).Error
+}
+
+// FindByID 根据ID查找角色（包含权限）
+func (r *RoleRepository) FindByID(id uint) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").First(&role, id).Error
+	return &role, err
+}
+
+// FindByName 根据名称查找角色
+func (r *RoleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	return &role, err
+}
+
+// FindAll 查找所有角色
+func (r *RoleRepository) FindAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("id").Find(&roles).Error
+	return roles, err
+}
+
+// Update 更新角色基本信息
+func (r *RoleRepository) Update(role *models.Role) error {
+	return r.db.Omit("Permissions").Save(role).Error
+}
+
+// Delete 删除角色及其关联（物理删除，以便角色名可以重新使用）
+func (r *RoleRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		role := &models.Role{BaseModel: models.BaseModel{ID: id}}
+		if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(role).Error
+	})
+}
+
+// FindAllPermissions 查找所有权限
+func (r *RoleRepository) FindAllPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+// EnsurePermissions 确保指定名称的权限存在，并按名称返回
+func (r *RoleRepository) EnsurePermissions(names []string) ([]models.Permission, error) {
+	permissions := make([]models.Permission, 0, len(names))
+	for _, name := range names {
+		var permission models.Permission
+		err := r.db.Where(models.Permission{Name: name}).FirstOrCreate(&permission).Error
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+// SetPermissions 替换角色的权限
+func (r *RoleRepository) SetPermissions(role *models.Role, names []string) error {
+	permissions, err := r.EnsurePermissions(names)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Model(role).Association("Permissions").Replace(permissions); err != nil {
+		return err
+	}
+	role.Permissions = permissions
+	return nil
+}
+
+// FindUserRoles 查找用户的角色
+func (r *RoleRepository) FindUserRoles(userID uint) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").
+		Find(&roles).Error
+	return roles, err
+}
+
+// SetUserRoles 替换用户的角色
+func (r *RoleRepository) SetUserRoles(user *models.User, roleIDs []uint) error {
+	var roles []models.Role
+	if len(roleIDs) > 0 {
+		if err := r.db.Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+			return err
+		}
+		if len(roles) != len(roleIDs) {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	if err := r.db.Model(user).Association("Roles").Replace(roles); err != nil {
+		return err
+	}
+	user.Roles = roles
+	return nil
+}
+
+// GetUserPermissions 查找用户通过角色获得的所有权限名称
+func (r *RoleRepository) GetUserPermissions(userID uint) ([]string, error) {
+	var names []string
+	err := r.db.Model(&models.Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleRepository_GetUserPermissions(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Permission{}, &models.Role{})
+	repo := NewRoleRepository(db)
+	userRepo := NewUserRepository(db)
+
+	user := &models.User{Username: "editor", Email: "editor@example.com", Password: "password"}
+	userRepo.Create(user)
+
+	role := &models.Role{Name: "editor"}
+	assert.NoError(t, repo.Create(role))
+	assert.NoError(t, repo.SetPermissions(role, []string{models.PermissionProductsWrite}))
+	assert.NoError(t, repo.SetUserRoles(user, []uint{role.ID}))
+
+	granted, err := repo.GetUserPermissions(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.PermissionProductsWrite}, granted)
+	assert.True(t, models.PermissionGranted(granted, models.PermissionProductsWrite))
+	assert.False(t, models.PermissionGranted(granted, models.PermissionUsersWrite))
+
+	// 删除角色后权限随之失效
+	assert.NoError(t, repo.Delete(role.ID))
+	granted, err = repo.GetUserPermissions(user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, granted)
+}
+
+func TestPermissionGranted_Wildcards(t *testing.T) {
+	assert.True(t, models.PermissionGranted([]string{models.PermissionAll}, "users:write"))
+	assert.True(t, models.PermissionGranted([]string{"products:*"}, "products:write"))
+	assert.False(t, models.PermissionGranted([]string{"products:*"}, "users:write"))
+}
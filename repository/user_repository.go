@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/fangyanlin/gin-gorm-app/models"
 	"gorm.io/gorm"
 )
@@ -13,9 +15,19 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Create 创建用户
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *UserRepository) WithContext(ctx context.Context) *UserRepository {
+	return &UserRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建用户，同一事务中写入 user.created 事件
 func (r *UserRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, models.EventUserCreated, models.AggregateUser, user.ID, userEventPayload(user))
+	})
 }
 
 // FindByID 根据ID查找用户
@@ -39,33 +51,53 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	return &user, err
 }
 
-// FindAll 查找所有用户（分页）
-func (r *UserRepository) FindAll(pagination *models.Pagination) ([]models.User, error) {
+// FindAll 查找所有用户（分页），按 q 过滤和排序
+func (r *UserRepository) FindAll(q *models.ListQuery, pagination *models.Pagination) ([]models.User, error) {
 	var users []models.User
 
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	query := q.ApplyFilters(r.db.Model(&models.User{}))
 
 	// 获取总数
-	r.db.Model(&models.User{}).Count(&pagination.Total)
+	query.Count(&pagination.Total)
 
 	// 分页查询
-	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
+	offset := pagination.GetOffset()
+	limit := pagination.GetLimit()
+	err := q.ApplySort(query).Offset(offset).Limit(limit).Find(&users).Error
+
+	return users, err
+}
+
+// FindAllCursor 按游标分页查找用户，page.WithTotal 为 false 时不查询总数
+func (r *UserRepository) FindAllCursor(q *models.ListQuery, page *models.CursorPage) ([]models.User, error) {
+	var users []models.User
+	err := models.FindCursorPage(r.db.Model(&models.User{}), q, models.UserQuerySchema, page, &users)
 	return users, err
 }
 
-// Update 更新用户
+// Update 更新用户，同一事务中写入 user.updated 事件
 func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, models.EventUserUpdated, models.AggregateUser, user.ID, userEventPayload(user))
+	})
 }
 
-// Delete 删除用户（软删除）
+// Delete 删除用户（软删除），用户存在时同一事务中写入 user.deleted 事件
 func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return recordEvent(tx, models.EventUserDeleted, models.AggregateUser, id, map[string]uint{"id": id})
+	})
 }
 
 // Search 搜索用户
-func (r *UserRepository) Search(keyword string, pagination *models.Pagination) ([]models.User, error) {
+func (r *UserRepository) Search(keyword string, q *models.ListQuery, pagination *models.Pagination) ([]models.User, error) {
 	var users []models.User
 
 	query := r.db.Model(&models.User{})
@@ -73,6 +105,7 @@ func (r *UserRepository) Search(keyword string, pagination *models.Pagination) (
 		query = query.Where("username LIKE ? OR email LIKE ? OR full_name LIKE ?",
 			"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
 	}
+	query = q.ApplyFilters(query)
 
 	// 获取总数
 	query.Count(&pagination.Total)
@@ -80,7 +113,7 @@ func (r *UserRepository) Search(keyword string, pagination *models.Pagination) (
 	// 分页查询
 	offset := pagination.GetOffset()
 	limit := pagination.GetLimit()
-	err := query.Offset(offset).Limit(limit).Find(&users).Error
+	err := q.ApplySort(query).Offset(offset).Limit(limit).Find(&users).Error
 
 	return users, err
 }
@@ -12,7 +12,7 @@ import (
 
 func setupTestDB() *gorm.DB {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&models.User{})
+	db.AutoMigrate(&models.User{}, &models.OutboxEvent{})
 	return db
 }
 
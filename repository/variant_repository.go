@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSKUTaken SKU 已被其他产品或变体使用
+	ErrSKUTaken = errors.New("sku already exists")
+	// ErrDuplicateVariant 产品下已有相同属性组合的变体
+	ErrDuplicateVariant = errors.New("a variant with the same attributes already exists")
+	// ErrProductHasStock 产品自身仍有库存或预留，不能转为按变体管理库存
+	ErrProductHasStock = errors.New("product has stock of its own, adjust it to zero before adding variants")
+	// ErrVariantInUse 变体仍有预留库存
+	ErrVariantInUse = errors.New("variant has reserved stock")
+)
+
+type VariantRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) *VariantRepository {
+	return &VariantRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *VariantRepository) WithContext(ctx context.Context) *VariantRepository {
+	return &VariantRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 为产品创建变体，初始库存记入库存流水并计入产品库存合计。
+// 产品的第一个变体创建前，产品自身的库存和预留必须为 0
+func (r *VariantRepository) Create(variant *models.ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 && (product.Stock != 0 || product.Reserved != 0) {
+			return ErrProductHasStock
+		}
+		if variant.Stock < 0 {
+			return ErrInsufficientStock
+		}
+
+		variant.ID = 0
+		variant.Reserved = 0
+		if err := prepareVariant(tx, product, variant); err != nil {
+			return err
+		}
+		stock := variant.Stock
+		variant.Stock = 0
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+
+		if stock > 0 {
+			if err := applyStockChange(tx, product, variant, stock, 0, ""); err != nil {
+				return err
+			}
+			_, err := recordMovement(tx, product, variant, models.StockMovementAdjust, stock, models.StockChange{
+				Reason: "initial stock",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return refreshVariantTerms(tx, product.ID)
+	})
+}
+
+// FindByProduct 查找产品的所有变体
+func (r *VariantRepository) FindByProduct(productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	err := r.db.Where("product_id = ?", productID).Order("sort_order").Order("id").Find(&variants).Error
+	return variants, err
+}
+
+// FindByID 查找属于产品的变体
+func (r *VariantRepository) FindByID(productID, id uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := r.db.Where("product_id = ?", productID).First(&variant, id).Error
+	return &variant, err
+}
+
+// FindBySKU 根据 SKU 查找变体
+func (r *VariantRepository) FindBySKU(sku string) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := r.db.Where("sku = ?", sku).First(&variant).Error
+	return &variant, err
+}
+
+// Update 更新变体，库存和预留数量只能通过库存操作修改
+func (r *VariantRepository) Update(variant *models.ProductVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := prepareVariant(tx, product, variant); err != nil {
+			return err
+		}
+		// Save 会为 nil 的嵌入价格分配零值并写入 0，取消覆盖价格时需显式清空
+		clearPrice := variant.Price == nil
+		if err := tx.Omit("stock", "reserved").Save(variant).Error; err != nil {
+			return err
+		}
+		if clearPrice {
+			err := tx.Model(&models.ProductVariant{}).Where("id = ?", variant.ID).
+				UpdateColumns(map[string]interface{}{"price_minor": nil, "price_currency": nil}).Error
+			if err != nil {
+				return err
+			}
+			variant.Price = nil
+		}
+		return refreshVariantTerms(tx, product.ID)
+	})
+}
+
+// Delete 删除变体，剩余库存从产品库存合计中扣除并记入流水；有预留时返回 ErrVariantInUse
+func (r *VariantRepository) Delete(productID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		variant, err := lockVariant(tx, productID, id)
+		if err != nil {
+			return err
+		}
+		if variant.Reserved > 0 {
+			return ErrVariantInUse
+		}
+
+		if variant.Stock != 0 {
+			removed := -variant.Stock
+			if err := applyStockChange(tx, product, variant, removed, 0, ""); err != nil {
+				return err
+			}
+			_, err := recordMovement(tx, product, variant, models.StockMovementAdjust, removed, models.StockChange{
+				Reason:    "variant deleted",
+				Reference: "sku:" + variant.SKU,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(variant).Error; err != nil {
+			return err
+		}
+		return refreshVariantTerms(tx, productID)
+	})
+}
+
+// prepareVariant 校验覆盖价格、SKU 唯一性、属性定义以及同一产品下属性组合不重复
+func prepareVariant(tx *gorm.DB, product *models.Product, variant *models.ProductVariant) error {
+	if variant.Price != nil {
+		if err := validatePrice(variant.Price, true); err != nil {
+			return err
+		}
+	}
+	variant.SKU = strings.TrimSpace(variant.SKU)
+	variant.Barcode = strings.TrimSpace(variant.Barcode)
+	if variant.Attributes == nil {
+		variant.Attributes = models.Attributes{}
+	}
+
+	err := checkSKUAvailable(tx, variant.SKU, 0, variant.ID)
+	if err != nil {
+		return err
+	}
+
+	var definitions []models.AttributeDefinition
+	if product.CategoryID != nil {
+		if definitions, err = effectiveAttributes(tx, *product.CategoryID); err != nil {
+			return err
+		}
+	}
+	if err := models.ValidateAttributes(variant.Attributes, definitions); err != nil {
+		return err
+	}
+
+	var siblings []models.ProductVariant
+	err = tx.Select("id", "attributes").
+		Where("product_id = ? AND id <> ?", product.ID, variant.ID).
+		Find(&siblings).Error
+	if err != nil {
+		return err
+	}
+	key := variant.Attributes.Key()
+	for _, sibling := range siblings {
+		if sibling.Attributes.Key() == key {
+			return ErrDuplicateVariant
+		}
+	}
+	return nil
+}
+
+// refreshVariantTerms 重新生成产品的变体搜索内容，全文索引随产品行更新
+func refreshVariantTerms(tx *gorm.DB, productID uint) error {
+	var variants []models.ProductVariant
+	if err := tx.Where("product_id = ?", productID).Order("sort_order").Order("id").Find(&variants).Error; err != nil {
+		return err
+	}
+
+	var terms []string
+	for i := range variants {
+		terms = append(terms, variants[i].SearchTerms()...)
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).
+		UpdateColumn("variant_terms", strings.Join(terms, " ")).Error
+}
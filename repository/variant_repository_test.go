@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariantRepository_StockAndAttributes(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&models.Category{}, &models.AttributeDefinition{}, &models.Product{}, &models.ProductVariant{},
+		&models.StockMovement{}, &models.StockReservation{})
+	categories := NewCategoryRepository(db)
+	attributes := NewAttributeRepository(db)
+	products := NewProductRepository(db)
+	repo := NewVariantRepository(db)
+
+	// 子分类继承祖先分类的属性定义
+	apparel := &models.Category{Name: "Apparel"}
+	require.NoError(t, categories.Create(apparel))
+	shirts := &models.Category{Name: "Shirts", ParentID: &apparel.ID}
+	require.NoError(t, categories.Create(shirts))
+	require.NoError(t, attributes.Create(&models.AttributeDefinition{
+		CategoryID: apparel.ID, Code: "color", Name: "Color", Type: models.AttributeString, Required: true,
+	}))
+	require.NoError(t, attributes.Create(&models.AttributeDefinition{
+		CategoryID: shirts.ID, Code: "size", Name: "Size", Type: models.AttributeEnum, Options: models.StringList{"S", "M", "L"},
+	}))
+	effective, err := attributes.FindEffective(shirts.ID)
+	require.NoError(t, err)
+	assert.Len(t, effective, 2)
+
+	product := &models.Product{Name: "T-Shirt", Price: models.NewMoney(2000, "USD"), CategoryID: &shirts.ID}
+	require.NoError(t, products.Create(product))
+
+	price := models.NewMoney(2500, "USD")
+	red := &models.ProductVariant{ProductID: product.ID, SKU: "TS-RED-M", Price: &price, Stock: 5,
+		Attributes: models.Attributes{"color": "red", "size": "M"}}
+	require.NoError(t, repo.Create(red))
+	blue := &models.ProductVariant{ProductID: product.ID, SKU: "TS-BLUE-L", Barcode: "4006381333931", Stock: 3,
+		Attributes: models.Attributes{"color": "blue", "size": "L"}}
+	require.NoError(t, repo.Create(blue))
+
+	// 属性按定义校验，SKU 和属性组合不能重复
+	assert.ErrorIs(t, repo.Create(&models.ProductVariant{ProductID: product.ID, SKU: "TS-X",
+		Attributes: models.Attributes{"color": "red", "size": "XL"}}), models.ErrInvalidAttribute)
+	assert.ErrorIs(t, repo.Create(&models.ProductVariant{ProductID: product.ID, SKU: "TS-Y",
+		Attributes: models.Attributes{"size": "S"}}), models.ErrInvalidAttribute)
+	assert.ErrorIs(t, repo.Create(&models.ProductVariant{ProductID: product.ID, SKU: "TS-RED-M",
+		Attributes: models.Attributes{"color": "green"}}), ErrSKUTaken)
+	assert.ErrorIs(t, repo.Create(&models.ProductVariant{ProductID: product.ID, SKU: "TS-RED-M-2",
+		Attributes: models.Attributes{"size": "M", "color": "red"}}), ErrDuplicateVariant)
+
+	// 产品库存为变体合计，有变体时产品级库存操作必须指定变体
+	found, err := products.FindByID(product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 8, found.Stock)
+	assert.Contains(t, found.VariantTerms, "TS-BLUE-L")
+	assert.Contains(t, found.VariantTerms, "4006381333931")
+	_, err = products.Reserve(product.ID, 1, models.StockChange{Reference: "order-1"})
+	assert.ErrorIs(t, err, ErrVariantRequired)
+
+	_, err = products.ReserveVariant(product.ID, red.ID, 4, models.StockChange{Reference: "order-1"})
+	require.NoError(t, err)
+	_, err = products.ReserveVariant(product.ID, red.ID, 2, models.StockChange{Reference: "order-2"})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	_, err = products.CommitReservation(models.StockChange{Reference: "order-1"})
+	require.NoError(t, err)
+
+	red, err = repo.FindByID(product.ID, red.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, red.Stock)
+	assert.Equal(t, 0, red.Reserved)
+	assert.Equal(t, models.NewMoney(2500, "USD"), *red.Price)
+
+	// 取消覆盖价格后使用产品价格
+	red.Price = nil
+	require.NoError(t, repo.Update(red))
+	red, _ = repo.FindByID(product.ID, red.ID)
+	assert.Nil(t, red.Price)
+	assert.Equal(t, product.Price, red.EffectivePrice(product))
+	found, _ = products.FindByID(product.ID)
+	assert.Equal(t, 4, found.Stock)
+	assert.Equal(t, 0, found.Reserved)
+
+	var pagination models.Pagination
+	movements, err := products.FindStockMovements(product.ID, &red.ID, &pagination)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pagination.Total)
+	assert.Equal(t, 1, movements[0].StockAfter)
+
+	// 删除变体时剩余库存从产品合计中扣除
+	require.NoError(t, repo.Delete(product.ID, blue.ID))
+	found, _ = products.FindByID(product.ID)
+	assert.Equal(t, 1, found.Stock)
+	assert.NotContains(t, found.VariantTerms, "TS-BLUE-L")
+
+	// 产品自身有库存时不能添加第一个变体
+	plain := &models.Product{Name: "Socks", Price: models.NewMoney(500, "USD"), Stock: 10, CategoryID: &shirts.ID}
+	require.NoError(t, products.Create(plain))
+	assert.ErrorIs(t, repo.Create(&models.ProductVariant{ProductID: plain.ID, SKU: "SOCKS-M",
+		Attributes: models.Attributes{"color": "black"}}), ErrProductHasStock)
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// ErrWebhookDisabled webhook 已停用，不能投递或重放
+var ErrWebhookDisabled = errors.New("webhook is disabled")
+
+// DeliveryFilter 投递记录过滤条件，Success 为 nil 时不过滤
+type DeliveryFilter struct {
+	Success *bool
+}
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// WithContext 返回使用 ctx 执行查询的仓库，ctx 中的请求ID会出现在 SQL 日志中
+func (r *WebhookRepository) WithContext(ctx context.Context) *WebhookRepository {
+	return &WebhookRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建 webhook
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// FindByID 根据ID查找 webhook
+func (r *WebhookRepository) FindByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.First(&webhook, id).Error
+	return &webhook, err
+}
+
+// FindAll 获取 webhook 列表（分页）
+func (r *WebhookRepository) FindAll(pagination *models.Pagination) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Model(&models.Webhook{}).Count(&pagination.Total).Error; err != nil {
+		return nil, err
+	}
+	err := r.db.Order("id").Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// FindSubscribed 查找订阅了该事件类型的启用中的 webhook
+func (r *WebhookRepository) FindSubscribed(eventType string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Where("active = ?", true).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	subscribed := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(eventType) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+// Update 更新 webhook
+func (r *WebhookRepository) Update(webhook *models.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// Delete 删除 webhook（软删除），投递记录保留
+func (r *WebhookRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Webhook{}, id).Error
+}
+
+// RecordDelivery 保存一次投递尝试并更新 webhook 的连续失败次数。
+// 连续失败达到 disableAfter 次时停用 webhook，返回 disabled 为 true；disableAfter 为 0 时不自动停用
+func (r *WebhookRepository) RecordDelivery(delivery *models.WebhookDelivery, disableAfter int, now time.Time) (disabled bool, err error) {
+	now = now.UTC()
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+		webhook := tx.Model(&models.Webhook{}).Where("id = ?", delivery.WebhookID)
+		if delivery.Success {
+			return webhook.Updates(map[string]interface{}{
+				"consecutive_failures": 0,
+				"last_delivery_at":     now,
+			}).Error
+		}
+		err := webhook.Updates(map[string]interface{}{
+			"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+			"last_delivery_at":     now,
+		}).Error
+		if err != nil || disableAfter <= 0 {
+			return err
+		}
+
+		result := tx.Model(&models.Webhook{}).
+			Where("id = ? AND active = ? AND consecutive_failures >= ?", delivery.WebhookID, true, disableAfter).
+			Updates(map[string]interface{}{
+				"active":          false,
+				"disabled_at":     now,
+				"disabled_reason": fmt.Sprintf("disabled after %d consecutive failed deliveries", disableAfter),
+			})
+		disabled = result.RowsAffected > 0
+		return result.Error
+	})
+	return disabled, err
+}
+
+// FindDeliveries 获取 webhook 的投递记录（分页），最新的在前
+func (r *WebhookRepository) FindDeliveries(webhookID uint, filter DeliveryFilter, pagination *models.Pagination) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if filter.Success != nil {
+		query = query.Where("success = ?", *filter.Success)
+	}
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		return nil, err
+	}
+	err := query.Order("id DESC").Offset(pagination.GetOffset()).Limit(pagination.GetLimit()).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// FindDelivery 查找 webhook 的一条投递记录
+func (r *WebhookRepository) FindDelivery(webhookID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Where("webhook_id = ?", webhookID).First(&delivery, id).Error
+	return &delivery, err
+}
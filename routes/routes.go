@@ -1,61 +1,311 @@
 package routes
 
 import (
+	"log"
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/config"
 	"github.com/fangyanlin/gin-gorm-app/controller"
+	"github.com/fangyanlin/gin-gorm-app/health"
+	"github.com/fangyanlin/gin-gorm-app/media"
+	"github.com/fangyanlin/gin-gorm-app/metrics"
 	"github.com/fangyanlin/gin-gorm-app/middleware"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/ratelimit"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/fangyanlin/gin-gorm-app/storage"
+	"github.com/fangyanlin/gin-gorm-app/utils"
+	"github.com/fangyanlin/gin-gorm-app/webhooks"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// Dependencies 路由所依赖的组件，由 main 创建
+type Dependencies struct {
+	DB             *gorm.DB
+	Config         *config.Config
+	Tokens         *utils.TokenService
+	RateLimitStore ratelimit.Store
+	Storage        storage.Storage
+	// Webhooks webhook 投递服务，为 nil 时使用默认配置
+	Webhooks *webhooks.Service
+	// Metrics 指标注册表，为 nil 时不导出指标
+	Metrics *metrics.Registry
+	// Health 存活和就绪检查，为 nil 时只检查数据库连接
+	Health *health.Registry
+	// APIKeys 限流 api_key 维度使用的 API key 校验，为 nil 时该维度退化为按 IP 限流
+	APIKeys middleware.APIKeyValidator
+}
+
 // SetupRoutes 设置路由
-func SetupRoutes(router *gin.Engine, db *gorm.DB) {
+func SetupRoutes(router *gin.Engine, deps Dependencies) {
+	db := deps.DB
+	tokens := deps.Tokens
+
 	// 初始化控制器
-	userController := controller.NewUserController(db)
-	productController := controller.NewProductController(db)
-
-	// 健康检查
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "Server is running",
+	cursors := utils.NewCursorCodec(deps.Config.Pagination.CursorSecret)
+	userController := controller.NewUserController(db, cursors)
+	productController := controller.NewProductController(db, cursors)
+	productBulkController := controller.NewProductBulkController(db)
+	categoryController := controller.NewCategoryController(db)
+	variantController := controller.NewVariantController(db)
+	priceController := controller.NewPriceController(db)
+	attributeController := controller.NewAttributeController(db)
+	authController := controller.NewAuthController(db, tokens)
+	roleController := controller.NewRoleController(db)
+	inventoryController := controller.NewInventoryController(db)
+	orderController := controller.NewOrderController(db)
+	cartController := controller.NewCartController(db)
+	imageController := controller.NewImageController(db, deps.Storage, imageOptions(deps))
+	jobController := controller.NewJobController(db)
+	webhookService := deps.Webhooks
+	if webhookService == nil {
+		webhookService = webhooks.NewService(db, webhooks.Config{})
+	}
+	webhookController := controller.NewWebhookController(db, webhookService)
+	checks := deps.Health
+	if checks == nil {
+		checks = health.NewRegistry(health.Config{})
+		checks.Register("database", health.DBPing(db))
+	}
+	healthController := controller.NewHealthController(checks)
+
+	// 认证与权限中间件
+	authRequired := middleware.AuthMiddleware(tokens)
+	authOptional := middleware.OptionalAuthMiddleware(tokens)
+	permissions := repository.NewRoleRepository(db)
+	canWriteUsers := middleware.RequirePermissions(permissions, models.PermissionUsersWrite)
+	canWriteProducts := middleware.RequirePermissions(permissions, models.PermissionProductsWrite)
+	canManageRoles := middleware.RequirePermissions(permissions, models.PermissionRolesManage)
+	canManageOrders := middleware.RequirePermissions(permissions, models.PermissionOrdersManage)
+	canManageJobs := middleware.RequirePermissions(permissions, models.PermissionJobsManage)
+	canManageWebhooks := middleware.RequirePermissions(permissions, models.PermissionWebhooksManage)
+
+	// 健康检查：/livez 存活探针，/readyz 就绪探针，/health 保留为就绪探针的别名
+	router.GET("/livez", healthController.Livez)
+	router.GET("/readyz", healthController.Readyz)
+	router.GET("/health", healthController.Readyz)
+
+	// Prometheus 指标
+	if deps.Metrics != nil {
+		router.GET(deps.Config.Metrics.Path, gin.WrapH(deps.Metrics.Handler()))
+	}
+
+	// 本地存储的上传文件，禁止浏览器猜测内容类型
+	if local, ok := deps.Storage.(*storage.Local); ok && strings.HasPrefix(local.PublicURL(), "/") {
+		uploads := router.Group(strings.TrimRight(local.PublicURL(), "/"))
+		uploads.Use(func(c *gin.Context) {
+			c.Header("X-Content-Type-Options", "nosniff")
+			c.Next()
 		})
-	})
+		uploads.Static("/", local.Root())
+	}
 
 	// API v1 路由组
 	v1 := router.Group("/api/v1")
+	v1.Use(rateLimit(deps, "default"))
 	{
+		// 认证路由
+		auth := v1.Group("/auth")
+		auth.Use(rateLimit(deps, "auth"))
+		{
+			auth.POST("/login", authController.Login)
+			auth.POST("/refresh", authController.Refresh)
+			auth.POST("/logout", authController.Logout)
+		}
+
 		// 用户路由
 		users := v1.Group("/users")
+		users.Use(rateLimit(deps, "users"))
 		{
-			users.POST("", userController.CreateUser)
+			users.POST("", authRequired, canWriteUsers, userController.CreateUser)
 			users.GET("", userController.GetUsers)
 			users.GET("/search", userController.SearchUsers)
 			users.GET("/:id", userController.GetUser)
-			users.PUT("/:id", userController.UpdateUser)
-			users.DELETE("/:id", userController.DeleteUser)
+			users.PUT("/:id", authRequired, canWriteUsers, userController.UpdateUser)
+			users.DELETE("/:id", authRequired, canWriteUsers, userController.DeleteUser)
 		}
 
 		// 产品路由
 		products := v1.Group("/products")
+		products.Use(rateLimit(deps, "products"))
 		{
-			products.POST("", productController.CreateProduct)
+			products.POST("", authRequired, canWriteProducts, productController.CreateProduct)
 			products.GET("", productController.GetProducts)
 			products.GET("/search", productController.SearchProducts)
+			products.GET("/export", authRequired, canWriteProducts, productBulkController.ExportProducts)
+			products.POST("/import", authRequired, canWriteProducts, productBulkController.ImportProducts)
 			products.GET("/category/:category", productController.GetProductsByCategory)
 			products.GET("/:id", productController.GetProduct)
-			products.PUT("/:id", productController.UpdateProduct)
-			products.DELETE("/:id", productController.DeleteProduct)
+			products.PUT("/:id", authRequired, canWriteProducts, productController.UpdateProduct)
+			products.DELETE("/:id", authRequired, canWriteProducts, productController.DeleteProduct)
+
+			// 产品变体
+			products.GET("/:id/variants", variantController.GetVariants)
+			products.GET("/:id/variants/:variant_id", variantController.GetVariant)
+			products.POST("/:id/variants", authRequired, canWriteProducts, variantController.CreateVariant)
+			products.PUT("/:id/variants/:variant_id", authRequired, canWriteProducts, variantController.UpdateVariant)
+			products.DELETE("/:id/variants/:variant_id", authRequired, canWriteProducts, variantController.DeleteVariant)
+
+			// 价目表
+			products.GET("/:id/prices", priceController.GetPrices)
+			products.PUT("/:id/prices/:currency", authRequired, canWriteProducts, priceController.SetPrice)
+			products.DELETE("/:id/prices/:currency", authRequired, canWriteProducts, priceController.DeletePrice)
+
+			// 产品图片
+			products.GET("/:id/images", imageController.GetImages)
+			products.POST("/:id/images", authRequired, canWriteProducts, imageController.UploadImage)
+			products.PUT("/:id/images/order", authRequired, canWriteProducts, imageController.ReorderImages)
+			products.PUT("/:id/images/:image_id", authRequired, canWriteProducts, imageController.UpdateImage)
+			products.DELETE("/:id/images/:image_id", authRequired, canWriteProducts, imageController.DeleteImage)
+
+			// 库存操作与流水
+			products.GET("/:id/stock/movements", authRequired, canWriteProducts, inventoryController.GetStockMovements)
+			products.POST("/:id/stock/adjust", authRequired, canWriteProducts, inventoryController.AdjustStock)
+			products.POST("/:id/stock/reserve", authRequired, canWriteProducts, inventoryController.ReserveStock)
+		}
+
+		// 分类路由
+		categories := v1.Group("/categories")
+		categories.Use(rateLimit(deps, "products"))
+		{
+			categories.GET("", categoryController.GetCategories)
+			categories.GET("/:id", categoryController.GetCategory)
+			categories.POST("", authRequired, canWriteProducts, categoryController.CreateCategory)
+			categories.PUT("/:id", authRequired, canWriteProducts, categoryController.UpdateCategory)
+			categories.DELETE("/:id", authRequired, canWriteProducts, categoryController.DeleteCategory)
+
+			// 分类属性定义
+			categories.GET("/:id/attributes", attributeController.GetAttributes)
+			categories.POST("/:id/attributes", authRequired, canWriteProducts, attributeController.CreateAttribute)
+			categories.PUT("/:id/attributes/:attribute_id", authRequired, canWriteProducts, attributeController.UpdateAttribute)
+			categories.DELETE("/:id/attributes/:attribute_id", authRequired, canWriteProducts, attributeController.DeleteAttribute)
+		}
+
+		// 库存预留路由
+		inventory := v1.Group("/inventory")
+		inventory.Use(rateLimit(deps, "products"), authRequired, canWriteProducts)
+		{
+			inventory.GET("/reservations/:reference", inventoryController.GetReservations)
+			inventory.POST("/reservations/:reference/commit", inventoryController.CommitReservation)
+			inventory.POST("/reservations/:reference/release", inventoryController.ReleaseReservation)
+		}
+
+		// 订单路由，只能访问当前用户自己的订单
+		orders := v1.Group("/orders")
+		orders.Use(authRequired)
+		{
+			orders.POST("", orderController.CreateOrder)
+			orders.GET("", orderController.GetOrders)
+			orders.GET("/:id", orderController.GetOrder)
+			orders.POST("/:id/cancel", orderController.CancelOrder)
+			orders.DELETE("/:id", orderController.DeleteOrder)
+		}
+
+		// 购物车路由，匿名用户通过 X-Cart-Token 访问，结算需要登录
+		cart := v1.Group("/cart")
+		cart.Use(authOptional)
+		{
+			cart.GET("", cartController.GetCart)
+			cart.DELETE("", cartController.ClearCart)
+			cart.POST("/items", cartController.AddItem)
+			cart.PUT("/items/:product_id", cartController.UpdateItem)
+			cart.DELETE("/items/:product_id", cartController.RemoveItem)
+			cart.POST("/checkout", authRequired, cartController.Checkout)
+		}
+
+		// 订单管理路由
+		adminOrders := v1.Group("/admin/orders")
+		adminOrders.Use(rateLimit(deps, "admin"), authRequired, canManageOrders)
+		{
+			adminOrders.GET("", orderController.GetAllOrders)
+			adminOrders.PUT("/:id/status", orderController.UpdateOrderStatus)
+		}
+
+		// 后台任务管理路由
+		adminJobs := v1.Group("/admin/jobs")
+		adminJobs.Use(rateLimit(deps, "admin"), authRequired, canManageJobs)
+		{
+			adminJobs.GET("", jobController.GetJobs)
+			adminJobs.GET("/stats", jobController.GetJobStats)
+			adminJobs.GET("/:id", jobController.GetJob)
+			adminJobs.POST("/:id/retry", jobController.RetryJob)
+			adminJobs.POST("/:id/cancel", jobController.CancelJob)
+		}
+
+		// webhook 管理路由
+		adminWebhooks := v1.Group("/admin/webhooks")
+		adminWebhooks.Use(rateLimit(deps, "admin"), authRequired, canManageWebhooks)
+		{
+			adminWebhooks.GET("", webhookController.GetWebhooks)
+			adminWebhooks.POST("", webhookController.CreateWebhook)
+			adminWebhooks.GET("/:id", webhookController.GetWebhook)
+			adminWebhooks.PUT("/:id", webhookController.UpdateWebhook)
+			adminWebhooks.DELETE("/:id", webhookController.DeleteWebhook)
+			adminWebhooks.GET("/:id/deliveries", webhookController.GetDeliveries)
+			adminWebhooks.GET("/:id/deliveries/:delivery_id", webhookController.GetDelivery)
+			adminWebhooks.POST("/:id/deliveries/:delivery_id/replay", webhookController.ReplayDelivery)
+		}
+
+		// 角色与权限管理路由
+		admin := v1.Group("/admin")
+		admin.Use(rateLimit(deps, "admin"), authRequired, canManageRoles)
+		{
+			admin.GET("/roles", roleController.GetRoles)
+			admin.POST("/roles", roleController.CreateRole)
+			admin.GET("/roles/:id", roleController.GetRole)
+			admin.PUT("/roles/:id", roleController.UpdateRole)
+			admin.DELETE("/roles/:id", roleController.DeleteRole)
+			admin.PUT("/roles/:id/permissions", roleController.SetRolePermissions)
+			admin.GET("/permissions", roleController.GetPermissions)
+			admin.GET("/users/:id/roles", roleController.GetUserRoles)
+			admin.PUT("/users/:id/roles", roleController.SetUserRoles)
 		}
 	}
 
 	// 示例：使用认证中间件的路由组
 	authenticated := v1.Group("/protected")
-	authenticated.Use(middleware.AuthMiddleware())
+	authenticated.Use(authRequired)
 	{
 		authenticated.GET("/profile", func(c *gin.Context) {
+			userID, _ := middleware.GetUserID(c)
 			c.JSON(200, gin.H{
 				"message": "This is a protected route",
+				"user_id": userID,
 			})
 		})
 	}
 }
+
+// imageOptions 根据配置创建图片上传选项，缩略图规格错误时启动失败
+func imageOptions(deps Dependencies) controller.ImageOptions {
+	cfg := deps.Config.Upload
+	sizes, err := media.ParseSizes(cfg.ThumbnailSizes)
+	if err != nil {
+		log.Fatalf("Invalid IMAGE_THUMBNAIL_SIZES: %v", err)
+	}
+	return controller.ImageOptions{
+		MaxSize:   cfg.MaxSize,
+		MaxPixels: cfg.MaxPixels,
+		Sizes:     sizes,
+	}
+}
+
+// rateLimit 根据配置创建路由组的限流中间件，未配置规则时直接放行
+func rateLimit(deps Dependencies, group string) gin.HandlerFunc {
+	cfg := deps.Config.RateLimit
+	rule, ok := cfg.Rules[group]
+	if !cfg.Enabled || !ok || deps.RateLimitStore == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limiter, err := ratelimit.New(rule.Algorithm, deps.RateLimitStore, ratelimit.Rule{
+		Limit:  rule.Limit,
+		Window: rule.Window,
+		Burst:  rule.Burst,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create rate limiter for %s: %v", group, err)
+	}
+	return middleware.RateLimitMiddleware(limiter, group, middleware.RateLimitKeyBy(rule.KeyBy, deps.Tokens, deps.APIKeys))
+}
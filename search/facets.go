@@ -0,0 +1,89 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// computeFacets 在与搜索结果相同的范围内计算分面：分类分面使用一次 GROUP BY，
+// 可售状态和价格区间合并为一次条件计数，各方言通用
+func computeFacets(query *gorm.DB, req *models.FacetRequest) (*models.ProductFacets, error) {
+	facets := &models.ProductFacets{}
+
+	if req.Category {
+		facets.Category = []models.FacetCount{}
+		err := query.Session(&gorm.Session{}).
+			Select("products.category AS value, COUNT(*) AS count").
+			Group("products.category").
+			Order("COUNT(*) DESC").Order("products.category").
+			Limit(models.MaxCategoryFacets).
+			Scan(&facets.Category).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var columns []string
+	var args []interface{}
+	if req.Availability {
+		columns = append(columns,
+			"COUNT(CASE WHEN products.stock - products.reserved > 0 THEN 1 END)",
+			"COUNT(CASE WHEN products.stock - products.reserved <= 0 THEN 1 END)")
+	}
+	if req.Price {
+		for i := 0; i <= len(req.PriceBoundaries); i++ {
+			switch {
+			case len(req.PriceBoundaries) == 0:
+				columns = append(columns, "COUNT(*)")
+			case i == 0:
+				columns = append(columns, "COUNT(CASE WHEN products.price_minor < ? THEN 1 END)")
+				args = append(args, req.PriceBoundaries[i].Amount)
+			case i == len(req.PriceBoundaries):
+				columns = append(columns, "COUNT(CASE WHEN products.price_minor >= ? THEN 1 END)")
+				args = append(args, req.PriceBoundaries[i-1].Amount)
+			default:
+				columns = append(columns, "COUNT(CASE WHEN products.price_minor >= ? AND products.price_minor < ? THEN 1 END)")
+				args = append(args, req.PriceBoundaries[i-1].Amount, req.PriceBoundaries[i].Amount)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return facets, nil
+	}
+
+	counts, err := scanCounts(query.Session(&gorm.Session{}).Select(strings.Join(columns, ", "), args...), len(columns))
+	if err != nil {
+		return nil, err
+	}
+	if req.Availability {
+		facets.Availability = &models.AvailabilityFacet{InStock: counts[0], OutOfStock: counts[1]}
+		counts = counts[2:]
+	}
+	if req.Price {
+		facets.Price = req.PriceBuckets(counts)
+	}
+	return facets, nil
+}
+
+// scanCounts 读取只有一行的聚合查询结果
+func scanCounts(query *gorm.DB, n int) ([]int64, error) {
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]int64, n)
+	dest := make([]interface{}, n)
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// likeEngine 不依赖全文索引的 LIKE 搜索，按命中字段加权计算相关度，
+// 用于未编译 FTS5 的 SQLite 以及全文索引尚未由迁移创建的数据库
+type likeEngine struct {
+	db *gorm.DB
+}
+
+func (e *likeEngine) Name() string {
+	return "like"
+}
+
+func (e *likeEngine) Setup(ctx context.Context) error {
+	return nil
+}
+
+func (e *likeEngine) Search(ctx context.Context, req Request) (*Result, error) {
+	terms := Terms(req.Keyword)
+	if len(terms) == 0 {
+		return list(ctx, e.db, req)
+	}
+
+	base := e.db.Model(&models.Product{})
+	var scores []string
+	var args []interface{}
+	for _, term := range terms {
+		pattern := "%" + models.EscapeLike(term) + "%"
+		base = base.Where("(LOWER(products.name) LIKE ? ESCAPE '!' OR LOWER(products.description) LIKE ? ESCAPE '!' "+
+			"OR LOWER(products.category) LIKE ? ESCAPE '!' OR LOWER(products.variant_terms) LIKE ? ESCAPE '!')",
+			pattern, pattern, pattern, pattern)
+		scores = append(scores,
+			"CASE WHEN LOWER(products.name) LIKE ? ESCAPE '!' THEN 10 ELSE 0 END",
+			"CASE WHEN LOWER(products.category) LIKE ? ESCAPE '!' THEN 5 ELSE 0 END",
+			"CASE WHEN LOWER(products.variant_terms) LIKE ? ESCAPE '!' THEN 5 ELSE 0 END",
+			"CASE WHEN LOWER(products.description) LIKE ? ESCAPE '!' THEN 1 ELSE 0 END")
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+
+	return run(ctx, base, req, terms, "products.*, "+strings.Join(scores, " + ")+" AS score", args...)
+}
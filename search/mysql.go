@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// mysqlMatch 与 FULLTEXT 索引列一致的 MATCH 表达式
+const mysqlMatch = "MATCH(products.name, products.description, products.category, products.variant_terms) AGAINST (? IN BOOLEAN MODE)"
+
+// mysqlEngine 基于 InnoDB FULLTEXT 索引的搜索，索引由迁移创建、数据库自动维护。
+// MySQL 不提供高亮函数，片段在 Go 中生成
+type mysqlEngine struct {
+	db       *gorm.DB
+	fallback Engine
+	ready    atomic.Bool
+}
+
+func (e *mysqlEngine) Name() string {
+	return "mysql_fulltext"
+}
+
+func (e *mysqlEngine) Setup(ctx context.Context) error {
+	ok, err := indexed(e.db.WithContext(ctx), &e.ready, e.indexExists)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Println("MySQL FULLTEXT index idx_products_fulltext is missing or outdated (run database migrations), product search falls back to LIKE")
+	}
+	return nil
+}
+
+func (e *mysqlEngine) Search(ctx context.Context, req Request) (*Result, error) {
+	terms := Terms(req.Keyword)
+	if len(terms) == 0 {
+		return list(ctx, e.db, req)
+	}
+	ok, err := indexed(e.db.WithContext(ctx), &e.ready, e.indexExists)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return e.fallback.Search(ctx, req)
+	}
+
+	// 布尔模式下每个词项必须出现，并按前缀匹配
+	required := make([]string, len(terms))
+	for i, term := range terms {
+		required[i] = "+" + term + "*"
+	}
+	against := strings.Join(required, " ")
+
+	base := e.db.Model(&models.Product{}).Where(mysqlMatch, against)
+	return run(ctx, base, req, terms, "products.*, "+mysqlMatch+" AS score", against)
+}
+
+// indexExists 检查 FULLTEXT 索引是否包含 mysqlMatch 的全部列，MATCH 的列必须与索引完全一致
+func (e *mysqlEngine) indexExists(db *gorm.DB) (bool, error) {
+	var columns []string
+	err := db.Raw(`SELECT column_name FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = 'products' AND index_name = 'idx_products_fulltext'`).
+		Scan(&columns).Error
+	if err != nil {
+		return false, err
+	}
+	for _, column := range columns {
+		if strings.EqualFold(column, "variant_terms") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+const (
+	postgresNameHeadline    = "StartSel=" + markStart + ", StopSel=" + markEnd + ", HighlightAll=true"
+	postgresSnippetHeadline = "StartSel=" + markStart + ", StopSel=" + markEnd + ", MaxWords=24, MinWords=8, MaxFragments=2, FragmentDelimiter=…"
+)
+
+// postgresEngine 基于 tsvector 生成列和 GIN 索引的搜索（需要 Postgres 12+），
+// 生成列和索引由迁移创建
+type postgresEngine struct {
+	db       *gorm.DB
+	fallback Engine
+	ready    atomic.Bool
+}
+
+func (e *postgresEngine) Name() string {
+	return "postgres_tsvector"
+}
+
+func (e *postgresEngine) Setup(ctx context.Context) error {
+	ok, err := indexed(e.db.WithContext(ctx), &e.ready, e.indexExists)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Println("Postgres column products.search_vector is missing or outdated (run database migrations), product search falls back to LIKE")
+	}
+	return nil
+}
+
+func (e *postgresEngine) Search(ctx context.Context, req Request) (*Result, error) {
+	terms := Terms(req.Keyword)
+	if len(terms) == 0 {
+		return list(ctx, e.db, req)
+	}
+	ok, err := indexed(e.db.WithContext(ctx), &e.ready, e.indexExists)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return e.fallback.Search(ctx, req)
+	}
+
+	// 词项只含字母和数字，可以安全地拼成 tsquery：每个词项前缀匹配，词项之间为 AND
+	prefixed := make([]string, len(terms))
+	for i, term := range terms {
+		prefixed[i] = term + ":*"
+	}
+
+	base := e.db.Model(&models.Product{}).
+		Joins("CROSS JOIN to_tsquery('simple', ?) AS search_query", strings.Join(prefixed, " & ")).
+		Where("products.search_vector @@ search_query")
+	return run(ctx, base, req, nil,
+		"products.*, ts_rank_cd(products.search_vector, search_query) AS score, "+
+			"ts_headline('simple', products.name, search_query, ?) AS name_highlight, "+
+			"ts_headline('simple', coalesce(products.description, ''), search_query, ?) AS snippet",
+		postgresNameHeadline, postgresSnippetHeadline)
+}
+
+// indexExists 检查 search_vector 生成列是否存在且包含变体列
+func (e *postgresEngine) indexExists(db *gorm.DB) (bool, error) {
+	var expression string
+	err := db.Raw(`SELECT coalesce(generation_expression, '') FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'search_vector'`).
+		Scan(&expression).Error
+	return strings.Contains(expression, "variant_terms"), err
+}
@@ -0,0 +1,244 @@
+// Package search 提供产品全文搜索，按数据库方言选择 SQLite FTS5、MySQL FULLTEXT
+// 或 Postgres tsvector 实现，结果按相关度排序并返回高亮片段
+package search
+
+import (
+	"context"
+	"html"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// 数据库返回的片段先用控制字符标记命中位置，做 HTML 转义后再替换为 <mark>，
+// 避免产品描述中的 HTML 原样输出
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+const (
+	// maxTerms 单次搜索最多使用的词项数
+	maxTerms = 10
+	// snippetRunes Go 侧生成片段的最大长度
+	snippetRunes = 120
+)
+
+// Request 搜索请求，Query 中的过滤和排序条件作用于同一范围，排序在相关度之后生效
+type Request struct {
+	Keyword string
+	Query   *models.ListQuery
+	Offset  int
+	Limit   int
+	// Facets 需要计算的分面，nil 表示不计算
+	Facets *models.FacetRequest
+}
+
+// Result 搜索结果
+type Result struct {
+	Hits   []models.ProductSearchHit
+	Total  int64
+	Facets *models.ProductFacets
+}
+
+// Engine 全文搜索后端
+type Engine interface {
+	// Name 后端名称
+	Name() string
+	// Setup 检查迁移创建的全文索引是否可用，启动时调用；索引缺失时搜索退回 LIKE
+	Setup(ctx context.Context) error
+	// Search 执行搜索，关键词为空时按 Query 的排序列出所有产品
+	Search(ctx context.Context, req Request) (*Result, error)
+}
+
+// New 根据数据库方言创建搜索后端
+func New(db *gorm.DB) Engine {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return &mysqlEngine{db: db, fallback: &likeEngine{db: db}}
+	case "postgres":
+		return &postgresEngine{db: db, fallback: &likeEngine{db: db}}
+	case "sqlite":
+		return &sqliteEngine{db: db, fallback: &likeEngine{db: db}}
+	default:
+		return &likeEngine{db: db}
+	}
+}
+
+// indexed 返回全文索引是否可用。索引由迁移创建，未就绪时每次调用都重新检查，
+// 迁移在其他进程中执行后无需重启即可切换到全文搜索
+func indexed(db *gorm.DB, ready *atomic.Bool, exists func(db *gorm.DB) (bool, error)) (bool, error) {
+	if ready.Load() {
+		return true, nil
+	}
+	ok, err := exists(db)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		ready.Store(true)
+	}
+	return ok, nil
+}
+
+// Terms 将关键词拆分为只包含字母和数字的词项，丢弃各数据库全文检索语法中的特殊字符
+func Terms(keyword string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(keyword), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(fields) > maxTerms {
+		fields = fields[:maxTerms]
+	}
+	return fields
+}
+
+// row 搜索结果行，包含产品字段以及相关度和高亮列
+type row struct {
+	models.Product
+	Score         float64
+	NameHighlight string
+	Snippet       string
+}
+
+func (r *row) hit() models.ProductSearchHit {
+	return models.ProductSearchHit{
+		Product: r.Product,
+		Score:   r.Score,
+		Highlight: models.ProductHighlight{
+			Name:    renderMarks(r.NameHighlight),
+			Snippet: renderMarks(r.Snippet),
+		},
+	}
+}
+
+// run 在 base 的范围内应用过滤条件，统计总数和分面并按相关度分页查询。
+// highlightTerms 非空时在 Go 中生成高亮，用于数据库不支持高亮的后端
+func run(ctx context.Context, base *gorm.DB, req Request, highlightTerms []string, selectSQL string, args ...interface{}) (*Result, error) {
+	query := req.Query.ApplyFilters(base.WithContext(ctx))
+
+	result := &Result{}
+	if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+	if req.Facets != nil {
+		facets, err := computeFacets(query, req.Facets)
+		if err != nil {
+			return nil, err
+		}
+		result.Facets = facets
+	}
+
+	var rows []row
+	err := req.Query.ApplySort(query.Select(selectSQL, args...).Order("score DESC")).
+		Offset(req.Offset).Limit(req.Limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result.Hits = make([]models.ProductSearchHit, 0, len(rows))
+	for i := range rows {
+		if len(highlightTerms) > 0 {
+			rows[i].NameHighlight = markTerms(rows[i].Name, highlightTerms)
+			rows[i].Snippet = snippet(rows[i].Description, highlightTerms)
+		}
+		result.Hits = append(result.Hits, rows[i].hit())
+	}
+	return result, nil
+}
+
+// list 关键词为空时按过滤和排序条件列出产品
+func list(ctx context.Context, db *gorm.DB, req Request) (*Result, error) {
+	return run(ctx, db.Model(&models.Product{}), req, nil,
+		"products.*, 0 AS score, '' AS name_highlight, '' AS snippet")
+}
+
+// renderMarks 转义 HTML 并将控制字符标记替换为 <mark>
+func renderMarks(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>").Replace(s)
+}
+
+// markTerms 不区分大小写地标记 text 中出现的词项
+func markTerms(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// 大小写转换改变了字节长度时无法按位置对应，不做高亮
+		return text
+	}
+
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for start := 0; ; {
+			i := strings.Index(lower[start:], term)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(term); j++ {
+				marked[j] = true
+			}
+			start += i + len(term)
+		}
+	}
+
+	var b strings.Builder
+	inMark := false
+	for i := 0; i < len(text); i++ {
+		if marked[i] != inMark {
+			if marked[i] {
+				b.WriteString(markStart)
+			} else {
+				b.WriteString(markEnd)
+			}
+			inMark = marked[i]
+		}
+		b.WriteByte(text[i])
+	}
+	if inMark {
+		b.WriteString(markEnd)
+	}
+	return b.String()
+}
+
+// snippet 截取 text 中第一个命中词项附近的片段并标记词项
+func snippet(text string, terms []string) string {
+	if text == "" {
+		return ""
+	}
+	lower := strings.ToLower(text)
+	first := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+	if len(lower) != len(text) {
+		first = 0
+	}
+
+	// 命中位置前保留约四分之一的上下文
+	runeStart := utf8.RuneCountInString(text[:first]) - snippetRunes/4
+	if runeStart < 0 {
+		runeStart = 0
+	}
+	runes := []rune(text)
+	runeEnd := runeStart + snippetRunes
+	if runeEnd > len(runes) {
+		runeEnd = len(runes)
+	}
+
+	out := markTerms(string(runes[runeStart:runeEnd]), terms)
+	if runeStart > 0 {
+		out = "…" + out
+	}
+	if runeEnd < len(runes) {
+		out += "…"
+	}
+	return out
+}
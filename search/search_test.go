@@ -0,0 +1,167 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/fangyanlin/gin-gorm-app/database"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSearchDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Product{}))
+	return db
+}
+
+func seedProducts(t *testing.T, db *gorm.DB) {
+	products := []models.Product{
+		{Name: "Mechanical Keyboard", Description: "Hot-swappable <b>switches</b>", Category: "peripherals", Price: models.NewMoney(9900, "USD"), Stock: 5},
+		{Name: "Wireless Mouse", Description: "Pairs with any keyboard", Category: "peripherals", Price: models.NewMoney(2900, "USD"), Stock: 0},
+		{Name: "USB Cable", Description: "Braided cable", Category: "accessories", Price: models.NewMoney(900, "USD"), Stock: 100},
+	}
+	require.NoError(t, db.Create(&products).Error)
+}
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"usb", "c", "cable"}, Terms(` USB-C "cable"* `))
+	assert.Empty(t, Terms(`"*:()`))
+	assert.Len(t, Terms("a b c d e f g h i j k l"), maxTerms)
+}
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	marked := markTerms("<b>Keyboard</b> keys", []string{"key"})
+	assert.Equal(t, "&lt;b&gt;<mark>Key</mark>board&lt;/b&gt; <mark>key</mark>s", renderMarks(marked))
+	assert.Equal(t, "no match", renderMarks(snippet("no match", []string{"key"})))
+}
+
+func runEngineTests(t *testing.T, db *gorm.DB, engine Engine) {
+	ctx := context.Background()
+
+	// 名称命中的产品排在仅描述命中的产品之前
+	result, err := engine.Search(ctx, Request{Keyword: "keyboard", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 2)
+	assert.Equal(t, int64(2), result.Total)
+	assert.Equal(t, "Mechanical Keyboard", result.Hits[0].Product.Name)
+	assert.Greater(t, result.Hits[0].Score, result.Hits[1].Score)
+	assert.Contains(t, result.Hits[0].Highlight.Name, "<mark>Keyboard</mark>")
+	assert.Contains(t, result.Hits[1].Highlight.Snippet, "<mark>keyboard</mark>")
+
+	// 前缀匹配，且过滤条件作用于同一范围
+	q, err := models.ParseListQuery(url.Values{"stock[gt]": {"0"}}, models.ProductQuerySchema)
+	require.NoError(t, err)
+	result, err = engine.Search(ctx, Request{Keyword: "keyb", Query: q, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, int64(1), result.Total)
+
+	// 更新和删除后索引同步
+	require.NoError(t, db.Model(&models.Product{}).Where("name = ?", "USB Cable").Update("name", "USB Keyboard Cable").Error)
+	require.NoError(t, db.Where("name = ?", "Wireless Mouse").Delete(&models.Product{}).Error)
+	result, err = engine.Search(ctx, Request{Keyword: "keyboard", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	for _, hit := range result.Hits {
+		assert.NotEqual(t, "Wireless Mouse", hit.Product.Name)
+	}
+
+	// 变体的 SKU 和属性值可被搜索
+	require.NoError(t, db.Model(&models.Product{}).Where("name = ?", "Mechanical Keyboard").
+		Update("variant_terms", "KB-RED-87 red tenkeyless").Error)
+	result, err = engine.Search(ctx, Request{Keyword: "tenkeyless", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "Mechanical Keyboard", result.Hits[0].Product.Name)
+
+	// 空关键词按排序列出全部产品
+	result, err = engine.Search(ctx, Request{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+}
+
+func TestLikeEngine(t *testing.T) {
+	db := setupSearchDB(t)
+	seedProducts(t, db)
+	runEngineTests(t, db, &likeEngine{db: db})
+}
+
+func TestSQLiteEngine(t *testing.T) {
+	// FTS5 索引由迁移创建
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	migrator, err := database.NewMigrator(db, "sqlite")
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	seedProducts(t, db)
+
+	engine := New(db)
+	require.NoError(t, engine.Setup(context.Background()))
+	if exists, _ := engine.(*sqliteEngine).indexExists(db); !exists {
+		t.Skip("SQLite FTS5 is not available, run with -tags sqlite_fts5")
+	}
+	runEngineTests(t, db, engine)
+}
+
+func TestSQLiteEngine_FallsBackWithoutIndex(t *testing.T) {
+	// 未执行迁移时不创建索引，搜索退回 LIKE
+	db := setupSearchDB(t)
+	seedProducts(t, db)
+
+	engine := New(db)
+	require.NoError(t, engine.Setup(context.Background()))
+	exists, err := engine.(*sqliteEngine).indexExists(db)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	runEngineTests(t, db, engine)
+}
+
+func TestFacets(t *testing.T) {
+	db := setupSearchDB(t)
+	seedProducts(t, db)
+	require.NoError(t, db.Create(&models.Product{Name: "Keyboard Cover", Category: "accessories", Price: models.NewMoney(1500, "USD"), Stock: 2, Reserved: 2}).Error)
+	require.NoError(t, db.Create(&models.Product{Name: "Old Keyboard", Category: "peripherals", Price: models.NewMoney(1000, "USD")}).Error)
+	require.NoError(t, db.Where("name = ?", "Old Keyboard").Delete(&models.Product{}).Error)
+
+	facets, err := models.ParseFacetRequest(url.Values{"facets": {"category,availability,price"}, "price_buckets": {"50,20"}})
+	require.NoError(t, err)
+
+	// 分面与命中结果使用相同的关键词和过滤范围，已删除的产品不计入
+	result, err := New(db).Search(context.Background(), Request{Keyword: "keyboard", Limit: 1, Facets: facets})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Len(t, result.Hits, 1)
+
+	require.NotNil(t, result.Facets)
+	assert.Equal(t, []models.FacetCount{{Value: "peripherals", Count: 2}, {Value: "accessories", Count: 1}}, result.Facets.Category)
+	assert.Equal(t, &models.AvailabilityFacet{InStock: 1, OutOfStock: 2}, result.Facets.Availability)
+	require.Len(t, result.Facets.Price, 3)
+	assert.Nil(t, result.Facets.Price[0].Min)
+	assert.Equal(t, models.NewMoney(2000, "USD"), *result.Facets.Price[0].Max)
+	assert.Equal(t, []int64{1, 1, 1}, []int64{result.Facets.Price[0].Count, result.Facets.Price[1].Count, result.Facets.Price[2].Count})
+	assert.Nil(t, result.Facets.Price[2].Max)
+
+	q, err := models.ParseListQuery(url.Values{"category": {"accessories"}}, models.ProductQuerySchema)
+	require.NoError(t, err)
+	result, err = New(db).Search(context.Background(), Request{Query: q, Limit: 10, Facets: &models.FacetRequest{Category: true}})
+	require.NoError(t, err)
+	assert.Equal(t, []models.FacetCount{{Value: "accessories", Count: 2}}, result.Facets.Category)
+	assert.Nil(t, result.Facets.Availability)
+	assert.Nil(t, result.Facets.Price)
+
+	_, err = models.ParseFacetRequest(url.Values{"facets": {"brand"}})
+	assert.ErrorIs(t, err, models.ErrInvalidQuery)
+	_, err = models.ParseFacetRequest(url.Values{"facets": {"price"}, "price_buckets": {"10,abc"}})
+	assert.ErrorIs(t, err, models.ErrInvalidQuery)
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"gorm.io/gorm"
+)
+
+// sqliteEngine 基于 FTS5 的搜索，products_fts 及同步触发器由迁移创建。
+// FTS5 需要以 -tags sqlite_fts5 编译，不可用或索引尚未创建时退回 LIKE 搜索
+type sqliteEngine struct {
+	db       *gorm.DB
+	fallback Engine
+	ready    atomic.Bool
+}
+
+func (e *sqliteEngine) Name() string {
+	return "sqlite_fts5"
+}
+
+func (e *sqliteEngine) Setup(ctx context.Context) error {
+	db := e.db.WithContext(ctx)
+
+	var available int
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available).Error; err != nil {
+		return err
+	}
+	exists, err := indexed(db, &e.ready, e.indexExists)
+	if err != nil {
+		return err
+	}
+	switch {
+	case available == 0 && exists:
+		// 同步触发器引用 FTS5 表，缺少 FTS5 时写入产品将失败
+		return errors.New("products_fts exists but SQLite FTS5 is not available, rebuild with -tags sqlite_fts5")
+	case available == 0:
+		log.Println("SQLite FTS5 is not available (build with -tags sqlite_fts5), product search falls back to LIKE")
+	case !exists:
+		log.Println("SQLite FTS5 index products_fts is missing (migrations were applied without FTS5), product search falls back to LIKE")
+	}
+	return nil
+}
+
+func (e *sqliteEngine) Search(ctx context.Context, req Request) (*Result, error) {
+	terms := Terms(req.Keyword)
+	if len(terms) == 0 {
+		return list(ctx, e.db, req)
+	}
+	ok, err := indexed(e.db.WithContext(ctx), &e.ready, e.indexExists)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return e.fallback.Search(ctx, req)
+	}
+
+	// 每个词项按前缀匹配，词项之间为 AND
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"*`
+	}
+
+	base := e.db.Model(&models.Product{}).
+		Joins("JOIN products_fts ON products_fts.rowid = products.id").
+		Where("products_fts MATCH ?", strings.Join(quoted, " "))
+	return run(ctx, base, req, nil,
+		"products.*, -bm25(products_fts, 10.0, 1.0, 5.0, 5.0) AS score, "+
+			"highlight(products_fts, 0, char(2), char(3)) AS name_highlight, "+
+			"snippet(products_fts, 1, char(2), char(3), '…', 16) AS snippet")
+}
+
+func (e *sqliteEngine) indexExists(db *gorm.DB) (bool, error) {
+	var count int64
+	err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'products_fts'").Scan(&count).Error
+	return count > 0, err
+}
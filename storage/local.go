@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local 本地文件系统存储，对象保存在根目录下与键相同的相对路径中
+type Local struct {
+	root      string
+	publicURL string
+}
+
+// NewLocal 创建本地存储，根目录不存在时自动创建
+func NewLocal(root, publicURL string) (*Local, error) {
+	if root == "" {
+		root = "./uploads"
+	}
+	if publicURL == "" {
+		publicURL = "/uploads"
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{root: root, publicURL: publicURL}, nil
+}
+
+// Root 本地存储根目录，用于静态文件服务
+func (s *Local) Root() string {
+	return s.root
+}
+
+// PublicURL 公开访问地址前缀
+func (s *Local) PublicURL() string {
+	return s.publicURL
+}
+
+// Put 实现 Storage，先写入临时文件再重命名，避免读到写了一半的文件
+func (s *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Get 实现 Storage
+func (s *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete 实现 Storage
+func (s *Local) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL 实现 Storage
+func (s *Local) URL(key string) string {
+	return joinURL(s.publicURL, key)
+}
+
+func (s *Local) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
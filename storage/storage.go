@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey 对象键为空、以 / 开头或包含 .. 等路径穿越片段
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage 文件存储。键使用 / 分隔，例如 "products/1/abc.jpg"
+type Storage interface {
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get 读取对象，不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 对象的公开访问地址
+	URL(key string) string
+}
+
+// Config 存储配置
+type Config struct {
+	// Driver 存储驱动：local 或 s3
+	Driver string
+	// LocalDir 本地存储根目录
+	LocalDir string
+	// PublicURL 对象公开访问地址前缀，本地存储默认为 /uploads
+	PublicURL string
+	S3        S3Options
+}
+
+// New 根据配置创建存储
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		opts := cfg.S3
+		if opts.PublicURL == "" {
+			opts.PublicURL = cfg.PublicURL
+		}
+		return NewS3(opts)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Driver)
+	}
+}
+
+// validateKey 校验对象键，本地存储依赖它防止写出根目录
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// joinURL 拼接地址前缀和对象键
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
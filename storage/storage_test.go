@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 实现 PutObject、GetObject、DeleteObject 的本地 S3 服务（路径形式地址），
+// 与 MinIO 一样校验 Signature V4 签名
+type fakeS3 struct {
+	accessKey, secretKey, region string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+var authPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([^,]+), Signature=([0-9a-f]{64})$`)
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{accessKey: "minio", secretKey: "minio-secret", region: "us-east-1",
+		objects: map[string][]byte{}, types: map[string]string{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `<Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error>`)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+		f.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify 按服务端收到的请求重新计算签名
+func (f *fakeS3) verify(r *http.Request) bool {
+	m := authPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil || m[1] != f.accessKey || m[3] != f.region || r.Header.Get("X-Amz-Content-Sha256") != unsignedPayload {
+		return false
+	}
+	r.URL.Host = r.Host
+	signedHeaders, canonical := canonicalRequest(r, unsignedPayload)
+	if signedHeaders != m[4] {
+		return false
+	}
+	digest := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + m[2] + "/" + f.region + "/s3/aws4_request\n" + hex.EncodeToString(digest[:])
+	expected := hex.EncodeToString(hmacSHA256(signingKey(f.secretKey, m[2], f.region, "s3"), stringToSign))
+	return expected == m[5]
+}
+
+func TestS3_PutGetDelete(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	store, err := NewS3(S3Options{Endpoint: srv.URL, Bucket: "media", AccessKey: fake.accessKey, SecretKey: fake.secretKey, PathStyle: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	body := []byte("image bytes")
+	require.NoError(t, store.Put(ctx, "products/1/a b.png", bytes.NewReader(body), int64(len(body)), "image/png"))
+	assert.Equal(t, body, fake.objects["media/products/1/a b.png"])
+	assert.Equal(t, "image/png", fake.types["media/products/1/a b.png"])
+
+	rc, err := store.Get(ctx, "products/1/a b.png")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, body, data)
+
+	require.NoError(t, store.Delete(ctx, "products/1/a b.png"))
+	_, err = store.Get(ctx, "products/1/a b.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "products/1/a b.png"))
+
+	assert.Equal(t, srv.URL+"/media/products/1/a%20b.png", store.URL("products/1/a b.png"))
+
+	// 密钥错误时返回 S3 错误码
+	bad, _ := NewS3(S3Options{Endpoint: srv.URL, Bucket: "media", AccessKey: fake.accessKey, SecretKey: "wrong", PathStyle: true})
+	err = bad.Put(ctx, "x.png", bytes.NewReader(body), int64(len(body)), "image/png")
+	assert.ErrorContains(t, err, "SignatureDoesNotMatch")
+}
+
+func TestS3_VirtualHostedURL(t *testing.T) {
+	store, err := NewS3(S3Options{Endpoint: "https://s3.eu-west-1.amazonaws.com", Region: "eu-west-1", Bucket: "media"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://media.s3.eu-west-1.amazonaws.com/products/1.jpg", store.URL("products/1.jpg"))
+
+	store, _ = NewS3(S3Options{Endpoint: "https://s3.amazonaws.com", Bucket: "media", PublicURL: "https://cdn.example.com/"})
+	assert.Equal(t, "https://cdn.example.com/products/1.jpg", store.URL("products/1.jpg"))
+}
+
+func TestSigningKey(t *testing.T) {
+	// AWS 文档中的签名密钥派生示例
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestLocal_PutGetDelete(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "/uploads/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "products/1/a.png", strings.NewReader("data"), 4, "image/png"))
+	rc, err := store.Get(ctx, "products/1/a.png")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "data", string(data))
+	assert.Equal(t, "/uploads/products/1/a.png", store.URL("products/1/a.png"))
+
+	require.NoError(t, store.Delete(ctx, "products/1/a.png"))
+	_, err = store.Get(ctx, "products/1/a.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "/etc/passwd", "../x", "a/../../x", "a//b", `a\b`} {
+		assert.ErrorIs(t, store.Put(ctx, key, strings.NewReader(""), 0, ""), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin GORM 插件，为每次查询创建子 span。
+// 只有 ctx 中已有被采样的 span（如 HTTP 请求）时才创建，后台任务轮询等没有上游 span 的查询不产生链路
+type GormPlugin struct {
+	tracer *Tracer
+}
+
+// NewGormPlugin 创建 GORM 插件，通过 db.Use 安装
+func NewGormPlugin(tracer *Tracer) *GormPlugin {
+	return &GormPlugin{tracer: tracer}
+}
+
+// Name 实现 gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+type gormRegistrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// Initialize 实现 gorm.Plugin
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation     string
+		before, after gormRegistrar
+	}{
+		{"create", cb.Create().Before("*"), cb.Create().After("*")},
+		{"query", cb.Query().Before("*"), cb.Query().After("*")},
+		{"update", cb.Update().Before("*"), cb.Update().After("*")},
+		{"delete", cb.Delete().Before("*"), cb.Delete().After("*")},
+		{"row", cb.Row().Before("*"), cb.Row().After("*")},
+		{"raw", cb.Raw().Before("*"), cb.Raw().After("*")},
+	}
+	for _, hook := range hooks {
+		if err := hook.before.Register("tracing:before_"+hook.operation, p.start(hook.operation)); err != nil {
+			return err
+		}
+		if err := hook.after.Register("tracing:after_"+hook.operation, p.end(hook.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if !SpanFromContext(ctx).IsRecording() {
+			return
+		}
+		_, span := p.tracer.Start(ctx, "gorm."+operation,
+			WithSpanKind(SpanKindClient),
+			WithAttributes(String("db.system", dbSystem(db.Dialector.Name())), String("db.operation", operation)),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// end 结束 span，span 名称为 "gorm.<操作> <表名>"
+func (p *GormPlugin) end(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span, ok := value.(*Span)
+		if !ok {
+			return
+		}
+
+		if table := db.Statement.Table; table != "" {
+			span.SetName("gorm." + operation + " " + table)
+			span.SetAttributes(String("db.sql.table", table))
+		}
+		// 只记录带占位符的 SQL，不记录参数值
+		span.SetAttributes(
+			String("db.statement", db.Statement.SQL.String()),
+			Int("db.rows_affected", db.RowsAffected),
+		)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(db.Error)
+		}
+		span.End()
+	}
+}
+
+// dbSystem 将 GORM 方言名转换为 OpenTelemetry 的 db.system 取值
+func dbSystem(dialect string) string {
+	if dialect == "postgres" {
+		return "postgresql"
+	}
+	return dialect
+}
@@ -0,0 +1,295 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader W3C Trace Context 请求头
+const TraceparentHeader = "traceparent"
+
+// TraceID 16 字节的链路ID
+type TraceID [16]byte
+
+// IsValid 全 0 的链路ID无效
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String 返回小写十六进制
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID 8 字节的 span ID
+type SpanID [8]byte
+
+// IsValid 全 0 的 span ID 无效
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String 返回小写十六进制
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// SpanContext 跨进程传播的 span 标识
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// Remote 是否从请求头解析得到
+	Remote bool
+}
+
+// IsValid 链路ID和 span ID 都有效
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent 返回 W3C traceparent 头的值
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent 解析 W3C traceparent 头，格式为 version-trace_id-parent_id-flags。
+// 只接受小写十六进制，版本 ff 和全 0 的 ID 无效；未知的更高版本按 00 版本的前四段解析
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || !isLowerHex(version) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent version %q", version)
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 ||
+		!isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+
+	var sc SpanContext
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	var flag [1]byte
+	hex.Decode(flag[:], []byte(flags))
+	sc.Sampled = flag[0]&0x01 == 1
+	sc.Remote = true
+	return sc, nil
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SpanKind span 类型，取值与 OTLP 一致
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode span 状态，取值与 OTLP 一致
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute span 属性，Value 为 string、bool、int64 或 float64
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String 字符串属性
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int 整数属性
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool 布尔属性
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData 结束后交给导出器的 span 数据
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	ParentSpanID  SpanID
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    []Attribute
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Span 进行中的 span，只有被采样的 span 会记录属性并在 End 时导出。
+// 方法可以在 nil 上调用
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext 返回 span 的标识
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// IsRecording span 是否被采样并且尚未结束
+func (s *Span) IsRecording() bool {
+	if s == nil || !s.data.SpanContext.Sampled {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.ended
+}
+
+// SetName 修改 span 名称
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttributes 添加属性
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+	s.mu.Unlock()
+}
+
+// SetStatus 设置状态，message 只在 StatusError 时保留
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+	if code != StatusError {
+		message = ""
+	}
+	s.mu.Lock()
+	s.data.StatusCode = code
+	s.data.StatusMessage = message
+	s.mu.Unlock()
+}
+
+// RecordError 将 span 状态设为错误并记录错误信息
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetAttributes(String("error.message", err.Error()))
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End 结束 span，只有第一次调用生效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled && s.tracer != nil {
+		s.tracer.enqueue(&data)
+	}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan 返回携带 span 的 ctx
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext 返回 ctx 中的 span，没有时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext 返回携带上游 span 标识的 ctx，之后创建的 span 会成为它的子 span
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext 返回 ctx 中当前 span 的标识，没有 span 时返回上游的标识
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
@@ -0,0 +1,239 @@
+// Package tracing 实现分布式链路追踪：W3C traceparent 传播、span 采样和批量导出，
+// 导出格式为 OTLP/HTTP JSON，测试中使用 InMemoryExporter
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config 追踪配置
+type Config struct {
+	// ServiceName 导出时的 service.name 资源属性
+	ServiceName string
+	// Exporter 为 nil 时仍然生成链路ID用于日志关联，但不导出 span
+	Exporter Exporter
+	// SampleRatio 没有上游 span 时的采样比例，0 到 1；有上游时沿用上游的采样决定
+	SampleRatio float64
+	// BatchSize 每批导出的最大 span 数，默认 512
+	BatchSize int
+	// BatchTimeout 导出间隔，默认 5s
+	BatchTimeout time.Duration
+	// MaxQueueSize 等待导出的最大 span 数，超过后丢弃，默认 2048
+	MaxQueueSize int
+}
+
+// Tracer 创建 span 并在后台批量导出结束的 span
+type Tracer struct {
+	cfg       Config
+	threshold uint64
+
+	mu      sync.Mutex
+	queue   []*SpanData
+	dropped int
+	closed  bool
+
+	exportMu sync.Mutex
+	flush    chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTracer 创建 Tracer，有导出器时启动后台导出，需要调用 Shutdown 停止
+func NewTracer(cfg Config) *Tracer {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.BatchTimeout <= 0 {
+		cfg.BatchTimeout = 5 * time.Second
+	}
+	if cfg.MaxQueueSize <= 0 {
+		cfg.MaxQueueSize = 2048
+	}
+
+	t := &Tracer{
+		cfg:     cfg,
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	// 与 OpenTelemetry 的 TraceIDRatioBased 一致，用链路ID的后 8 字节决定是否采样
+	switch {
+	case cfg.SampleRatio >= 1:
+		t.threshold = 1 << 63
+	case cfg.SampleRatio > 0:
+		t.threshold = uint64(cfg.SampleRatio * (1 << 63))
+	}
+
+	if cfg.Exporter != nil {
+		go t.loop()
+	} else {
+		close(t.stopped)
+	}
+	return t
+}
+
+// StartOption 创建 span 的选项
+type StartOption func(*SpanData)
+
+// WithSpanKind 设置 span 类型，默认 SpanKindInternal
+func WithSpanKind(kind SpanKind) StartOption {
+	return func(d *SpanData) {
+		d.Kind = kind
+	}
+}
+
+// WithAttributes 设置初始属性
+func WithAttributes(attrs ...Attribute) StartOption {
+	return func(d *SpanData) {
+		d.Attributes = append(d.Attributes, attrs...)
+	}
+}
+
+// Start 创建 span 并返回携带它的 ctx。ctx 中有 span 或上游标识时创建子 span，否则开始新的链路
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	data := SpanData{Name: name, Kind: SpanKindInternal, StartTime: time.Now()}
+	for _, opt := range opts {
+		opt(&data)
+	}
+
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() {
+		data.SpanContext.TraceID = parent.TraceID
+		data.SpanContext.Sampled = parent.Sampled
+		data.ParentSpanID = parent.SpanID
+	} else {
+		data.SpanContext.TraceID = newTraceID()
+		data.SpanContext.Sampled = t.sample(data.SpanContext.TraceID)
+	}
+	data.SpanContext.SpanID = newSpanID()
+	if !data.SpanContext.Sampled {
+		data.Attributes = nil
+	}
+
+	span := &Span{tracer: t, data: data}
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) sample(id TraceID) bool {
+	return binary.BigEndian.Uint64(id[8:])>>1 < t.threshold
+}
+
+// enqueue 将结束的 span 放入导出队列
+func (t *Tracer) enqueue(data *SpanData) {
+	if t.cfg.Exporter == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.closed || len(t.queue) >= t.cfg.MaxQueueSize {
+		t.dropped++
+		t.mu.Unlock()
+		return
+	}
+	t.queue = append(t.queue, data)
+	full := len(t.queue) >= t.cfg.BatchSize
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.cfg.BatchTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		case <-t.flush:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := t.ForceFlush(ctx); err != nil {
+			log.Printf("Failed to export spans: %v", err)
+		}
+		cancel()
+	}
+}
+
+// ForceFlush 立即导出队列中的所有 span
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	if t.cfg.Exporter == nil {
+		return nil
+	}
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+
+	for {
+		t.mu.Lock()
+		n := len(t.queue)
+		if n > t.cfg.BatchSize {
+			n = t.cfg.BatchSize
+		}
+		batch := t.queue[:n:n]
+		t.queue = t.queue[n:]
+		dropped := t.dropped
+		t.dropped = 0
+		t.mu.Unlock()
+
+		if dropped > 0 {
+			log.Printf("Dropped %d spans because the export queue was full", dropped)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := t.cfg.Exporter.ExportSpans(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+// Shutdown 停止后台导出，导出剩余的 span 并关闭导出器
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	err := t.ForceFlush(ctx)
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+	if t.cfg.Exporter != nil {
+		if shutdownErr := t.cfg.Exporter.Shutdown(ctx); err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func init() {
+	defaultTracer.Store(NewTracer(Config{}))
+}
+
+// SetDefault 设置默认 Tracer，供数据库插件等全局组件使用
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default 返回默认 Tracer，未设置时不采样也不导出
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
@@ -0,0 +1,203 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.True(t, sc.Remote)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	// 未知的更高版本按前四段解析
+	sc, err = ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	require.NoError(t, err)
+	assert.False(t, sc.Sampled)
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceparent(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 1})
+	defer tracer.Shutdown(context.Background())
+	ctx := context.Background()
+
+	// 子 span 继承链路ID，父 span ID 指向父 span
+	ctx1, root := tracer.Start(ctx, "root", WithSpanKind(SpanKindServer), WithAttributes(String("a", "b")))
+	_, child := tracer.Start(ctx1, "child")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End()
+	root.SetAttributes(String("ignored", "after end"))
+
+	require.NoError(t, tracer.ForceFlush(ctx))
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, root.SpanContext().TraceID, spans[0].SpanContext.TraceID)
+	assert.Equal(t, root.SpanContext().SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, StatusError, spans[0].StatusCode)
+	assert.Equal(t, "boom", spans[0].StatusMessage)
+	assert.Equal(t, SpanKindServer, spans[1].Kind)
+	assert.False(t, spans[1].ParentSpanID.IsValid())
+	assert.Equal(t, []Attribute{String("a", "b")}, spans[1].Attributes)
+
+	// 加入上游链路，并沿用上游的采样决定
+	exporter.Reset()
+	remote, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	_, span := tracer.Start(ContextWithRemoteSpanContext(ctx, remote), "unsampled")
+	assert.Equal(t, remote.TraceID, span.SpanContext().TraceID)
+	assert.False(t, span.IsRecording())
+	span.End()
+	remote.Sampled = true
+	_, span = tracer.Start(ContextWithRemoteSpanContext(ctx, remote), "sampled")
+	span.End()
+	require.NoError(t, tracer.ForceFlush(ctx))
+	spans = exporter.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "sampled", spans[0].Name)
+	assert.Equal(t, remote.SpanID, spans[0].ParentSpanID)
+
+	// 采样比例为 0 时仍然生成链路ID，但不导出
+	exporter.Reset()
+	never := NewTracer(Config{Exporter: exporter})
+	_, span = never.Start(ctx, "never")
+	assert.True(t, span.SpanContext().IsValid())
+	span.End()
+	require.NoError(t, never.Shutdown(ctx))
+	assert.Empty(t, exporter.Spans())
+
+	// nil span 的方法可以安全调用
+	var none *Span
+	none.SetAttributes(String("a", "b"))
+	none.End()
+	assert.False(t, SpanContextFromContext(ctx).IsValid())
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 1})
+	require.NoError(t, db.Use(NewGormPlugin(tracer)))
+
+	type item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.Exec("CREATE TABLE items (id integer PRIMARY KEY, name text)").Error)
+
+	// 没有上游 span 的查询不产生链路
+	require.NoError(t, db.Create(&item{Name: "secret"}).Error)
+	require.NoError(t, tracer.ForceFlush(context.Background()))
+	assert.Empty(t, exporter.Spans())
+
+	ctx, root := tracer.Start(context.Background(), "request")
+	var found item
+	require.NoError(t, db.WithContext(ctx).Where("name = ?", "secret").First(&found).Error)
+	assert.ErrorIs(t, db.WithContext(ctx).First(&found, 99).Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.WithContext(ctx).Table("missing").Find(&[]item{}).Error)
+	root.End()
+
+	require.NoError(t, tracer.ForceFlush(context.Background()))
+	spans := exporter.Spans()
+	require.Len(t, spans, 4)
+	query := spans[0]
+	assert.Equal(t, "gorm.query items", query.Name)
+	assert.Equal(t, SpanKindClient, query.Kind)
+	assert.Equal(t, root.SpanContext().SpanID, query.ParentSpanID)
+	attrs := map[string]interface{}{}
+	for _, attr := range query.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, "sqlite", attrs["db.system"])
+	assert.Equal(t, "items", attrs["db.sql.table"])
+	assert.Contains(t, attrs["db.statement"], "name = ?")
+	assert.NotContains(t, attrs["db.statement"], "secret")
+	assert.Equal(t, int64(1), attrs["db.rows_affected"])
+	assert.Equal(t, StatusUnset, spans[1].StatusCode)
+	assert.Equal(t, "gorm.query missing", spans[2].Name)
+	assert.Equal(t, StatusError, spans[2].StatusCode)
+	assert.Equal(t, "request", spans[3].Name)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]interface{}
+	var auth string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &body))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{
+		Endpoint:    server.URL + "/",
+		Headers:     ParseHeaders("Authorization=Bearer token, invalid"),
+		ServiceName: "test-service",
+	})
+	tracer := NewTracer(Config{Exporter: exporter, SampleRatio: 1})
+	ctx, parent := tracer.Start(context.Background(), "parent", WithSpanKind(SpanKindServer))
+	_, span := tracer.Start(ctx, "child", WithAttributes(String("s", "v"), Int("n", 42), Bool("b", true)))
+	span.End()
+	parent.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	assert.Equal(t, "Bearer token", auth)
+	resourceSpans := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	resource := resourceSpans["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "service.name", resource["key"])
+	assert.Equal(t, "test-service", resource["value"].(map[string]interface{})["stringValue"])
+
+	spans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	require.Len(t, spans, 2)
+	child := spans[0].(map[string]interface{})
+	assert.Equal(t, "child", child["name"])
+	assert.Equal(t, span.SpanContext().TraceID.String(), child["traceId"])
+	assert.Equal(t, parent.SpanContext().SpanID.String(), child["parentSpanId"])
+	assert.Equal(t, float64(SpanKindInternal), child["kind"])
+	assert.IsType(t, "", child["startTimeUnixNano"])
+	attrs := child["attributes"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"key": "n", "value": map[string]interface{}{"intValue": "42"}}, attrs[1])
+	assert.Equal(t, map[string]interface{}{"key": "b", "value": map[string]interface{}{"boolValue": true}}, attrs[2])
+	assert.NotContains(t, spans[1].(map[string]interface{}), "parentSpanId")
+
+	status = http.StatusServiceUnavailable
+	err := exporter.ExportSpans(context.Background(), []*SpanData{{Name: "failed"}})
+	assert.ErrorContains(t, err, "503")
+}
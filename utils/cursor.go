@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidCursor 游标格式错误或签名不匹配
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorCodec 将游标编码为带 HMAC 签名的不透明字符串，防止客户端伪造排序键
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec 创建游标编解码器
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{key: []byte(secret)}
+}
+
+// Encode 将游标内容序列化并签名
+func (c *CursorCodec) Encode(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + c.sign(encoded), nil
+}
+
+// Decode 校验签名并反序列化游标内容
+func (c *CursorCodec) Decode(cursor string, v interface{}) error {
+	encoded, signature, ok := strings.Cut(cursor, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.sign(encoded))) {
+		return ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (c *CursorCodec) sign(encoded string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CursorPaginatedResponse 游标分页响应，只有请求总数时才返回 total
+type CursorPaginatedResponse struct {
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+	Total      *int64      `json:"total,omitempty"`
+}
+
+// CursorPaginatedSuccessResponse 游标分页成功响应
+func CursorPaginatedSuccessResponse(c *gin.Context, data interface{}, limit int, nextCursor, prevCursor string, total *int64) {
+	c.JSON(200, CursorPaginatedResponse{
+		Code:       200,
+		Message:    "success",
+		Data:       data,
+		Limit:      limit,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+		Total:      total,
+	})
+}
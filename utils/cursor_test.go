@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	payload := map[string]interface{}{"s": "-price,id", "v": []string{"10", "3"}}
+
+	encoded, err := codec.Encode(payload)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, codec.Decode(encoded, &decoded))
+	assert.Equal(t, "-price,id", decoded["s"])
+
+	// 篡改内容或使用其他密钥签名的游标无效
+	assert.ErrorIs(t, codec.Decode("x"+encoded, &decoded), ErrInvalidCursor)
+	assert.ErrorIs(t, NewCursorCodec("other").Decode(encoded, &decoded), ErrInvalidCursor)
+	assert.ErrorIs(t, codec.Decode("garbage", &decoded), ErrInvalidCursor)
+}
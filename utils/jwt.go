@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidToken token 无效（签名、格式、签发者或受众不匹配）
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken token 已过期
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Claims JWT 声明
+type Claims struct {
+	UserID   uint   `json:"uid"`
+	Username string `json:"username,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenService JWT 签发与校验服务
+type TokenService struct {
+	method            jwt.SigningMethod
+	signKey           interface{}
+	verifyKey         interface{}
+	issuer            string
+	audience          string
+	expiration        time.Duration
+	refreshExpiration time.Duration
+}
+
+// NewTokenService 根据配置创建 token 服务，支持 HS256 和 RS256
+func NewTokenService(cfg config.JWTConfig) (*TokenService, error) {
+	s := &TokenService{
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		expiration: time.Duration(cfg.Expiration) * time.Hour,
+	}
+	if s.expiration <= 0 {
+		s.expiration = 24 * time.Hour
+	}
+	s.refreshExpiration = time.Duration(cfg.RefreshExpiration) * time.Hour
+	if s.refreshExpiration <= 0 {
+		s.refreshExpiration = 30 * 24 * time.Hour
+	}
+
+	switch cfg.Algorithm {
+	case "", "HS256":
+		if cfg.Secret == "" {
+			return nil, errors.New("jwt: JWT_SECRET is required for HS256")
+		}
+		s.method = jwt.SigningMethodHS256
+		s.signKey = []byte(cfg.Secret)
+		s.verifyKey = []byte(cfg.Secret)
+	case "RS256":
+		s.method = jwt.SigningMethodRS256
+		if err := s.loadRSAKeys(cfg.PrivateKeyPath, cfg.PublicKeyPath); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	return s, nil
+}
+
+// loadRSAKeys 加载 RSA 密钥，只提供私钥时从私钥推导公钥
+func (s *TokenService) loadRSAKeys(privatePath, publicPath string) error {
+	if privatePath != "" {
+		pem, err := os.ReadFile(privatePath)
+		if err != nil {
+			return fmt.Errorf("jwt: failed to read private key: %w", err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return fmt.Errorf("jwt: failed to parse private key: %w", err)
+		}
+		s.signKey = key
+		s.verifyKey = &key.PublicKey
+	}
+
+	if publicPath != "" {
+		pem, err := os.ReadFile(publicPath)
+		if err != nil {
+			return fmt.Errorf("jwt: failed to read public key: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return fmt.Errorf("jwt: failed to parse public key: %w", err)
+		}
+		s.verifyKey = key
+	}
+
+	if s.verifyKey == nil {
+		return errors.New("jwt: JWT_PRIVATE_KEY_PATH or JWT_PUBLIC_KEY_PATH is required for RS256")
+	}
+	return nil
+}
+
+// GenerateToken 为用户签发访问 token，返回 token 及其过期时间
+func (s *TokenService) GenerateToken(userID uint, username string) (string, time.Time, error) {
+	if s.signKey == nil {
+		return "", time.Time{}, errors.New("jwt: no signing key configured")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.expiration)
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   fmt.Sprintf("%d", userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	token, err := jwt.NewWithClaims(s.method, claims).SignedString(s.signKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("jwt: failed to sign token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseToken 校验 token 的签名、有效期、签发者和受众，并返回声明
+func (s *TokenService) ParseToken(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{s.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return s.verifyKey, nil
+	}, opts...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+	if !token.Valid || claims.UserID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// Expiration 返回访问 token 的有效期
+func (s *TokenService) Expiration() time.Duration {
+	return s.expiration
+}
+
+// RefreshExpiration 返回刷新令牌的有效期
+func (s *TokenService) RefreshExpiration() time.Duration {
+	return s.refreshExpiration
+}
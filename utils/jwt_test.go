@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func testJWTConfig() config.JWTConfig {
+	return config.JWTConfig{
+		Secret:     "test-secret",
+		Expiration: 1,
+		Algorithm:  "HS256",
+		Issuer:     "test-issuer",
+		Audience:   "test-audience",
+	}
+}
+
+func TestTokenService_HS256(t *testing.T) {
+	svc, err := NewTokenService(testJWTConfig())
+	assert.NoError(t, err)
+
+	token, expiresAt, err := svc.GenerateToken(42, "alice")
+	assert.NoError(t, err)
+	assert.True(t, expiresAt.After(time.Now()))
+
+	claims, err := svc.ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), claims.UserID)
+	assert.Equal(t, "alice", claims.Username)
+}
+
+func TestTokenService_RejectsWrongSecret(t *testing.T) {
+	svc, _ := NewTokenService(testJWTConfig())
+	token, _, _ := svc.GenerateToken(1, "alice")
+
+	cfg := testJWTConfig()
+	cfg.Secret = "other-secret"
+	other, _ := NewTokenService(cfg)
+
+	_, err := other.ParseToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenService_RejectsWrongAudience(t *testing.T) {
+	svc, _ := NewTokenService(testJWTConfig())
+	token, _, _ := svc.GenerateToken(1, "alice")
+
+	cfg := testJWTConfig()
+	cfg.Audience = "someone-else"
+	other, _ := NewTokenService(cfg)
+
+	_, err := other.ParseToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenService_RejectsExpired(t *testing.T) {
+	cfg := testJWTConfig()
+	claims := Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    cfg.Issuer,
+			Audience:  jwt.ClaimStrings{cfg.Audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		},
+	}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Secret))
+
+	svc, _ := NewTokenService(cfg)
+	_, err := svc.ParseToken(token)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestTokenService_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+
+	cfg := testJWTConfig()
+	cfg.Algorithm = "RS256"
+	cfg.PrivateKeyPath = path
+	svc, err := NewTokenService(cfg)
+	assert.NoError(t, err)
+
+	token, _, err := svc.GenerateToken(7, "bob")
+	assert.NoError(t, err)
+
+	claims, err := svc.ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+
+	// HS256 token 不能通过 RS256 校验
+	hs, _ := NewTokenService(testJWTConfig())
+	hsToken, _, _ := hs.GenerateToken(7, "bob")
+	_, err = svc.ParseToken(hsToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/fangyanlin/gin-gorm-app/tracing"
 	"github.com/gin-gonic/gin"
 )
 
@@ -9,6 +10,8 @@ type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	// TraceID 错误响应中的链路ID，用于根据用户反馈查找链路和日志
+	TraceID string `json:"trace_id,omitempty"`
 }
 
 // SuccessResponse 成功响应
@@ -29,12 +32,18 @@ func CreatedResponse(c *gin.Context, data interface{}) {
 	})
 }
 
-// ErrorResponse 错误响应
+// ErrorResponse 错误响应，开启链路追踪时带上当前请求的链路ID
 func ErrorResponse(c *gin.Context, code int, message string) {
-	c.JSON(code, Response{
+	response := Response{
 		Code:    code,
 		Message: message,
-	})
+	}
+	if c.Request != nil {
+		if sc := tracing.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			response.TraceID = sc.TraceID.String()
+		}
+	}
+	c.JSON(code, response)
 }
 
 // BadRequestResponse 400 错误响应
@@ -57,6 +66,11 @@ func NotFoundResponse(c *gin.Context, message string) {
 	ErrorResponse(c, 404, message)
 }
 
+// ConflictResponse 409 错误响应
+func ConflictResponse(c *gin.Context, message string) {
+	ErrorResponse(c, 409, message)
+}
+
 // InternalServerErrorResponse 500 错误响应
 func InternalServerErrorResponse(c *gin.Context, message string) {
 	ErrorResponse(c, 500, message)
@@ -70,6 +84,7 @@ type PaginatedResponse struct {
 	Page    int         `json:"page"`
 	PerPage int         `json:"per_page"`
 	Total   int64       `json:"total"`
+	Facets  interface{} `json:"facets,omitempty"`
 }
 
 // PaginatedSuccessResponse 分页成功响应
@@ -83,3 +98,16 @@ func PaginatedSuccessResponse(c *gin.Context, data interface{}, page, perPage in
 		Total:   total,
 	})
 }
+
+// FacetedPaginatedSuccessResponse 带分面统计的分页成功响应
+func FacetedPaginatedSuccessResponse(c *gin.Context, data, facets interface{}, page, perPage int, total int64) {
+	c.JSON(200, PaginatedResponse{
+		Code:    200,
+		Message: "success",
+		Data:    data,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		Facets:  facets,
+	})
+}
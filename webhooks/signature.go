@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 投递请求的请求头
+const (
+	// HeaderEventID 事件的幂等键，重试和重放时不变
+	HeaderEventID = "X-Webhook-ID"
+	// HeaderEvent 事件类型
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderTimestamp 发送时间（Unix 秒），参与签名，接收方据此拒绝过期的请求
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature 签名，格式为 sha256=<hex>
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign 计算签名：以 secret 为密钥对 "<timestamp>.<body>" 做 HMAC-SHA256
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验投递请求的签名和时间戳，供接收方使用。tolerance 为允许的时钟偏差，为 0 时不检查时间戳
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signature := header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, "sha256=") ||
+		!hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		skew := now.Sub(time.Unix(timestamp, 0))
+		if skew > tolerance || skew < -tolerance {
+			return ErrExpiredTimestamp
+		}
+	}
+	return nil
+}
+
+// NewSecret 生成随机签名密钥
+func NewSecret() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b[:]), nil
+}
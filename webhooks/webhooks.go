@@ -0,0 +1,229 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/jobs"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"gorm.io/gorm"
+)
+
+// JobType 投递任务的类型，需要在 worker 中注册 Service.Deliver
+const JobType = "webhook.deliver"
+
+// maxResponseBody 投递记录中保存的响应体长度上限
+const maxResponseBody = 1024
+
+// Config webhook 投递配置，零值字段使用默认值
+type Config struct {
+	// Queue 投递任务所在的队列，需要在 JOBS_QUEUES 中配置，默认 default
+	Queue string
+	// MaxAttempts 每个事件向每个 webhook 投递的最大次数，默认 8
+	MaxAttempts int
+	// Timeout 单次请求的超时时间，默认 10s
+	Timeout time.Duration
+	// DisableAfter 连续失败多少次后停用 webhook，默认 20
+	DisableAfter int
+	// Client 发送请求的 HTTP 客户端，默认 http.DefaultClient
+	Client *http.Client
+}
+
+// Event 投递请求体
+type Event struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      models.RawJSON `json:"data"`
+}
+
+// Delivery 投递任务的参数
+type Delivery struct {
+	WebhookID uint           `json:"webhook_id"`
+	EventKey  string         `json:"event_key"`
+	EventType string         `json:"event_type"`
+	Body      models.RawJSON `json:"body"`
+	ReplayOf  *uint          `json:"replay_of,omitempty"`
+}
+
+// Service 将领域事件投递给订阅的 webhook。事件由 outbox dispatcher 交给 HandleEvent，
+// 为每个订阅的 webhook 创建一个后台任务，由 worker 调用 Deliver 发送，失败时按任务队列的退避策略重试
+type Service struct {
+	db   *gorm.DB
+	repo *repository.WebhookRepository
+	cfg  Config
+	now  func() time.Time
+}
+
+// NewService 创建 webhook 投递服务
+func NewService(db *gorm.DB, cfg Config) *Service {
+	if cfg.Queue == "" {
+		cfg.Queue = jobs.DefaultQueue
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.DisableAfter <= 0 {
+		cfg.DisableAfter = 20
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Service{
+		db:   db,
+		repo: repository.NewWebhookRepository(db),
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// HandleEvent outbox 订阅者：为订阅了该事件的每个 webhook 创建投递任务，所有任务在同一事务中入队
+func (s *Service) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	webhooks, err := repository.NewWebhookRepository(s.db.WithContext(ctx)).FindSubscribed(event.Type)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	body, err := json.Marshal(Event{
+		ID:        event.Key,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, webhook := range webhooks {
+			_, err := s.enqueue(ctx, tx, Delivery{
+				WebhookID: webhook.ID,
+				EventKey:  event.Key,
+				EventType: event.Type,
+				Body:      body,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Replay 将一次投递记录的请求体重新投递给其 webhook，事件 ID 不变，签名和时间戳重新生成
+func (s *Service) Replay(ctx context.Context, delivery *models.WebhookDelivery) (*models.Job, error) {
+	webhook, err := s.repo.FindByID(delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, repository.ErrWebhookDisabled
+	}
+	replayOf := delivery.ID
+	return s.enqueue(ctx, s.db, Delivery{
+		WebhookID: delivery.WebhookID,
+		EventKey:  delivery.EventKey,
+		EventType: delivery.EventType,
+		Body:      delivery.Payload,
+		ReplayOf:  &replayOf,
+	})
+}
+
+func (s *Service) enqueue(ctx context.Context, db *gorm.DB, delivery Delivery) (*models.Job, error) {
+	return jobs.Enqueue(ctx, db, JobType, delivery, jobs.Options{
+		Queue:       s.cfg.Queue,
+		MaxAttempts: s.cfg.MaxAttempts,
+	})
+}
+
+// Deliver 投递任务的处理函数，用 jobs.Handle(s.Deliver) 注册。每次尝试都记录投递日志；
+// 返回错误时任务重试，webhook 被停用后剩余的投递直接进入死信状态，已删除的 webhook 不再投递
+func (s *Service) Deliver(ctx context.Context, d Delivery) error {
+	webhook, err := s.repo.FindByID(d.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !webhook.Active {
+		return jobs.Permanent(repository.ErrWebhookDisabled)
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventKey:  d.EventKey,
+		EventType: d.EventType,
+		Payload:   d.Body,
+		Attempt:   1,
+		ReplayOf:  d.ReplayOf,
+	}
+	if job, ok := jobs.FromContext(ctx); ok {
+		delivery.Attempt = job.Attempts
+	}
+
+	sendErr := s.send(ctx, webhook, d, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// worker 停止或任务超时，由任务队列重新排队，不计入 webhook 的失败次数
+		return sendErr
+	}
+	delivery.Success = sendErr == nil
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	disabled, err := s.repo.RecordDelivery(delivery, s.cfg.DisableAfter, s.now())
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("Webhook %d disabled after %d consecutive failed deliveries", webhook.ID, s.cfg.DisableAfter)
+		return jobs.Permanent(sendErr)
+	}
+	return sendErr
+}
+
+// send 发送签名的 POST 请求，2xx 响应视为成功，请求结果写入 delivery
+func (s *Service) send(ctx context.Context, webhook *models.Webhook, d Delivery, delivery *models.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gin-gorm-app-webhooks/1.0")
+	req.Header.Set(HeaderEventID, d.EventKey)
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, d.Body))
+
+	start := time.Now()
+	resp, err := s.cfg.Client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fangyanlin/gin-gorm-app/jobs"
+	"github.com/fangyanlin/gin-gorm-app/models"
+	"github.com/fangyanlin/gin-gorm-app/outbox"
+	"github.com/fangyanlin/gin-gorm-app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testSecret = "test-secret-0123456789"
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Job{}, &models.OutboxEvent{}, &models.OutboxDelivery{},
+		&models.Webhook{}, &models.WebhookDelivery{}))
+	return db
+}
+
+// receiver 本地的 webhook 接收方，校验签名并按 statuses 依次返回状态码，用完后返回 200
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+	errors   []error
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		r.errors = append(r.errors, Verify(testSecret, req.Header, body, time.Minute, time.Now()))
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func createWebhook(t *testing.T, db *gorm.DB, url string, events ...string) *models.Webhook {
+	webhook := &models.Webhook{URL: url, Events: events, Secret: testSecret, Active: true}
+	require.NoError(t, repository.NewWebhookRepository(db).Create(webhook))
+	return webhook
+}
+
+// pendingDeliveries 返回已入队的投递任务参数
+func pendingDeliveries(t *testing.T, db *gorm.DB) []Delivery {
+	var queued []models.Job
+	require.NoError(t, db.Where("type = ?", JobType).Order("id").Find(&queued).Error)
+	deliveries := make([]Delivery, len(queued))
+	for i, job := range queued {
+		require.NoError(t, json.Unmarshal(job.Payload, &deliveries[i]))
+	}
+	return deliveries
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1700000000, 0)
+	header := http.Header{}
+	header.Set(HeaderTimestamp, "1700000000")
+	header.Set(HeaderSignature, Sign(testSecret, now.Unix(), body))
+
+	assert.NoError(t, Verify(testSecret, header, body, time.Minute, now))
+	assert.ErrorIs(t, Verify("other-secret", header, body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, header, []byte(`{"id":"2"}`), time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, header, body, time.Minute, now.Add(2*time.Minute)), ErrExpiredTimestamp)
+
+	// 时间戳参与签名，不能单独修改
+	header.Set(HeaderTimestamp, "1700000060")
+	assert.ErrorIs(t, Verify(testSecret, header, body, 0, now), ErrInvalidSignature)
+
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, len("whsec_")+64)
+}
+
+func TestService_FanOutAndSignedDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	server := newReceiver(t)
+	service := NewService(db, Config{})
+	ctx := context.Background()
+
+	users := createWebhook(t, db, server.URL+"/users", models.EventUserCreated)
+	createWebhook(t, db, server.URL+"/products", "product.*")
+	disabled := createWebhook(t, db, server.URL+"/disabled", "*")
+	disabled.Active = false
+	require.NoError(t, repository.NewWebhookRepository(db).Update(disabled))
+
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: "secret-hash"}
+	require.NoError(t, repository.NewUserRepository(db).Create(user))
+	var event models.OutboxEvent
+	require.NoError(t, db.First(&event).Error)
+
+	// 只为订阅了该事件的启用中的 webhook 入队
+	require.NoError(t, service.HandleEvent(ctx, &event))
+	deliveries := pendingDeliveries(t, db)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, users.ID, deliveries[0].WebhookID)
+
+	require.NoError(t, service.Deliver(ctx, deliveries[0]))
+	require.Equal(t, 1, server.count())
+	req, body := server.requests[0], server.bodies[0]
+	assert.NoError(t, server.errors[0])
+	assert.Equal(t, "/users", req.URL.Path)
+	assert.Equal(t, event.Key, req.Header.Get(HeaderEventID))
+	assert.Equal(t, models.EventUserCreated, req.Header.Get(HeaderEvent))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+	var sent Event
+	require.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, event.Key, sent.ID)
+	assert.Equal(t, models.EventUserCreated, sent.Type)
+	assert.Contains(t, string(sent.Data), "alice@example.com")
+	assert.NotContains(t, string(body), "secret-hash")
+
+	logs, err := repository.NewWebhookRepository(db).FindDeliveries(users.ID, repository.DeliveryFilter{}, &models.Pagination{})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.True(t, logs[0].Success)
+	assert.Equal(t, http.StatusOK, logs[0].StatusCode)
+	assert.Equal(t, "ok", logs[0].ResponseBody)
+	assert.JSONEq(t, string(body), string(logs[0].Payload))
+}
+
+func TestService_FailuresAutoDisableAndReplay(t *testing.T) {
+	db := setupTestDB(t)
+	server := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	service := NewService(db, Config{DisableAfter: 3})
+	repo := repository.NewWebhookRepository(db)
+	ctx := context.Background()
+
+	webhook := createWebhook(t, db, server.URL, "*")
+	delivery := Delivery{WebhookID: webhook.ID, EventKey: "key-1", EventType: models.EventUserCreated, Body: models.RawJSON(`{"id":"key-1"}`)}
+
+	// 失败时返回错误由任务队列重试，并记录每次尝试
+	err := service.Deliver(ctx, delivery)
+	require.Error(t, err)
+	assert.False(t, jobs.IsPermanent(err))
+	assert.Contains(t, err.Error(), "500")
+	require.Error(t, service.Deliver(ctx, delivery))
+
+	found, _ := repo.FindByID(webhook.ID)
+	assert.True(t, found.Active)
+	assert.Equal(t, 2, found.ConsecutiveFailures)
+
+	// 连续失败达到上限后停用，剩余的投递不再重试
+	err = service.Deliver(ctx, delivery)
+	assert.True(t, jobs.IsPermanent(err))
+	found, _ = repo.FindByID(webhook.ID)
+	assert.False(t, found.Active)
+	assert.NotNil(t, found.DisabledAt)
+	assert.Contains(t, found.DisabledReason, "3 consecutive failed deliveries")
+	err = service.Deliver(ctx, delivery)
+	assert.ErrorIs(t, err, repository.ErrWebhookDisabled)
+	assert.True(t, jobs.IsPermanent(err))
+	assert.Equal(t, 3, server.count())
+
+	failed := false
+	logs, err := repo.FindDeliveries(webhook.ID, repository.DeliveryFilter{Success: &failed}, &models.Pagination{})
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, logs[0].StatusCode)
+
+	// 停用的 webhook 不能重放，重新启用后可以，重放保留事件 ID
+	_, err = service.Replay(ctx, &logs[0])
+	assert.ErrorIs(t, err, repository.ErrWebhookDisabled)
+	found.Active = true
+	found.ConsecutiveFailures = 0
+	require.NoError(t, repo.Update(found))
+
+	job, err := service.Replay(ctx, &logs[0])
+	require.NoError(t, err)
+	assert.Equal(t, JobType, job.Type)
+	replays := pendingDeliveries(t, db)
+	require.Len(t, replays, 1)
+	require.NoError(t, service.Deliver(ctx, replays[0]))
+	assert.Equal(t, 4, server.count())
+	assert.NoError(t, server.errors[3])
+	assert.Equal(t, "key-1", server.requests[3].Header.Get(HeaderEventID))
+
+	logs, err = repo.FindDeliveries(webhook.ID, repository.DeliveryFilter{}, &models.Pagination{})
+	require.NoError(t, err)
+	assert.True(t, logs[0].Success)
+	require.NotNil(t, logs[0].ReplayOf)
+	assert.Equal(t, logs[1].ID, *logs[0].ReplayOf)
+	found, _ = repo.FindByID(webhook.ID)
+	assert.Zero(t, found.ConsecutiveFailures)
+}
+
+func TestService_EndToEndWithWorkerAndDispatcher(t *testing.T) {
+	db := setupTestDB(t)
+	server := newReceiver(t, http.StatusInternalServerError)
+	service := NewService(db, Config{})
+	ctx := context.Background()
+	createWebhook(t, db, server.URL, models.EventUserCreated)
+
+	worker := jobs.NewWorker(db, jobs.Config{
+		Queues:       map[string]int{jobs.DefaultQueue: 1},
+		PollInterval: 10 * time.Millisecond,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   time.Millisecond,
+	})
+	worker.Register(JobType, jobs.Handle(service.Deliver))
+	dispatcher := outbox.NewDispatcher(db, outbox.Config{PollInterval: 10 * time.Millisecond})
+	dispatcher.Subscribe("webhooks", service.HandleEvent)
+	require.NoError(t, worker.Start(ctx))
+	require.NoError(t, dispatcher.Start(ctx))
+
+	require.NoError(t, repository.NewUserRepository(db).Create(&models.User{Username: "bob", Email: "bob@example.com", Password: "x"}))
+
+	// 第一次返回 500，重试后成功，两次请求的事件 ID 相同
+	require.Eventually(t, func() bool { return server.count() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, dispatcher.Stop(ctx))
+	require.NoError(t, worker.Stop(ctx))
+
+	assert.Equal(t, server.requests[0].Header.Get(HeaderEventID), server.requests[1].Header.Get(HeaderEventID))
+	assert.NoError(t, server.errors[1])
+	var logs []models.WebhookDelivery
+	require.NoError(t, db.Order("id").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Equal(t, []int{1, 2}, []int{logs[0].Attempt, logs[1].Attempt})
+	assert.False(t, logs[0].Success)
+	assert.True(t, logs[1].Success)
+}